@@ -0,0 +1,19 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- name and sku weigh more than notes when ranking results
+ALTER TABLE "products"
+  ADD COLUMN IF NOT EXISTS "search_vector" tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce("name", '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce("sku", '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce("notes", '')), 'B')
+  ) STORED;
+
+CREATE INDEX IF NOT EXISTS "products_search_vector_idx"
+  ON "products" USING GIN ("search_vector");
+
+-- trigram indexes serve both the fuzzy match and the existing `name ilike` filter
+CREATE INDEX IF NOT EXISTS "products_name_trgm_idx"
+  ON "products" USING GIN ("name" gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS "products_sku_trgm_idx"
+  ON "products" USING GIN ("sku" gin_trgm_ops);
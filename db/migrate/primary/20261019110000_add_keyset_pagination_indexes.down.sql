@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "orders_created_at_id_idx";
+
+DROP INDEX IF EXISTS "customers_created_at_id_idx";
+
+DROP INDEX IF EXISTS "products_created_at_id_idx";
@@ -0,0 +1,10 @@
+-- keyset pagination walks (created_at, id) in both directions
+CREATE INDEX IF NOT EXISTS "products_created_at_id_idx"
+  ON "products" ("created_at", "id")
+  WHERE "deleted_at" IS NULL;
+
+CREATE INDEX IF NOT EXISTS "customers_created_at_id_idx"
+  ON "customers" ("created_at", "id");
+
+CREATE INDEX IF NOT EXISTS "orders_created_at_id_idx"
+  ON "orders" ("created_at", "id");
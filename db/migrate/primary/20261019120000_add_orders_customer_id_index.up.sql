@@ -0,0 +1,3 @@
+-- backs the "purchased since" and lifetime spend customer filters
+CREATE INDEX IF NOT EXISTS "orders_customer_id_created_at_idx"
+  ON "orders" ("customer_id", "created_at");
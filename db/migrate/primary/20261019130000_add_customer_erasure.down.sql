@@ -0,0 +1,33 @@
+DROP INDEX IF EXISTS "customers_phone_number_active_key";
+
+-- erased customers all have '' as their phone number, and deleted ones can
+-- share theirs with a customer created after them. Keep the number on the
+-- active (or else newest) row and give the others a placeholder that is
+-- unique and still fits varchar(20), so the old constraint can come back.
+WITH "ranked" AS (
+  SELECT
+    "id",
+    ROW_NUMBER() OVER (
+      PARTITION BY "phone_number"
+      ORDER BY ("deleted_at" IS NULL) DESC, "created_at" DESC, "id"
+    ) AS "rn"
+  FROM "customers"
+),
+"duplicates" AS (
+  SELECT
+    "id",
+    ROW_NUMBER() OVER (ORDER BY "id") AS "seq"
+  FROM "ranked"
+  WHERE "rn" > 1 OR "id" IN (
+    SELECT "id" FROM "customers" WHERE "phone_number" = ''
+  )
+)
+UPDATE "customers" AS c
+SET "phone_number" = 'removed-' || d."seq"
+FROM "duplicates" AS d
+WHERE c."id" = d."id";
+
+ALTER TABLE "customers"
+  ADD CONSTRAINT "customers_phone_number_key" UNIQUE ("phone_number");
+
+ALTER TABLE "customers" DROP COLUMN IF EXISTS "erased_at";
@@ -0,0 +1,11 @@
+ALTER TABLE "customers"
+  ADD COLUMN IF NOT EXISTS "erased_at" timestamp NULL DEFAULT NULL;
+
+-- deleted and erased customers give their phone number back, so only
+-- active rows have to be unique
+ALTER TABLE "customers"
+  DROP CONSTRAINT IF EXISTS "customers_phone_number_key";
+
+CREATE UNIQUE INDEX IF NOT EXISTS "customers_phone_number_active_key"
+  ON "customers" ("phone_number")
+  WHERE "deleted_at" IS NULL;
@@ -0,0 +1 @@
+-- the totals are kept, they are what the orders were charged
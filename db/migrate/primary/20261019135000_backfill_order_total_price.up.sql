@@ -0,0 +1,12 @@
+-- orders used to be saved without their total, every order so far was
+-- charged the full price of its lines. The customer stats and the minSpend
+-- filter sum the totals of the orders.
+UPDATE "orders" o SET
+  "total_price" = lines."total"
+FROM (
+  SELECT "order_id", sum("total_price") AS "total"
+  FROM "order_product"
+  GROUP BY "order_id"
+) lines
+WHERE lines."order_id" = o."id"
+  AND o."total_price" = 0;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "customers_name_trgm_idx";
+
+DROP INDEX IF EXISTS "customers_phone_number_digits_idx";
+
+DROP TABLE IF EXISTS "customer_merges";
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS "customer_merges" (
+  "id" uuid NOT NULL,
+  "survivor_id" uuid NOT NULL,
+  "duplicate_id" uuid NOT NULL,
+  "duplicate_name" varchar(50) NOT NULL,
+  "duplicate_phone_number" varchar(20) NOT NULL,
+  "orders_moved" int NOT NULL,
+  "merged_by" uuid NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("id"),
+  FOREIGN KEY ("survivor_id") REFERENCES "customers" ("id"),
+  FOREIGN KEY ("duplicate_id") REFERENCES "customers" ("id"),
+  FOREIGN KEY ("merged_by") REFERENCES "users" ("id")
+);
+
+-- duplicate candidates are matched on digits only phone numbers and on
+-- name similarity
+CREATE INDEX IF NOT EXISTS "customers_phone_number_digits_idx"
+  ON "customers" (regexp_replace("phone_number", '\D', '', 'g'))
+  WHERE "deleted_at" IS NULL;
+
+CREATE INDEX IF NOT EXISTS "customers_name_trgm_idx"
+  ON "customers" USING GIN ("name" gin_trgm_ops);
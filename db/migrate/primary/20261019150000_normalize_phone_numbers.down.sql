@@ -0,0 +1,10 @@
+-- normalized numbers are kept, the original formatting cannot be restored
+DROP TABLE IF EXISTS "phone_number_normalization_collisions";
+
+ALTER TABLE "customers"
+  DROP COLUMN IF EXISTS "phone_region",
+  DROP COLUMN IF EXISTS "phone_country_code";
+
+ALTER TABLE "users"
+  DROP COLUMN IF EXISTS "phone_region",
+  DROP COLUMN IF EXISTS "phone_country_code";
@@ -0,0 +1,174 @@
+ALTER TABLE "users"
+  ADD COLUMN IF NOT EXISTS "phone_country_code" varchar(3) NULL DEFAULT NULL,
+  ADD COLUMN IF NOT EXISTS "phone_region" char(2) NULL DEFAULT NULL;
+
+ALTER TABLE "customers"
+  ADD COLUMN IF NOT EXISTS "phone_country_code" varchar(3) NULL DEFAULT NULL,
+  ADD COLUMN IF NOT EXISTS "phone_region" char(2) NULL DEFAULT NULL;
+
+-- rows whose number would collide with another row once normalized keep
+-- their original number and are listed here, to be merged or fixed by hand
+CREATE TABLE IF NOT EXISTS "phone_number_normalization_collisions" (
+  "table_name" varchar(20) NOT NULL,
+  "row_id" uuid NOT NULL,
+  "original_phone_number" varchar(20) NOT NULL,
+  "normalized_phone_number" varchar(20) NOT NULL,
+  "kept_row_id" uuid NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("table_name", "row_id")
+);
+
+-- same calling codes as util.callingCodes, keep_trunk_zero is set where a
+-- leading zero after the calling code is part of the number
+CREATE TEMPORARY TABLE "phone_calling_codes" (
+  "code" varchar(3) NOT NULL,
+  "region" char(2) NOT NULL,
+  "keep_trunk_zero" boolean NOT NULL DEFAULT false
+);
+
+INSERT INTO "phone_calling_codes" ("code", "region") VALUES
+  ('1', 'US'), ('7', 'RU'), ('20', 'EG'), ('27', 'ZA'), ('31', 'NL'),
+  ('33', 'FR'), ('34', 'ES'), ('44', 'GB'), ('49', 'DE'),
+  ('60', 'MY'), ('61', 'AU'), ('62', 'ID'), ('63', 'PH'), ('64', 'NZ'),
+  ('65', 'SG'), ('66', 'TH'), ('81', 'JP'), ('82', 'KR'), ('84', 'VN'),
+  ('86', 'CN'), ('90', 'TR'), ('91', 'IN'), ('92', 'PK'), ('670', 'TL'),
+  ('673', 'BN'), ('852', 'HK'), ('853', 'MO'), ('855', 'KH'), ('856', 'LA'),
+  ('886', 'TW'), ('966', 'SA'), ('971', 'AE');
+
+INSERT INTO "phone_calling_codes" ("code", "region", "keep_trunk_zero") VALUES
+  ('39', 'IT', true);
+
+-- the E.164 form util.NormalizePhoneNumber gives: a 00 prefix is taken as
+-- the plus sign, everything but digits is dropped and a zero written after
+-- the calling code is dropped unless the country keeps it. The lengths are
+-- not checked, the numbers were accepted when they were stored.
+CREATE FUNCTION pg_temp.normalize_phone_number(raw text) RETURNS text AS $$
+  WITH "digits" AS (
+    SELECT regexp_replace(
+      regexp_replace(btrim(raw), '^00', ''),
+      '\D', '', 'g'
+    ) AS "number"
+  )
+  SELECT '+' || coalesce(
+    (
+      SELECT cc."code" || CASE
+        WHEN NOT cc."keep_trunk_zero"
+          AND substr(d."number", length(cc."code") + 1, 1) = '0'
+        THEN substr(d."number", length(cc."code") + 2)
+        ELSE substr(d."number", length(cc."code") + 1)
+      END
+      FROM "phone_calling_codes" cc
+      WHERE d."number" LIKE cc."code" || '%'
+        AND length(d."number") > length(cc."code")
+      ORDER BY length(cc."code")
+      LIMIT 1
+    ),
+    d."number"
+  )
+  FROM "digits" d
+$$ LANGUAGE sql STABLE;
+
+-- users: a number that is already canonical wins, then the oldest row
+WITH "normalized" AS (
+  SELECT
+    "id",
+    "phone_number",
+    pg_temp.normalize_phone_number("phone_number") AS "normalized_phone_number",
+    first_value("id") OVER (
+      PARTITION BY pg_temp.normalize_phone_number("phone_number")
+      ORDER BY
+        "phone_number" = pg_temp.normalize_phone_number("phone_number") DESC,
+        "created_at",
+        "id"
+    ) AS "kept_row_id"
+  FROM "users"
+)
+INSERT INTO "phone_number_normalization_collisions"
+  ("table_name", "row_id", "original_phone_number", "normalized_phone_number", "kept_row_id")
+SELECT 'users', "id", "phone_number", "normalized_phone_number", "kept_row_id"
+FROM "normalized"
+WHERE "id" <> "kept_row_id"
+ON CONFLICT DO NOTHING;
+
+UPDATE "users" u
+SET "phone_number" = pg_temp.normalize_phone_number(u."phone_number")
+WHERE u."phone_number" <> pg_temp.normalize_phone_number(u."phone_number")
+  AND NOT EXISTS (
+    SELECT 1 FROM "phone_number_normalization_collisions" c
+    WHERE c."table_name" = 'users' AND c."row_id" = u."id"
+  );
+
+-- customers: only active rows have to be unique, erased rows have no number
+WITH "normalized" AS (
+  SELECT
+    "id",
+    "phone_number",
+    pg_temp.normalize_phone_number("phone_number") AS "normalized_phone_number",
+    first_value("id") OVER (
+      PARTITION BY pg_temp.normalize_phone_number("phone_number")
+      ORDER BY
+        "phone_number" = pg_temp.normalize_phone_number("phone_number") DESC,
+        "created_at",
+        "id"
+    ) AS "kept_row_id"
+  FROM "customers"
+  WHERE "deleted_at" IS NULL
+)
+INSERT INTO "phone_number_normalization_collisions"
+  ("table_name", "row_id", "original_phone_number", "normalized_phone_number", "kept_row_id")
+SELECT 'customers', "id", "phone_number", "normalized_phone_number", "kept_row_id"
+FROM "normalized"
+WHERE "id" <> "kept_row_id"
+ON CONFLICT DO NOTHING;
+
+UPDATE "customers" c
+SET "phone_number" = pg_temp.normalize_phone_number(c."phone_number")
+WHERE c."phone_number" <> ''
+  AND c."phone_number" <> pg_temp.normalize_phone_number(c."phone_number")
+  AND NOT EXISTS (
+    SELECT 1 FROM "phone_number_normalization_collisions" n
+    WHERE n."table_name" = 'customers' AND n."row_id" = c."id"
+  );
+
+-- country metadata from the longest matching calling code
+UPDATE "users" u
+SET
+  "phone_country_code" = m."code",
+  "phone_region" = m."region"
+FROM (
+  SELECT DISTINCT ON (u2."id") u2."id", cc."code", cc."region"
+  FROM "users" u2
+  JOIN "phone_calling_codes" cc ON u2."phone_number" LIKE '+' || cc."code" || '%'
+  ORDER BY u2."id", length(cc."code") DESC
+) m
+WHERE m."id" = u."id";
+
+UPDATE "users" SET "phone_region" = 'ZZ' WHERE "phone_region" IS NULL;
+
+UPDATE "customers" c
+SET
+  "phone_country_code" = m."code",
+  "phone_region" = m."region"
+FROM (
+  SELECT DISTINCT ON (c2."id") c2."id", cc."code", cc."region"
+  FROM "customers" c2
+  JOIN "phone_calling_codes" cc ON c2."phone_number" LIKE '+' || cc."code" || '%'
+  ORDER BY c2."id", length(cc."code") DESC
+) m
+WHERE m."id" = c."id";
+
+UPDATE "customers"
+SET "phone_region" = 'ZZ'
+WHERE "phone_region" IS NULL AND "phone_number" <> '';
+
+DROP TABLE "phone_calling_codes";
+
+DO $$
+DECLARE
+  collisions int;
+BEGIN
+  SELECT count(*) INTO collisions FROM "phone_number_normalization_collisions";
+  IF collisions > 0 THEN
+    RAISE WARNING '% phone numbers collide once normalized, see phone_number_normalization_collisions', collisions;
+  END IF;
+END $$;
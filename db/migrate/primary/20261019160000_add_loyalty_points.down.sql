@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS "loyalty_point_transactions";
+
+ALTER TABLE "orders"
+  DROP COLUMN IF EXISTS "voided_at",
+  DROP COLUMN IF EXISTS "points_earned",
+  DROP COLUMN IF EXISTS "points_amount",
+  DROP COLUMN IF EXISTS "points_redeemed";
+
+DROP TYPE IF EXISTS "loyalty_transaction_type";
@@ -0,0 +1,24 @@
+CREATE TYPE "loyalty_transaction_type" AS ENUM ('earn', 'redeem', 'reversal');
+
+ALTER TABLE "orders"
+  ADD COLUMN IF NOT EXISTS "points_redeemed" int NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS "points_amount" numeric(10,2) NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS "points_earned" int NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS "voided_at" timestamp NULL DEFAULT NULL;
+
+-- the balance of a customer is the sum of their points, redemptions are
+-- stored as negative points
+CREATE TABLE IF NOT EXISTS "loyalty_point_transactions" (
+  "id" uuid NOT NULL,
+  "customer_id" uuid NOT NULL,
+  "order_id" uuid NULL,
+  "type" loyalty_transaction_type NOT NULL,
+  "points" int NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("id"),
+  FOREIGN KEY ("customer_id") REFERENCES "customers" ("id"),
+  FOREIGN KEY ("order_id") REFERENCES "orders" ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "loyalty_point_transactions_customer_id_idx"
+  ON "loyalty_point_transactions" ("customer_id", "created_at");
@@ -0,0 +1,12 @@
+ALTER TABLE "orders"
+  DROP COLUMN IF EXISTS "discount_amount",
+  DROP COLUMN IF EXISTS "gross_price";
+
+ALTER TABLE "order_product"
+  DROP COLUMN IF EXISTS "net_price",
+  DROP COLUMN IF EXISTS "promotion_id",
+  DROP COLUMN IF EXISTS "discount_amount";
+
+DROP TABLE IF EXISTS "promotions";
+
+DROP TYPE IF EXISTS "promotion_type";
@@ -0,0 +1,48 @@
+CREATE TYPE "promotion_type" AS ENUM ('percentage', 'fixed', 'buy_x_get_y');
+
+-- a promotion without product and category applies to every product
+CREATE TABLE IF NOT EXISTS "promotions" (
+  "id" uuid NOT NULL,
+  "name" varchar(100) NOT NULL,
+  "type" promotion_type NOT NULL,
+  "value" numeric(10,2) NOT NULL DEFAULT 0,
+  "buy_quantity" int NOT NULL DEFAULT 0,
+  "get_quantity" int NOT NULL DEFAULT 0,
+  "product_id" uuid NULL,
+  "category" CATEGORY NULL,
+  "starts_at" timestamp NULL DEFAULT NULL,
+  "ends_at" timestamp NULL DEFAULT NULL,
+  "is_active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "deleted_at" timestamp NULL DEFAULT NULL,
+  "created_by" uuid NOT NULL,
+  PRIMARY KEY ("id"),
+  FOREIGN KEY ("product_id") REFERENCES "products" ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "promotions_active_idx"
+  ON "promotions" ("starts_at", "ends_at")
+  WHERE "is_active" AND "deleted_at" IS NULL;
+
+ALTER TABLE "order_product"
+  ADD COLUMN IF NOT EXISTS "discount_amount" numeric(10,2) NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS "promotion_id" uuid NULL REFERENCES "promotions" ("id"),
+  ADD COLUMN IF NOT EXISTS "net_price" numeric(10,2) NOT NULL
+    GENERATED ALWAYS AS ("quantity" * "price" - "discount_amount") STORED;
+
+ALTER TABLE "orders"
+  ADD COLUMN IF NOT EXISTS "gross_price" numeric(10,2) NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS "discount_amount" numeric(10,2) NOT NULL DEFAULT 0;
+
+-- orders used to be saved without their total, every order so far was
+-- charged the full price of its lines
+UPDATE "orders" o SET
+  "gross_price" = lines."total",
+  "total_price" = lines."total"
+FROM (
+  SELECT "order_id", sum("total_price") AS "total"
+  FROM "order_product"
+  GROUP BY "order_id"
+) lines
+WHERE lines."order_id" = o."id";
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS "coupon_redemptions";
+
+ALTER TABLE "orders"
+  DROP COLUMN IF EXISTS "coupon_discount",
+  DROP COLUMN IF EXISTS "coupon_id";
+
+DROP TABLE IF EXISTS "coupons";
+
+DROP TYPE IF EXISTS "coupon_type";
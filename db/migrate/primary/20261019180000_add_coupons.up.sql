@@ -0,0 +1,52 @@
+CREATE TYPE "coupon_type" AS ENUM ('percentage', 'fixed');
+
+-- max_uses and max_uses_per_customer are unlimited when null, used_count
+-- is only changed while the coupon row is locked
+CREATE TABLE IF NOT EXISTS "coupons" (
+  "id" uuid NOT NULL,
+  "code" varchar(50) NOT NULL,
+  "type" coupon_type NOT NULL,
+  "value" numeric(10,2) NOT NULL,
+  "min_basket_amount" numeric(10,2) NOT NULL DEFAULT 0,
+  "max_uses" int NULL,
+  "max_uses_per_customer" int NULL,
+  "used_count" int NOT NULL DEFAULT 0,
+  "product_id" uuid NULL,
+  "category" CATEGORY NULL,
+  "starts_at" timestamp NULL DEFAULT NULL,
+  "ends_at" timestamp NULL DEFAULT NULL,
+  "is_active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "deleted_at" timestamp NULL DEFAULT NULL,
+  "created_by" uuid NOT NULL,
+  PRIMARY KEY ("id"),
+  FOREIGN KEY ("product_id") REFERENCES "products" ("id"),
+  CHECK ("max_uses" IS NULL OR "used_count" <= "max_uses")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "coupons_code_unique_idx"
+  ON "coupons" (upper("code"))
+  WHERE "deleted_at" IS NULL;
+
+ALTER TABLE "orders"
+  ADD COLUMN IF NOT EXISTS "coupon_id" uuid NULL REFERENCES "coupons" ("id"),
+  ADD COLUMN IF NOT EXISTS "coupon_discount" numeric(10,2) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS "coupon_redemptions" (
+  "id" uuid NOT NULL,
+  "coupon_id" uuid NOT NULL,
+  "order_id" uuid NOT NULL,
+  "customer_id" uuid NOT NULL,
+  "discount_amount" numeric(10,2) NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "voided_at" timestamp NULL DEFAULT NULL,
+  PRIMARY KEY ("id"),
+  FOREIGN KEY ("coupon_id") REFERENCES "coupons" ("id"),
+  FOREIGN KEY ("order_id") REFERENCES "orders" ("id"),
+  FOREIGN KEY ("customer_id") REFERENCES "customers" ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "coupon_redemptions_coupon_customer_idx"
+  ON "coupon_redemptions" ("coupon_id", "customer_id")
+  WHERE "voided_at" IS NULL;
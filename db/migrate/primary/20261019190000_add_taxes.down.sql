@@ -0,0 +1,10 @@
+ALTER TABLE "orders"
+  DROP COLUMN IF EXISTS "prices_include_tax",
+  DROP COLUMN IF EXISTS "tax_amount",
+  DROP COLUMN IF EXISTS "subtotal";
+
+ALTER TABLE "order_product"
+  DROP COLUMN IF EXISTS "tax_amount",
+  DROP COLUMN IF EXISTS "tax_rate";
+
+DROP TABLE IF EXISTS "tax_rates";
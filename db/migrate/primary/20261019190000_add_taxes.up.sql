@@ -0,0 +1,38 @@
+-- rates override the store default, a product rate wins over the rate of
+-- its category
+CREATE TABLE IF NOT EXISTS "tax_rates" (
+  "id" uuid NOT NULL,
+  "name" varchar(100) NOT NULL,
+  "rate" numeric(5,2) NOT NULL,
+  "product_id" uuid NULL,
+  "category" CATEGORY NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "deleted_at" timestamp NULL DEFAULT NULL,
+  "created_by" uuid NOT NULL,
+  PRIMARY KEY ("id"),
+  FOREIGN KEY ("product_id") REFERENCES "products" ("id"),
+  CHECK (("product_id" IS NULL) <> ("category" IS NULL)),
+  CHECK ("rate" >= 0 AND "rate" <= 100)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "tax_rates_product_unique_idx"
+  ON "tax_rates" ("product_id")
+  WHERE "deleted_at" IS NULL AND "product_id" IS NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "tax_rates_category_unique_idx"
+  ON "tax_rates" ("category")
+  WHERE "deleted_at" IS NULL AND "category" IS NOT NULL;
+
+ALTER TABLE "order_product"
+  ADD COLUMN IF NOT EXISTS "tax_rate" numeric(5,2) NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS "tax_amount" numeric(10,2) NOT NULL DEFAULT 0;
+
+-- total_price is subtotal + tax_amount, whether the prices included the tax
+-- or not
+ALTER TABLE "orders"
+  ADD COLUMN IF NOT EXISTS "subtotal" numeric(10,2) NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS "tax_amount" numeric(10,2) NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS "prices_include_tax" boolean NOT NULL DEFAULT true;
+
+UPDATE "orders" SET "subtotal" = "total_price";
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "order_payments";
+
+DROP TYPE IF EXISTS "payment_method";
@@ -0,0 +1,55 @@
+CREATE TYPE "payment_method" AS ENUM (
+  'cash',
+  'card',
+  'ewallet',
+  'qris',
+  'store_credit',
+  'loyalty_points'
+);
+
+-- amount is what the tender paid towards the order, for cash that is what
+-- was tendered minus the change
+CREATE TABLE IF NOT EXISTS "order_payments" (
+  "id" uuid NOT NULL,
+  "order_id" uuid NOT NULL,
+  "method" payment_method NOT NULL,
+  "amount" numeric(10,2) NOT NULL,
+  "tendered_amount" numeric(10,2) NOT NULL,
+  "reference" varchar(100) NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("id"),
+  FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "order_payments_order_id_idx"
+  ON "order_payments" ("order_id");
+
+CREATE INDEX IF NOT EXISTS "order_payments_method_created_at_idx"
+  ON "order_payments" ("method", "created_at");
+
+-- every order so far was paid in cash, apart from its loyalty points
+INSERT INTO "order_payments" (
+  "id", "order_id", "method", "amount", "tendered_amount", "created_at"
+)
+SELECT
+  gen_random_uuid(),
+  "id",
+  'cash',
+  "payment_amount" - "change",
+  "payment_amount",
+  "created_at"
+FROM "orders"
+WHERE "payment_amount" > 0;
+
+INSERT INTO "order_payments" (
+  "id", "order_id", "method", "amount", "tendered_amount", "created_at"
+)
+SELECT
+  gen_random_uuid(),
+  "id",
+  'loyalty_points',
+  "points_amount",
+  "points_amount",
+  "created_at"
+FROM "orders"
+WHERE "points_amount" > 0;
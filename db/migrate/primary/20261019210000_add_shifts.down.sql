@@ -0,0 +1,9 @@
+ALTER TABLE "orders"
+  DROP COLUMN IF EXISTS "shift_id",
+  DROP COLUMN IF EXISTS "created_by";
+
+DROP TABLE IF EXISTS "cash_movements";
+
+DROP TYPE IF EXISTS "cash_movement_type";
+
+DROP TABLE IF EXISTS "shifts";
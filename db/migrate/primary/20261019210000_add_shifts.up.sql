@@ -0,0 +1,56 @@
+-- the cash totals are set when the shift closes, expected_cash is
+-- opening_float + cash_sales + pay_ins - pay_outs
+CREATE TABLE IF NOT EXISTS "shifts" (
+  "id" uuid NOT NULL,
+  "cashier_id" uuid NOT NULL,
+  "opening_float" numeric(10,2) NOT NULL,
+  "cash_sales" numeric(10,2) NULL,
+  "pay_ins" numeric(10,2) NULL,
+  "pay_outs" numeric(10,2) NULL,
+  "expected_cash" numeric(10,2) NULL,
+  "counted_cash" numeric(10,2) NULL,
+  "variance" numeric(10,2) NULL,
+  "notes" varchar(200) NULL,
+  "opened_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "closed_at" timestamp NULL DEFAULT NULL,
+  "closed_by" uuid NULL,
+  PRIMARY KEY ("id"),
+  FOREIGN KEY ("cashier_id") REFERENCES "users" ("id"),
+  FOREIGN KEY ("closed_by") REFERENCES "users" ("id"),
+  CHECK ("opening_float" >= 0)
+);
+
+-- a cashier has at most one open shift
+CREATE UNIQUE INDEX IF NOT EXISTS "shifts_open_cashier_unique_idx"
+  ON "shifts" ("cashier_id")
+  WHERE "closed_at" IS NULL;
+
+CREATE INDEX IF NOT EXISTS "shifts_opened_at_idx"
+  ON "shifts" ("opened_at", "id");
+
+CREATE TYPE "cash_movement_type" AS ENUM ('pay_in', 'pay_out');
+
+CREATE TABLE IF NOT EXISTS "cash_movements" (
+  "id" uuid NOT NULL,
+  "shift_id" uuid NOT NULL,
+  "type" cash_movement_type NOT NULL,
+  "amount" numeric(10,2) NOT NULL,
+  "reason" varchar(200) NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "created_by" uuid NOT NULL,
+  PRIMARY KEY ("id"),
+  FOREIGN KEY ("shift_id") REFERENCES "shifts" ("id"),
+  FOREIGN KEY ("created_by") REFERENCES "users" ("id"),
+  CHECK ("amount" > 0)
+);
+
+CREATE INDEX IF NOT EXISTS "cash_movements_shift_id_idx"
+  ON "cash_movements" ("shift_id");
+
+-- orders before this migration have neither
+ALTER TABLE "orders"
+  ADD COLUMN IF NOT EXISTS "created_by" uuid NULL REFERENCES "users" ("id"),
+  ADD COLUMN IF NOT EXISTS "shift_id" uuid NULL REFERENCES "shifts" ("id");
+
+CREATE INDEX IF NOT EXISTS "orders_shift_id_idx"
+  ON "orders" ("shift_id");
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "orders_voided_at_idx";
+
+DROP TABLE IF EXISTS "daily_reports";
@@ -0,0 +1,26 @@
+-- a closed business day, frozen so that later changes to products or
+-- users don't rewrite it. The breakdowns are stored as they were reported.
+CREATE TABLE IF NOT EXISTS "daily_reports" (
+  "business_date" date NOT NULL,
+  "gross_sales" numeric(14,2) NOT NULL,
+  "refunds" numeric(14,2) NOT NULL,
+  "net_sales" numeric(14,2) NOT NULL,
+  "discounts" numeric(14,2) NOT NULL,
+  "tax" numeric(14,2) NOT NULL,
+  "order_count" int NOT NULL,
+  "refund_count" int NOT NULL,
+  "items_sold" int NOT NULL,
+  "categories" jsonb NOT NULL,
+  "cashiers" jsonb NOT NULL,
+  "payments" jsonb NOT NULL,
+  "time_zone" varchar(50) NOT NULL,
+  "closed_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "closed_by" uuid NOT NULL,
+  PRIMARY KEY ("business_date"),
+  FOREIGN KEY ("closed_by") REFERENCES "users" ("id")
+);
+
+-- refunds of a day are the orders voided on it
+CREATE INDEX IF NOT EXISTS "orders_voided_at_idx"
+  ON "orders" ("voided_at")
+  WHERE "voided_at" IS NOT NULL;
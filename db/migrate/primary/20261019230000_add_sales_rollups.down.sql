@@ -0,0 +1,3 @@
+DROP MATERIALIZED VIEW IF EXISTS "sales_hourly";
+
+DROP MATERIALIZED VIEW IF EXISTS "product_sales_hourly";
@@ -0,0 +1,33 @@
+-- hourly sales rollups for the analytics reports, so that they don't scan
+-- the orders that checkout writes to. They leave out voided orders and are
+-- refreshed concurrently by the app, which needs the unique indexes.
+CREATE MATERIALIZED VIEW IF NOT EXISTS "product_sales_hourly" AS
+  SELECT
+    date_trunc('hour', o."created_at") AS "sold_hour",
+    op."product_id",
+    sum(op."quantity") AS "units",
+    sum(op."net_price") AS "revenue",
+    count(*) AS "order_count"
+  FROM "orders" o
+  JOIN "order_product" op ON op."order_id" = o."id"
+  WHERE o."voided_at" IS NULL
+  GROUP BY 1, 2;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "product_sales_hourly_unique_idx"
+  ON "product_sales_hourly" ("sold_hour", "product_id");
+
+-- last sale of a product, for slow movers
+CREATE INDEX IF NOT EXISTS "product_sales_hourly_product_id_idx"
+  ON "product_sales_hourly" ("product_id", "sold_hour");
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS "sales_hourly" AS
+  SELECT
+    date_trunc('hour', "created_at") AS "sold_hour",
+    count(*) AS "order_count",
+    sum("total_price") AS "revenue"
+  FROM "orders"
+  WHERE "voided_at" IS NULL
+  GROUP BY 1;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "sales_hourly_unique_idx"
+  ON "sales_hourly" ("sold_hour");
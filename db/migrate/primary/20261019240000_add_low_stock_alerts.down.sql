@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS "stock_alerts";
+
+DROP INDEX IF EXISTS "products_low_stock_idx";
+
+ALTER TABLE "products"
+  DROP COLUMN IF EXISTS "low_stock_alerted_at",
+  DROP COLUMN IF EXISTS "reorder_quantity",
+  DROP COLUMN IF EXISTS "reorder_point";
@@ -0,0 +1,30 @@
+-- a reorder point of 0 turns the alerts of a product off.
+-- low_stock_alerted_at is set when the stock crosses the reorder point and
+-- cleared when it is back above it, so each crossing alerts once.
+ALTER TABLE "products"
+  ADD COLUMN IF NOT EXISTS "reorder_point" int NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS "reorder_quantity" int NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS "low_stock_alerted_at" timestamp NULL DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS "products_low_stock_idx"
+  ON "products" ("stock", "reorder_point")
+  WHERE "reorder_point" > 0 AND "deleted_at" IS NULL;
+
+-- alerts are written with the checkout and sent by a background job
+CREATE TABLE IF NOT EXISTS "stock_alerts" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "product_id" uuid NOT NULL,
+  "stock" int NOT NULL,
+  "reorder_point" int NOT NULL,
+  "reorder_quantity" int NOT NULL,
+  "attempts" int NOT NULL DEFAULT 0,
+  "last_error" text NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "notified_at" timestamp NULL DEFAULT NULL,
+  PRIMARY KEY ("id"),
+  FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "stock_alerts_pending_idx"
+  ON "stock_alerts" ("created_at")
+  WHERE "notified_at" IS NULL;
@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS "stock_movements";
+
+DROP TYPE IF EXISTS "stock_movement_type";
+
+ALTER TABLE "products"
+  DROP COLUMN IF EXISTS "cost";
+
+DROP TABLE IF EXISTS "purchase_order_lines";
+
+DROP TABLE IF EXISTS "purchase_orders";
+
+DROP TYPE IF EXISTS "purchase_order_status";
+
+DROP TABLE IF EXISTS "suppliers";
@@ -0,0 +1,81 @@
+CREATE TABLE IF NOT EXISTS "suppliers" (
+  "id" uuid NOT NULL,
+  "name" varchar(100) NOT NULL,
+  "phone_number" varchar(20) NULL,
+  "email" varchar(100) NULL,
+  "address" varchar(200) NULL,
+  "notes" varchar(200) NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "deleted_at" timestamp NULL DEFAULT NULL,
+  "created_by" uuid NOT NULL,
+  PRIMARY KEY ("id"),
+  FOREIGN KEY ("created_by") REFERENCES "users" ("id")
+);
+
+CREATE TYPE "purchase_order_status" AS ENUM (
+  'ordered',
+  'partially_received',
+  'received',
+  'cancelled'
+);
+
+CREATE TABLE IF NOT EXISTS "purchase_orders" (
+  "id" uuid NOT NULL,
+  "supplier_id" uuid NOT NULL,
+  "status" purchase_order_status NOT NULL DEFAULT 'ordered',
+  "notes" varchar(200) NULL,
+  "expected_at" timestamp NULL DEFAULT NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "created_by" uuid NOT NULL,
+  PRIMARY KEY ("id"),
+  FOREIGN KEY ("supplier_id") REFERENCES "suppliers" ("id"),
+  FOREIGN KEY ("created_by") REFERENCES "users" ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "purchase_orders_supplier_id_idx"
+  ON "purchase_orders" ("supplier_id");
+
+CREATE INDEX IF NOT EXISTS "purchase_orders_created_at_idx"
+  ON "purchase_orders" ("created_at", "id");
+
+CREATE TABLE IF NOT EXISTS "purchase_order_lines" (
+  "purchase_order_id" uuid NOT NULL,
+  "product_id" uuid NOT NULL,
+  "quantity" int NOT NULL,
+  "received_quantity" int NOT NULL DEFAULT 0,
+  "unit_cost" numeric(10,2) NOT NULL,
+  PRIMARY KEY ("purchase_order_id", "product_id"),
+  FOREIGN KEY ("purchase_order_id") REFERENCES "purchase_orders" ("id") ON DELETE CASCADE,
+  FOREIGN KEY ("product_id") REFERENCES "products" ("id"),
+  CHECK ("quantity" > 0),
+  CHECK ("received_quantity" BETWEEN 0 AND "quantity"),
+  CHECK ("unit_cost" >= 0)
+);
+
+-- cost is the unit cost of the last delivery received
+ALTER TABLE "products"
+  ADD COLUMN IF NOT EXISTS "cost" numeric(10,2) NOT NULL DEFAULT 0;
+
+-- every change of stock that is not a sale, quantity is signed
+CREATE TYPE "stock_movement_type" AS ENUM ('receipt');
+
+CREATE TABLE IF NOT EXISTS "stock_movements" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "product_id" uuid NOT NULL,
+  "type" stock_movement_type NOT NULL,
+  "quantity" int NOT NULL,
+  "unit_cost" numeric(10,2) NULL,
+  "purchase_order_id" uuid NULL,
+  "note" varchar(200) NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "created_by" uuid NOT NULL,
+  PRIMARY KEY ("id"),
+  FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE,
+  FOREIGN KEY ("purchase_order_id") REFERENCES "purchase_orders" ("id"),
+  FOREIGN KEY ("created_by") REFERENCES "users" ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "stock_movements_product_id_idx"
+  ON "stock_movements" ("product_id", "created_at");
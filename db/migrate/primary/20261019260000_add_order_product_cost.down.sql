@@ -0,0 +1,2 @@
+ALTER TABLE "order_product"
+  DROP COLUMN IF EXISTS "cost";
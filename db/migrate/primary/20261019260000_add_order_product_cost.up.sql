@@ -0,0 +1,11 @@
+-- cost is the unit cost of the product at checkout, as price is its unit
+-- price
+ALTER TABLE "order_product"
+  ADD COLUMN IF NOT EXISTS "cost" numeric(10,2) NOT NULL DEFAULT 0;
+
+-- orders from before costs were kept get the cost their product has now,
+-- the best guess there is
+UPDATE "order_product" op SET
+  "cost" = p."cost"
+FROM "products" p
+WHERE p."id" = op."product_id";
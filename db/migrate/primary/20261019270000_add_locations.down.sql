@@ -0,0 +1,24 @@
+ALTER TABLE "orders"
+  DROP COLUMN IF EXISTS "location_id";
+
+ALTER TABLE "purchase_orders"
+  DROP COLUMN IF EXISTS "location_id";
+
+DELETE FROM "stock_movements"
+WHERE "type" IN ('transfer_out', 'transfer_in');
+
+ALTER TABLE "stock_movements"
+  DROP COLUMN IF EXISTS "transfer_id",
+  DROP COLUMN IF EXISTS "location_id";
+
+-- enum values cannot be dropped, transfer_out and transfer_in stay unused
+
+DROP TABLE IF EXISTS "stock_transfer_lines";
+
+DROP TABLE IF EXISTS "stock_transfers";
+
+DROP TYPE IF EXISTS "stock_transfer_status";
+
+DROP TABLE IF EXISTS "product_stocks";
+
+DROP TABLE IF EXISTS "locations";
@@ -0,0 +1,119 @@
+CREATE TABLE IF NOT EXISTS "locations" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "name" varchar(100) NOT NULL,
+  "address" varchar(200) NULL,
+  "is_default" boolean NOT NULL DEFAULT false,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "deleted_at" timestamp NULL DEFAULT NULL,
+  PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "locations_name_unique_idx"
+  ON "locations" (lower("name"))
+  WHERE "deleted_at" IS NULL;
+
+-- the default location takes the stock of checkouts and deliveries that
+-- do not name one
+CREATE UNIQUE INDEX IF NOT EXISTS "locations_default_unique_idx"
+  ON "locations" ("is_default")
+  WHERE "is_default";
+
+INSERT INTO "locations" ("name", "is_default")
+VALUES ('Main', true)
+ON CONFLICT DO NOTHING;
+
+-- products.stock is kept as the total of the stock at every location
+CREATE TABLE IF NOT EXISTS "product_stocks" (
+  "product_id" uuid NOT NULL,
+  "location_id" uuid NOT NULL,
+  "stock" int NOT NULL DEFAULT 0,
+  PRIMARY KEY ("product_id", "location_id"),
+  FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE,
+  FOREIGN KEY ("location_id") REFERENCES "locations" ("id"),
+  CONSTRAINT "product_stocks_stock_check" CHECK ("stock" >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS "product_stocks_location_id_idx"
+  ON "product_stocks" ("location_id");
+
+-- all the stock so far was in the one store
+INSERT INTO "product_stocks" ("product_id", "location_id", "stock")
+SELECT p."id", l."id", greatest(p."stock", 0)
+FROM "products" p
+CROSS JOIN "locations" l
+WHERE l."is_default"
+ON CONFLICT DO NOTHING;
+
+UPDATE "products" SET "stock" = 0 WHERE "stock" < 0;
+
+CREATE TYPE "stock_transfer_status" AS ENUM (
+  'in_transit',
+  'received',
+  'cancelled'
+);
+
+-- the stock of a transfer leaves its source when it is sent and is at no
+-- location until it is received
+CREATE TABLE IF NOT EXISTS "stock_transfers" (
+  "id" uuid NOT NULL,
+  "from_location_id" uuid NOT NULL,
+  "to_location_id" uuid NOT NULL,
+  "status" stock_transfer_status NOT NULL DEFAULT 'in_transit',
+  "notes" varchar(200) NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "created_by" uuid NOT NULL,
+  "received_at" timestamp NULL DEFAULT NULL,
+  "received_by" uuid NULL,
+  PRIMARY KEY ("id"),
+  FOREIGN KEY ("from_location_id") REFERENCES "locations" ("id"),
+  FOREIGN KEY ("to_location_id") REFERENCES "locations" ("id"),
+  FOREIGN KEY ("created_by") REFERENCES "users" ("id"),
+  FOREIGN KEY ("received_by") REFERENCES "users" ("id"),
+  CHECK ("from_location_id" <> "to_location_id")
+);
+
+CREATE INDEX IF NOT EXISTS "stock_transfers_created_at_idx"
+  ON "stock_transfers" ("created_at", "id");
+
+CREATE TABLE IF NOT EXISTS "stock_transfer_lines" (
+  "transfer_id" uuid NOT NULL,
+  "product_id" uuid NOT NULL,
+  "quantity" int NOT NULL,
+  PRIMARY KEY ("transfer_id", "product_id"),
+  FOREIGN KEY ("transfer_id") REFERENCES "stock_transfers" ("id") ON DELETE CASCADE,
+  FOREIGN KEY ("product_id") REFERENCES "products" ("id"),
+  CHECK ("quantity" > 0)
+);
+
+ALTER TYPE "stock_movement_type" ADD VALUE IF NOT EXISTS 'transfer_out';
+ALTER TYPE "stock_movement_type" ADD VALUE IF NOT EXISTS 'transfer_in';
+
+ALTER TABLE "stock_movements"
+  ADD COLUMN IF NOT EXISTS "location_id" uuid NULL REFERENCES "locations" ("id"),
+  ADD COLUMN IF NOT EXISTS "transfer_id" uuid NULL REFERENCES "stock_transfers" ("id");
+
+ALTER TABLE "purchase_orders"
+  ADD COLUMN IF NOT EXISTS "location_id" uuid NULL REFERENCES "locations" ("id");
+
+ALTER TABLE "orders"
+  ADD COLUMN IF NOT EXISTS "location_id" uuid NULL REFERENCES "locations" ("id");
+
+UPDATE "stock_movements" SET
+  "location_id" = (SELECT "id" FROM "locations" WHERE "is_default")
+WHERE "location_id" IS NULL;
+
+UPDATE "purchase_orders" SET
+  "location_id" = (SELECT "id" FROM "locations" WHERE "is_default")
+WHERE "location_id" IS NULL;
+
+UPDATE "orders" SET
+  "location_id" = (SELECT "id" FROM "locations" WHERE "is_default")
+WHERE "location_id" IS NULL;
+
+ALTER TABLE "stock_movements"
+  ALTER COLUMN "location_id" SET NOT NULL;
+
+ALTER TABLE "purchase_orders"
+  ALTER COLUMN "location_id" SET NOT NULL;
@@ -0,0 +1,13 @@
+DELETE FROM "stock_movements"
+WHERE "type" = 'count_adjustment';
+
+ALTER TABLE "stock_movements"
+  DROP COLUMN IF EXISTS "stock_count_id";
+
+-- enum values cannot be dropped, count_adjustment stays unused
+
+DROP TABLE IF EXISTS "stock_count_lines";
+
+DROP TABLE IF EXISTS "stock_counts";
+
+DROP TYPE IF EXISTS "stock_count_status";
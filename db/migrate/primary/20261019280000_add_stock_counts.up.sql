@@ -0,0 +1,58 @@
+CREATE TYPE "stock_count_status" AS ENUM (
+  'open',
+  'posted',
+  'cancelled'
+);
+
+-- a count of the stock at a location, of one category of products or of
+-- all of them
+CREATE TABLE IF NOT EXISTS "stock_counts" (
+  "id" uuid NOT NULL,
+  "location_id" uuid NOT NULL,
+  "category" category NULL,
+  "status" stock_count_status NOT NULL DEFAULT 'open',
+  "notes" varchar(200) NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "created_by" uuid NOT NULL,
+  "closed_at" timestamp NULL DEFAULT NULL,
+  "closed_by" uuid NULL,
+  PRIMARY KEY ("id"),
+  FOREIGN KEY ("location_id") REFERENCES "locations" ("id"),
+  FOREIGN KEY ("created_by") REFERENCES "users" ("id"),
+  FOREIGN KEY ("closed_by") REFERENCES "users" ("id")
+);
+
+-- counts of the same location would adjust the same stock twice
+CREATE UNIQUE INDEX IF NOT EXISTS "stock_counts_open_location_idx"
+  ON "stock_counts" ("location_id")
+  WHERE "status" = 'open';
+
+CREATE INDEX IF NOT EXISTS "stock_counts_created_at_idx"
+  ON "stock_counts" ("created_at", "id");
+
+-- expected is the stock when the count was opened, stock_at_count the stock
+-- when the line was last counted. Posting adjusts the stock by counted minus
+-- stock_at_count, so that sales made after a line was counted are kept.
+CREATE TABLE IF NOT EXISTS "stock_count_lines" (
+  "count_id" uuid NOT NULL,
+  "product_id" uuid NOT NULL,
+  "expected" int NOT NULL,
+  "counted" int NULL,
+  "stock_at_count" int NULL,
+  "counted_at" timestamp NULL DEFAULT NULL,
+  "counted_by" uuid NULL,
+  PRIMARY KEY ("count_id", "product_id"),
+  FOREIGN KEY ("count_id") REFERENCES "stock_counts" ("id") ON DELETE CASCADE,
+  FOREIGN KEY ("product_id") REFERENCES "products" ("id"),
+  FOREIGN KEY ("counted_by") REFERENCES "users" ("id"),
+  CHECK ("counted" >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS "stock_count_lines_product_id_idx"
+  ON "stock_count_lines" ("product_id");
+
+ALTER TYPE "stock_movement_type" ADD VALUE IF NOT EXISTS 'count_adjustment';
+
+ALTER TABLE "stock_movements"
+  ADD COLUMN IF NOT EXISTS "stock_count_id" uuid NULL REFERENCES "stock_counts" ("id");
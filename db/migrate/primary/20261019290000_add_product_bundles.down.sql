@@ -0,0 +1,35 @@
+DROP MATERIALIZED VIEW IF EXISTS "product_sales_hourly";
+
+-- bundle lines are counted as products of their own again, rather than
+-- as their components
+CREATE MATERIALIZED VIEW IF NOT EXISTS "product_sales_hourly" AS
+  SELECT
+    date_trunc('hour', o."created_at") AS "sold_hour",
+    op."product_id",
+    sum(op."quantity") AS "units",
+    sum(op."net_price") AS "revenue",
+    count(*) AS "order_count"
+  FROM "orders" o
+  JOIN "order_product" op ON op."order_id" = o."id"
+  WHERE o."voided_at" IS NULL
+  GROUP BY 1, 2;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "product_sales_hourly_unique_idx"
+  ON "product_sales_hourly" ("sold_hour", "product_id");
+
+CREATE INDEX IF NOT EXISTS "product_sales_hourly_product_id_idx"
+  ON "product_sales_hourly" ("product_id", "sold_hour");
+
+DROP VIEW IF EXISTS "order_sales_lines";
+
+DROP TABLE IF EXISTS "order_product_components";
+
+ALTER TABLE "order_product"
+  DROP COLUMN IF EXISTS "is_bundle";
+
+DROP FUNCTION IF EXISTS "bundle_stock" (uuid);
+
+DROP TABLE IF EXISTS "product_bundle_components";
+
+ALTER TABLE "products"
+  DROP COLUMN IF EXISTS "is_bundle";
@@ -0,0 +1,102 @@
+-- a bundle holds no stock of its own, it is sold out of the stock of its
+-- components
+ALTER TABLE "products"
+  ADD COLUMN IF NOT EXISTS "is_bundle" bool NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS "product_bundle_components" (
+  "bundle_id" uuid NOT NULL,
+  "component_id" uuid NOT NULL,
+  "quantity" int NOT NULL,
+  PRIMARY KEY ("bundle_id", "component_id"),
+  FOREIGN KEY ("bundle_id") REFERENCES "products" ("id") ON DELETE CASCADE,
+  FOREIGN KEY ("component_id") REFERENCES "products" ("id"),
+  CHECK ("quantity" > 0),
+  CHECK ("bundle_id" <> "component_id")
+);
+
+CREATE INDEX IF NOT EXISTS "product_bundle_components_component_id_idx"
+  ON "product_bundle_components" ("component_id");
+
+-- how many of the bundle the stock of its components makes up, none when a
+-- component was deleted
+CREATE OR REPLACE FUNCTION "bundle_stock" ("bundle" uuid)
+RETURNS int
+LANGUAGE sql
+STABLE
+AS $$
+  SELECT coalesce(min(
+    CASE
+      WHEN c."deleted_at" IS NULL THEN c."stock" / pbc."quantity"
+      ELSE 0
+    END
+  ), 0)::int
+  FROM "product_bundle_components" pbc
+  JOIN "products" c ON c."id" = pbc."component_id"
+  WHERE pbc."bundle_id" = "bundle"
+$$;
+
+ALTER TABLE "order_product"
+  ADD COLUMN IF NOT EXISTS "is_bundle" bool NOT NULL DEFAULT false;
+
+-- the components a bundle line took out of stock, with the share of the
+-- net price and tax of the line each of them is credited with. cost is the
+-- unit cost of the component at checkout.
+CREATE TABLE IF NOT EXISTS "order_product_components" (
+  "order_id" uuid NOT NULL,
+  "bundle_id" uuid NOT NULL,
+  "product_id" uuid NOT NULL,
+  "quantity" int NOT NULL,
+  "net_price" numeric(10,2) NOT NULL,
+  "tax_amount" numeric(10,2) NOT NULL,
+  "cost" numeric(10,2) NOT NULL,
+  PRIMARY KEY ("order_id", "bundle_id", "product_id"),
+  FOREIGN KEY ("order_id", "bundle_id")
+    REFERENCES "order_product" ("order_id", "product_id") ON DELETE CASCADE,
+  FOREIGN KEY ("product_id") REFERENCES "products" ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "order_product_components_product_id_idx"
+  ON "order_product_components" ("product_id");
+
+-- the products an order sold as far as stock and reporting go: the lines
+-- that are not bundles and the components of the ones that are. A product
+-- may show up more than once per order.
+CREATE OR REPLACE VIEW "order_sales_lines" AS
+  SELECT
+    op."order_id",
+    op."product_id",
+    op."quantity",
+    op."net_price",
+    op."tax_amount",
+    op."cost"
+  FROM "order_product" op
+  WHERE NOT op."is_bundle"
+  UNION ALL
+  SELECT
+    opc."order_id",
+    opc."product_id",
+    opc."quantity",
+    opc."net_price",
+    opc."tax_amount",
+    opc."cost"
+  FROM "order_product_components" opc;
+
+DROP MATERIALIZED VIEW IF EXISTS "product_sales_hourly";
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS "product_sales_hourly" AS
+  SELECT
+    date_trunc('hour', o."created_at") AS "sold_hour",
+    osl."product_id",
+    sum(osl."quantity") AS "units",
+    sum(osl."net_price") AS "revenue",
+    count(DISTINCT o."id") AS "order_count"
+  FROM "orders" o
+  JOIN "order_sales_lines" osl ON osl."order_id" = o."id"
+  WHERE o."voided_at" IS NULL
+  GROUP BY 1, 2;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "product_sales_hourly_unique_idx"
+  ON "product_sales_hourly" ("sold_hour", "product_id");
+
+CREATE INDEX IF NOT EXISTS "product_sales_hourly_product_id_idx"
+  ON "product_sales_hourly" ("product_id", "sold_hour");
@@ -0,0 +1,115 @@
+-- quantities are rounded back to whole units
+DROP MATERIALIZED VIEW IF EXISTS "product_sales_hourly";
+
+DROP VIEW IF EXISTS "order_sales_lines";
+
+ALTER TABLE "order_product"
+  DROP COLUMN IF EXISTS "net_price",
+  DROP COLUMN IF EXISTS "total_price";
+
+ALTER TABLE "order_product"
+  ALTER COLUMN "quantity" TYPE int USING round("quantity");
+
+ALTER TABLE "order_product"
+  ADD COLUMN IF NOT EXISTS "total_price" numeric(10,2) NOT NULL
+    GENERATED ALWAYS AS ("quantity" * "price") STORED,
+  ADD COLUMN IF NOT EXISTS "net_price" numeric(10,2) NOT NULL
+    GENERATED ALWAYS AS ("quantity" * "price" - "discount_amount") STORED;
+
+ALTER TABLE "order_product_components"
+  ALTER COLUMN "quantity" TYPE int USING round("quantity");
+
+ALTER TABLE "products"
+  ALTER COLUMN "stock" TYPE int USING round("stock"),
+  ALTER COLUMN "reorder_point" TYPE int USING round("reorder_point"),
+  ALTER COLUMN "reorder_quantity" TYPE int USING round("reorder_quantity");
+
+ALTER TABLE "product_stocks"
+  ALTER COLUMN "stock" TYPE int USING round("stock");
+
+ALTER TABLE "product_bundle_components"
+  ALTER COLUMN "quantity" TYPE int USING greatest(round("quantity"), 1);
+
+ALTER TABLE "stock_alerts"
+  ALTER COLUMN "stock" TYPE int USING round("stock"),
+  ALTER COLUMN "reorder_point" TYPE int USING round("reorder_point"),
+  ALTER COLUMN "reorder_quantity" TYPE int USING round("reorder_quantity");
+
+ALTER TABLE "purchase_order_lines"
+  ALTER COLUMN "quantity" TYPE int USING greatest(round("quantity"), 1),
+  ALTER COLUMN "received_quantity" TYPE int USING least(
+    round("received_quantity"),
+    greatest(round("quantity"), 1)
+  );
+
+ALTER TABLE "stock_movements"
+  ALTER COLUMN "quantity" TYPE int USING round("quantity");
+
+ALTER TABLE "stock_transfer_lines"
+  ALTER COLUMN "quantity" TYPE int USING round("quantity");
+
+ALTER TABLE "stock_count_lines"
+  ALTER COLUMN "expected" TYPE int USING round("expected"),
+  ALTER COLUMN "counted" TYPE int USING round("counted"),
+  ALTER COLUMN "stock_at_count" TYPE int USING round("stock_at_count");
+
+CREATE OR REPLACE FUNCTION "bundle_stock" ("bundle" uuid)
+RETURNS int
+LANGUAGE sql
+STABLE
+AS $$
+  SELECT coalesce(min(
+    CASE
+      WHEN c."deleted_at" IS NULL THEN c."stock" / pbc."quantity"
+      ELSE 0
+    END
+  ), 0)::int
+  FROM "product_bundle_components" pbc
+  JOIN "products" c ON c."id" = pbc."component_id"
+  WHERE pbc."bundle_id" = "bundle"
+$$;
+
+CREATE OR REPLACE VIEW "order_sales_lines" AS
+  SELECT
+    op."order_id",
+    op."product_id",
+    op."quantity",
+    op."net_price",
+    op."tax_amount",
+    op."cost"
+  FROM "order_product" op
+  WHERE NOT op."is_bundle"
+  UNION ALL
+  SELECT
+    opc."order_id",
+    opc."product_id",
+    opc."quantity",
+    opc."net_price",
+    opc."tax_amount",
+    opc."cost"
+  FROM "order_product_components" opc;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS "product_sales_hourly" AS
+  SELECT
+    date_trunc('hour', o."created_at") AS "sold_hour",
+    osl."product_id",
+    sum(osl."quantity") AS "units",
+    sum(osl."net_price") AS "revenue",
+    count(DISTINCT o."id") AS "order_count"
+  FROM "orders" o
+  JOIN "order_sales_lines" osl ON osl."order_id" = o."id"
+  WHERE o."voided_at" IS NULL
+  GROUP BY 1, 2;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "product_sales_hourly_unique_idx"
+  ON "product_sales_hourly" ("sold_hour", "product_id");
+
+CREATE INDEX IF NOT EXISTS "product_sales_hourly_product_id_idx"
+  ON "product_sales_hourly" ("product_id", "sold_hour");
+
+DROP TABLE IF EXISTS "product_packs";
+
+ALTER TABLE "products"
+  DROP COLUMN IF EXISTS "unit";
+
+DROP TYPE IF EXISTS "unit_of_measure";
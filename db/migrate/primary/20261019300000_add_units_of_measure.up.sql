@@ -0,0 +1,144 @@
+-- the unit a product is stocked and sold in, the units that can be divided
+-- take quantities with up to 3 decimals
+CREATE TYPE "unit_of_measure" AS ENUM (
+  'piece',
+  'kg',
+  'g',
+  'l',
+  'ml',
+  'm'
+);
+
+ALTER TABLE "products"
+  ADD COLUMN IF NOT EXISTS "unit" unit_of_measure NOT NULL DEFAULT 'piece';
+
+-- a pack holds quantity units of the product, a case of 24 for instance.
+-- Its own sku lets a scanner read the pack as that many units.
+CREATE TABLE IF NOT EXISTS "product_packs" (
+  "id" uuid NOT NULL,
+  "product_id" uuid NOT NULL,
+  "name" varchar(30) NOT NULL,
+  "sku" varchar(30) NOT NULL,
+  "quantity" numeric(12,3) NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "deleted_at" timestamp NULL DEFAULT NULL,
+  PRIMARY KEY ("id"),
+  FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE,
+  CHECK ("quantity" > 0)
+);
+
+CREATE INDEX IF NOT EXISTS "product_packs_product_id_idx"
+  ON "product_packs" ("product_id")
+  WHERE "deleted_at" IS NULL;
+
+CREATE INDEX IF NOT EXISTS "product_packs_sku_idx"
+  ON "product_packs" ("sku")
+  WHERE "deleted_at" IS NULL;
+
+-- the views over the order lines are put back once the quantities changed
+-- type, as are the generated prices
+DROP MATERIALIZED VIEW IF EXISTS "product_sales_hourly";
+
+DROP VIEW IF EXISTS "order_sales_lines";
+
+ALTER TABLE "order_product"
+  DROP COLUMN IF EXISTS "net_price",
+  DROP COLUMN IF EXISTS "total_price";
+
+ALTER TABLE "order_product"
+  ALTER COLUMN "quantity" TYPE numeric(12,3);
+
+ALTER TABLE "order_product"
+  ADD COLUMN IF NOT EXISTS "total_price" numeric(10,2) NOT NULL
+    GENERATED ALWAYS AS ("quantity" * "price") STORED,
+  ADD COLUMN IF NOT EXISTS "net_price" numeric(10,2) NOT NULL
+    GENERATED ALWAYS AS ("quantity" * "price" - "discount_amount") STORED;
+
+ALTER TABLE "order_product_components"
+  ALTER COLUMN "quantity" TYPE numeric(12,3);
+
+ALTER TABLE "products"
+  ALTER COLUMN "stock" TYPE numeric(12,3),
+  ALTER COLUMN "reorder_point" TYPE numeric(12,3),
+  ALTER COLUMN "reorder_quantity" TYPE numeric(12,3);
+
+ALTER TABLE "product_stocks"
+  ALTER COLUMN "stock" TYPE numeric(12,3);
+
+ALTER TABLE "product_bundle_components"
+  ALTER COLUMN "quantity" TYPE numeric(12,3);
+
+ALTER TABLE "stock_alerts"
+  ALTER COLUMN "stock" TYPE numeric(12,3),
+  ALTER COLUMN "reorder_point" TYPE numeric(12,3),
+  ALTER COLUMN "reorder_quantity" TYPE numeric(12,3);
+
+ALTER TABLE "purchase_order_lines"
+  ALTER COLUMN "quantity" TYPE numeric(12,3),
+  ALTER COLUMN "received_quantity" TYPE numeric(12,3);
+
+ALTER TABLE "stock_movements"
+  ALTER COLUMN "quantity" TYPE numeric(12,3);
+
+ALTER TABLE "stock_transfer_lines"
+  ALTER COLUMN "quantity" TYPE numeric(12,3);
+
+ALTER TABLE "stock_count_lines"
+  ALTER COLUMN "expected" TYPE numeric(12,3),
+  ALTER COLUMN "counted" TYPE numeric(12,3),
+  ALTER COLUMN "stock_at_count" TYPE numeric(12,3);
+
+-- a bundle is still sold whole, whatever the units of its components
+CREATE OR REPLACE FUNCTION "bundle_stock" ("bundle" uuid)
+RETURNS int
+LANGUAGE sql
+STABLE
+AS $$
+  SELECT coalesce(min(
+    CASE
+      WHEN c."deleted_at" IS NULL THEN floor(c."stock" / pbc."quantity")
+      ELSE 0
+    END
+  ), 0)::int
+  FROM "product_bundle_components" pbc
+  JOIN "products" c ON c."id" = pbc."component_id"
+  WHERE pbc."bundle_id" = "bundle"
+$$;
+
+CREATE OR REPLACE VIEW "order_sales_lines" AS
+  SELECT
+    op."order_id",
+    op."product_id",
+    op."quantity",
+    op."net_price",
+    op."tax_amount",
+    op."cost"
+  FROM "order_product" op
+  WHERE NOT op."is_bundle"
+  UNION ALL
+  SELECT
+    opc."order_id",
+    opc."product_id",
+    opc."quantity",
+    opc."net_price",
+    opc."tax_amount",
+    opc."cost"
+  FROM "order_product_components" opc;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS "product_sales_hourly" AS
+  SELECT
+    date_trunc('hour', o."created_at") AS "sold_hour",
+    osl."product_id",
+    sum(osl."quantity") AS "units",
+    sum(osl."net_price") AS "revenue",
+    count(DISTINCT o."id") AS "order_count"
+  FROM "orders" o
+  JOIN "order_sales_lines" osl ON osl."order_id" = o."id"
+  WHERE o."voided_at" IS NULL
+  GROUP BY 1, 2;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "product_sales_hourly_unique_idx"
+  ON "product_sales_hourly" ("sold_hour", "product_id");
+
+CREATE INDEX IF NOT EXISTS "product_sales_hourly_product_id_idx"
+  ON "product_sales_hourly" ("product_id", "sold_hour");
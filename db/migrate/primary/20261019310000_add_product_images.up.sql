@@ -0,0 +1,25 @@
+-- images uploaded for a product, shown in the order of position. The
+-- first one is also kept as the image_url of the product. The keys are
+-- where the image and its thumbnail are in the image storage.
+CREATE TABLE IF NOT EXISTS "product_images" (
+  "id" uuid NOT NULL,
+  "product_id" uuid NOT NULL,
+  "position" int NOT NULL,
+  "url" varchar(255) NOT NULL,
+  "thumbnail_url" varchar(255) NOT NULL,
+  "storage_key" varchar(255) NOT NULL,
+  "thumbnail_key" varchar(255) NOT NULL,
+  "content_type" varchar(30) NOT NULL,
+  "size" int NOT NULL,
+  "width" int NOT NULL,
+  "height" int NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "created_by" uuid NOT NULL,
+  PRIMARY KEY ("id"),
+  FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE,
+  FOREIGN KEY ("created_by") REFERENCES "users" ("id"),
+  CHECK ("position" >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS "product_images_product_id_idx"
+  ON "product_images" ("product_id", "position");
@@ -0,0 +1,58 @@
+DROP MATERIALIZED VIEW IF EXISTS "product_sales_hourly";
+
+DROP VIEW IF EXISTS "order_sales_lines";
+
+ALTER TABLE "order_product"
+  DROP COLUMN IF EXISTS "net_price";
+
+ALTER TABLE "order_product"
+  ADD COLUMN IF NOT EXISTS "net_price" numeric(10,2) NOT NULL
+    GENERATED ALWAYS AS ("quantity" * "price" - "discount_amount") STORED;
+
+UPDATE "order_product_components"
+SET "net_price" = "net_price" + "coupon_discount"
+WHERE "coupon_discount" <> 0;
+
+ALTER TABLE "order_product_components"
+  DROP COLUMN IF EXISTS "coupon_discount";
+
+ALTER TABLE "order_product"
+  DROP COLUMN IF EXISTS "coupon_discount";
+
+CREATE OR REPLACE VIEW "order_sales_lines" AS
+  SELECT
+    op."order_id",
+    op."product_id",
+    op."quantity",
+    op."net_price",
+    op."tax_amount",
+    op."cost"
+  FROM "order_product" op
+  WHERE NOT op."is_bundle"
+  UNION ALL
+  SELECT
+    opc."order_id",
+    opc."product_id",
+    opc."quantity",
+    opc."net_price",
+    opc."tax_amount",
+    opc."cost"
+  FROM "order_product_components" opc;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS "product_sales_hourly" AS
+  SELECT
+    date_trunc('hour', o."created_at") AS "sold_hour",
+    osl."product_id",
+    sum(osl."quantity") AS "units",
+    sum(osl."net_price") AS "revenue",
+    count(DISTINCT o."id") AS "order_count"
+  FROM "orders" o
+  JOIN "order_sales_lines" osl ON osl."order_id" = o."id"
+  WHERE o."voided_at" IS NULL
+  GROUP BY 1, 2;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "product_sales_hourly_unique_idx"
+  ON "product_sales_hourly" ("sold_hour", "product_id");
+
+CREATE INDEX IF NOT EXISTS "product_sales_hourly_product_id_idx"
+  ON "product_sales_hourly" ("product_id", "sold_hour");
@@ -0,0 +1,151 @@
+-- the share of the order coupon taken off each line, so that the net price
+-- of the lines adds up to what the order was charged. The views over the
+-- order lines are put back once net_price takes it off.
+DROP MATERIALIZED VIEW IF EXISTS "product_sales_hourly";
+
+DROP VIEW IF EXISTS "order_sales_lines";
+
+ALTER TABLE "order_product"
+  ADD COLUMN IF NOT EXISTS "coupon_discount" numeric(10,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE "order_product_components"
+  ADD COLUMN IF NOT EXISTS "coupon_discount" numeric(10,2) NOT NULL DEFAULT 0;
+
+-- past orders get their coupon spread over the lines it covered by their
+-- amount after promotions, the last line takes the rounding, as checkout
+-- does
+WITH "eligible" AS (
+  SELECT
+    op."order_id",
+    op."product_id",
+    o."coupon_discount",
+    op."quantity" * op."price" - op."discount_amount" AS "amount",
+    sum(op."quantity" * op."price" - op."discount_amount")
+      OVER (PARTITION BY op."order_id") AS "eligible_amount",
+    row_number() OVER (
+      PARTITION BY op."order_id"
+      ORDER BY op."product_id" DESC
+    ) AS "rn"
+  FROM "orders" o
+  JOIN "coupons" cp ON cp."id" = o."coupon_id"
+  JOIN "order_product" op ON op."order_id" = o."id"
+  JOIN "products" p ON p."id" = op."product_id"
+  WHERE o."coupon_discount" > 0
+    AND (cp."product_id" IS NULL OR cp."product_id" = op."product_id")
+    AND (cp."category" IS NULL OR cp."category" = p."category")
+),
+"shares" AS (
+  SELECT
+    "order_id",
+    "product_id",
+    "coupon_discount",
+    "rn",
+    round("coupon_discount" * "amount" / nullif("eligible_amount", 0), 2)
+      AS "share"
+  FROM "eligible"
+),
+"allocated" AS (
+  SELECT
+    "order_id",
+    "product_id",
+    CASE
+      WHEN "rn" = 1 THEN "coupon_discount" - (
+        sum("share") OVER (PARTITION BY "order_id") - "share"
+      )
+      ELSE "share"
+    END AS "coupon_discount"
+  FROM "shares"
+)
+UPDATE "order_product" op
+SET "coupon_discount" = a."coupon_discount"
+FROM "allocated" a
+WHERE a."order_id" = op."order_id" AND a."product_id" = op."product_id";
+
+-- the share of a bundle line goes to its components by their net price,
+-- which is then taken after the coupon like that of the line
+WITH "shares" AS (
+  SELECT
+    opc."order_id",
+    opc."bundle_id",
+    opc."product_id",
+    op."coupon_discount",
+    round(
+      op."coupon_discount" * opc."net_price" /
+        nullif(sum(opc."net_price") OVER w, 0),
+      2
+    ) AS "share",
+    row_number() OVER (w ORDER BY opc."product_id" DESC) AS "rn"
+  FROM "order_product_components" opc
+  JOIN "order_product" op
+    ON op."order_id" = opc."order_id" AND op."product_id" = opc."bundle_id"
+  WHERE op."coupon_discount" > 0
+  WINDOW w AS (PARTITION BY opc."order_id", opc."bundle_id")
+),
+"allocated" AS (
+  SELECT
+    "order_id",
+    "bundle_id",
+    "product_id",
+    CASE
+      WHEN "rn" = 1 THEN "coupon_discount" - (
+        sum("share") OVER (PARTITION BY "order_id", "bundle_id") - "share"
+      )
+      ELSE "share"
+    END AS "coupon_discount"
+  FROM "shares"
+)
+UPDATE "order_product_components" opc
+SET
+  "coupon_discount" = a."coupon_discount",
+  "net_price" = opc."net_price" - a."coupon_discount"
+FROM "allocated" a
+WHERE a."order_id" = opc."order_id"
+  AND a."bundle_id" = opc."bundle_id"
+  AND a."product_id" = opc."product_id";
+
+ALTER TABLE "order_product"
+  DROP COLUMN IF EXISTS "net_price";
+
+ALTER TABLE "order_product"
+  ADD COLUMN IF NOT EXISTS "net_price" numeric(10,2) NOT NULL
+    GENERATED ALWAYS AS (
+      "quantity" * "price" - "discount_amount" - "coupon_discount"
+    ) STORED;
+
+CREATE OR REPLACE VIEW "order_sales_lines" AS
+  SELECT
+    op."order_id",
+    op."product_id",
+    op."quantity",
+    op."net_price",
+    op."tax_amount",
+    op."cost"
+  FROM "order_product" op
+  WHERE NOT op."is_bundle"
+  UNION ALL
+  SELECT
+    opc."order_id",
+    opc."product_id",
+    opc."quantity",
+    opc."net_price",
+    opc."tax_amount",
+    opc."cost"
+  FROM "order_product_components" opc;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS "product_sales_hourly" AS
+  SELECT
+    date_trunc('hour', o."created_at") AS "sold_hour",
+    osl."product_id",
+    sum(osl."quantity") AS "units",
+    sum(osl."net_price") AS "revenue",
+    count(DISTINCT o."id") AS "order_count"
+  FROM "orders" o
+  JOIN "order_sales_lines" osl ON osl."order_id" = o."id"
+  WHERE o."voided_at" IS NULL
+  GROUP BY 1, 2;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "product_sales_hourly_unique_idx"
+  ON "product_sales_hourly" ("sold_hour", "product_id");
+
+CREATE INDEX IF NOT EXISTS "product_sales_hourly_product_id_idx"
+  ON "product_sales_hourly" ("product_id", "sold_hour");
@@ -1,8 +1,21 @@
 package config
 
+import "time"
+
 type Config struct {
 	DB         DBConfig
+	Loyalty    LoyaltyConfig
+	Tax        TaxConfig
+	Shift      ShiftConfig
+	Analytics  AnalyticsConfig
+	Alert      AlertConfig
+	Cost       CostConfig
+	StockCount StockCountConfig
+	Image      ImageConfig
 	JWTSecret  string `json:"JWT_SECRET"`
+	// TimeZone is the IANA time zone of the store, days of the reports
+	// start at its midnight
+	TimeZone   string `json:"STORE_TIME_ZONE" envDefault:"Asia/Jakarta"`
 	BCryptSalt uint8  `json:"BCRYPT_SALT"`
 }
 
@@ -14,3 +27,92 @@ type DBConfig struct {
 	DBPassword string `json:"DB_PASSWORD"`
 	DBParams   string `json:"DB_PARAMS"`
 }
+
+type LoyaltyConfig struct {
+	// EarnRate is the number of points earned per currency unit paid
+	EarnRate float64 `json:"LOYALTY_EARN_RATE" envDefault:"0.001"`
+	// PointValue is the amount of money one point is worth on redemption
+	PointValue float64 `json:"LOYALTY_POINT_VALUE" envDefault:"1"`
+}
+
+const (
+	TaxRoundingPerLine    = "line"
+	TaxRoundingPerInvoice = "invoice"
+)
+
+type TaxConfig struct {
+	// PricesIncludeTax is set when product prices already contain the tax,
+	// otherwise the tax is added on top at checkout
+	PricesIncludeTax bool `json:"TAX_PRICES_INCLUDE_TAX" envDefault:"true"`
+	// DefaultRate is the rate in percent for products without a rate of
+	// their own or of their category
+	DefaultRate float64 `json:"TAX_DEFAULT_RATE" envDefault:"11"`
+	// Rounding is either TaxRoundingPerLine or TaxRoundingPerInvoice
+	Rounding string `json:"TAX_ROUNDING" envDefault:"line"`
+}
+
+type ShiftConfig struct {
+	// Required refuses checkouts from cashiers without an open shift,
+	// otherwise their orders are saved without a shift
+	Required bool `json:"SHIFT_REQUIRED" envDefault:"false"`
+}
+
+type AnalyticsConfig struct {
+	// RefreshInterval is how often the sales rollups behind the analytics
+	// reports are refreshed, 0 turns the refresh off
+	RefreshInterval time.Duration `json:"ANALYTICS_REFRESH_INTERVAL" envDefault:"15m"`
+}
+
+type AlertConfig struct {
+	// Notifier sends the low stock alerts, either log or webhook
+	Notifier string `json:"ALERT_NOTIFIER" envDefault:"log"`
+	// WebhookURL receives the alerts as JSON posts with the webhook notifier
+	WebhookURL string `json:"ALERT_WEBHOOK_URL"`
+	// PollInterval is how often new alerts are looked for, 0 stops sending
+	PollInterval time.Duration `json:"ALERT_POLL_INTERVAL" envDefault:"30s"`
+}
+
+const (
+	CostMethodLast    = "last"
+	CostMethodAverage = "average"
+)
+
+type CostConfig struct {
+	// Method is how receiving stock changes the product cost, either
+	// CostMethodLast for the unit cost of the delivery or CostMethodAverage
+	// for the weighted average of the stock on hand and the delivery
+	Method string `json:"COST_METHOD" envDefault:"last"`
+}
+
+type StockCountConfig struct {
+	// FreezeSales refuses checkouts of products on an open stock count at
+	// the location of the order. Otherwise they are sold as usual and
+	// posting the count keeps the sales made after a product was counted.
+	FreezeSales bool `json:"STOCK_COUNT_FREEZE_SALES" envDefault:"false"`
+}
+
+const (
+	ImageStorageLocal = "local"
+	ImageStorageS3    = "s3"
+)
+
+type ImageConfig struct {
+	// Storage keeps the uploaded product images, either ImageStorageLocal
+	// or ImageStorageS3
+	Storage string `json:"IMAGE_STORAGE" envDefault:"local"`
+	// Dir is where the local storage writes the images, they are served
+	// under BaseURL
+	Dir string `json:"IMAGE_DIR" envDefault:"uploads/images"`
+	// BaseURL is what the keys of the images are appended to for their
+	// URLs, a path served by the app for the local storage or the public
+	// URL of the bucket for s3
+	BaseURL string `json:"IMAGE_BASE_URL" envDefault:"/images"`
+	// S3Endpoint and S3Bucket are where the s3 storage puts the images
+	S3Endpoint string `json:"IMAGE_S3_ENDPOINT"`
+	S3Bucket   string `json:"IMAGE_S3_BUCKET"`
+	// MaxSize is the largest upload in bytes, it has to stay under the 4MB
+	// body limit of the app
+	MaxSize int64 `json:"IMAGE_MAX_SIZE" envDefault:"2097152"`
+	// ThumbnailSize is the longest side of the thumbnails in pixels
+	ThumbnailSize int `json:"IMAGE_THUMBNAIL_SIZE" envDefault:"256"`
+}
@@ -22,6 +22,10 @@ var (
 		"not found",
 	)
 
+	ErrUnauthorized = errors.New(
+		"unauthorized",
+	)
+
 	ErrSavingData = errors.New(
 		"failed to save data",
 	)
@@ -37,4 +41,92 @@ var (
 	ErrInvalidChange = errors.New(
 		"invalid change",
 	)
+
+	ErrInvalidPhoneNumber = errors.New(
+		"invalid phone number",
+	)
+
+	ErrInsufficientPoints = errors.New(
+		"insufficient loyalty points",
+	)
+
+	ErrOrderVoided = errors.New(
+		"order already voided",
+	)
+
+	ErrInvalidCoupon = errors.New(
+		"invalid or expired coupon",
+	)
+
+	ErrCouponLimitReached = errors.New(
+		"coupon usage limit reached",
+	)
+
+	ErrTenderExceedsDue = errors.New(
+		"non-cash tenders exceed the amount due",
+	)
+
+	ErrStoreCreditUnsupported = errors.New(
+		"store credit is not supported",
+	)
+
+	ErrShiftAlreadyOpen = errors.New(
+		"cashier already has an open shift",
+	)
+
+	ErrShiftClosed = errors.New(
+		"shift already closed",
+	)
+
+	ErrNoOpenShift = errors.New(
+		"no open shift",
+	)
+
+	ErrDayClosed = errors.New(
+		"day already closed",
+	)
+
+	ErrPurchaseOrderClosed = errors.New(
+		"purchase order already received or cancelled",
+	)
+
+	ErrExceedsOrdered = errors.New(
+		"quantity exceeds what is left to receive",
+	)
+
+	ErrLocationExists = errors.New(
+		"location name already taken",
+	)
+
+	ErrLocationInUse = errors.New(
+		"location is the default or still holds stock",
+	)
+
+	ErrTransferClosed = errors.New(
+		"stock transfer already received or cancelled",
+	)
+
+	ErrStockCountOpen = errors.New(
+		"location already has an open stock count",
+	)
+
+	ErrStockCountClosed = errors.New(
+		"stock count already posted or cancelled",
+	)
+
+	ErrStockCountInProgress = errors.New(
+		"products are being counted at this location",
+	)
+
+	ErrUnsupportedImage = errors.New(
+		"image must be a jpeg, png or gif",
+	)
+
+	ErrImageTooLarge = errors.New(
+		"image is larger than allowed",
+	)
+
+	ErrTooManyImages = errors.New(
+		"product already has the most images allowed",
+	)
 )
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/service"
+)
+
+type AnalyticsHandler struct {
+	AnalyticsService *service.AnalyticsService
+}
+
+func NewAnalyticsHandler(
+	analyticsService *service.AnalyticsService,
+) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		AnalyticsService: analyticsService,
+	}
+}
+
+// GetTopProducts ranks the products sold in a date range by revenue or by
+// units.
+func (handler *AnalyticsHandler) GetTopProducts(
+	c *fiber.Ctx,
+) error {
+	var queries model.TopProductsQuery
+	err := c.QueryParser(&queries)
+	if err != nil || !queries.IsValid() {
+		return invalidAnalyticsQuery(c, err)
+	}
+
+	products, err := handler.AnalyticsService.FindTopProducts(
+		c.Context(),
+		queries,
+	)
+	if err != nil {
+		return analyticsError(c, "top products", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    products,
+	})
+}
+
+// GetSlowMovers lists the products not sold in the last days of the query.
+func (handler *AnalyticsHandler) GetSlowMovers(
+	c *fiber.Ctx,
+) error {
+	var queries model.SlowMoversQuery
+	err := c.QueryParser(&queries)
+	if err != nil || !queries.IsValid() {
+		return invalidAnalyticsQuery(c, err)
+	}
+
+	products, err := handler.AnalyticsService.FindSlowMovers(
+		c.Context(),
+		queries,
+	)
+	if err != nil {
+		return analyticsError(c, "slow movers", err)
+	}
+
+	data := make(
+		[]model.SlowMoverResponseBody,
+		0,
+		len(products),
+	)
+	for _, product := range products {
+		data = append(data, product.ToResponseBody())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    data,
+	})
+}
+
+// GetHeatmap is the sales of a date range by weekday and hour.
+func (handler *AnalyticsHandler) GetHeatmap(
+	c *fiber.Ctx,
+) error {
+	var queries model.DateRangeQuery
+	err := c.QueryParser(&queries)
+	if err != nil || !queries.IsValid() {
+		return invalidAnalyticsQuery(c, err)
+	}
+
+	cells, err := handler.AnalyticsService.FindHeatmap(
+		c.Context(),
+		queries,
+	)
+	if err != nil {
+		return analyticsError(c, "sales heatmap", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    cells,
+	})
+}
+
+// GetCategoryTrends is the revenue of every category per day, week or
+// month of a date range.
+func (handler *AnalyticsHandler) GetCategoryTrends(
+	c *fiber.Ctx,
+) error {
+	var queries model.CategoryTrendQuery
+	err := c.QueryParser(&queries)
+	if err != nil || !queries.IsValid() {
+		return invalidAnalyticsQuery(c, err)
+	}
+
+	trends, err := handler.AnalyticsService.FindCategoryTrends(
+		c.Context(),
+		queries,
+	)
+	if err != nil {
+		return analyticsError(c, "category trends", err)
+	}
+
+	data := make(
+		[]model.CategoryTrendResponseBody,
+		0,
+		len(trends),
+	)
+	for _, trend := range trends {
+		data = append(data, trend.ToResponseBody())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    data,
+	})
+}
+
+func invalidAnalyticsQuery(c *fiber.Ctx, err error) error {
+	return HandleError(
+		c,
+		ErrorResponse{
+			message: "invalid query",
+			error:   constant.ErrBadInput,
+			detail: fmt.Sprintf(
+				"invalid analytics query: %v",
+				err,
+			),
+		},
+	)
+}
+
+func analyticsError(c *fiber.Ctx, report string, err error) error {
+	return HandleError(
+		c,
+		ErrorResponse{
+			message: "unable to get " + report,
+			error:   err,
+			detail: fmt.Sprintf(
+				"unable to get %s: %v",
+				report,
+				err.Error(),
+			),
+		},
+	)
+}
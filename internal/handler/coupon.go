@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/service"
+)
+
+type CouponHandler struct {
+	CouponService *service.CouponService
+}
+
+func NewCouponHandler(
+	couponService *service.CouponService,
+) *CouponHandler {
+	return &CouponHandler{
+		CouponService: couponService,
+	}
+}
+
+func (handler *CouponHandler) Create(
+	c *fiber.Ctx,
+) error {
+	var body model.CouponRequestBody
+	err := c.BodyParser(&body)
+	if err != nil || !body.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid coupon body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	coupon, err := handler.CouponService.Create(
+		c.Context(),
+		body.ToCoupon(),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "unable to create coupon",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to create coupon: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    coupon.ToResponseBody(),
+	})
+}
+
+func (handler *CouponHandler) Search(
+	c *fiber.Ctx,
+) error {
+	var queries model.SearchCouponQuery
+	err := c.QueryParser(&queries)
+	if err != nil || !queries.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid query",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid coupon query: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	coupons, err := handler.CouponService.Search(
+		c.Context(),
+		queries,
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "unable to search coupons",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to search coupons: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	data := make(
+		[]model.CouponResponseBody,
+		0,
+		len(coupons),
+	)
+	for _, coupon := range coupons {
+		data = append(data, coupon.ToResponseBody())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    data,
+	})
+}
+
+func (handler *CouponHandler) GetByID(
+	c *fiber.Ctx,
+) error {
+	coupon, err := handler.CouponService.FindByID(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "coupon not found",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to get coupon: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    coupon.ToResponseBody(),
+	})
+}
+
+func (handler *CouponHandler) Update(
+	c *fiber.Ctx,
+) error {
+	var body model.CouponRequestBody
+	err := c.BodyParser(&body)
+	if err != nil || !body.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid coupon body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	coupon, err := handler.CouponService.Update(
+		c.Context(),
+		c.Params("id"),
+		body.ToCoupon(),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "unable to update coupon",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to update coupon: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    coupon.ToResponseBody(),
+	})
+}
+
+func (handler *CouponHandler) Delete(
+	c *fiber.Ctx,
+) error {
+	err := handler.CouponService.Delete(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "unable to delete coupon",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to delete coupon: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+	})
+}
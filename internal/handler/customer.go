@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
 	"github.com/nozzlium/eniqilo_store/internal/middleware"
 	"github.com/nozzlium/eniqilo_store/internal/model"
 	"github.com/nozzlium/eniqilo_store/internal/service"
@@ -100,27 +102,150 @@ func (handler *CustomerHandler) Register(
 func (handler *CustomerHandler) GetCustomers(
 	c *fiber.Ctx,
 ) error {
-	phoneNumber := c.Query(
-		"phoneNumber",
-		"",
+	var query model.SearchCustomerQuery
+	err := c.QueryParser(&query)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{
+				"message": err.Error(),
+			})
+	}
+
+	if !query.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid query",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid customer query: %+v",
+					query,
+				),
+			},
+		)
+	}
+
+	customerData, nextCursor, err := handler.CustomerService.FindCustomers(
+		c.Context(),
+		query,
 	)
-	name := c.Query("name", "")
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "error get customer",
+				error:   err,
+				detail: fmt.Sprintf(
+					"error get customer: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	res := withNextCursor(fiber.Map{
+		"message": "success",
+		"data":    customerData,
+	}, nextCursor)
+
+	if wantsPaginationMeta(c) {
+		total, isEstimate, err := handler.CustomerService.Count(
+			c.Context(),
+			query,
+		)
+		if err != nil {
+			return HandleError(
+				c,
+				ErrorResponse{
+					message: "error count customer",
+					error:   err,
+					detail: fmt.Sprintf(
+						"error count customer: %v",
+						err.Error(),
+					),
+				},
+			)
+		}
+
+		res["meta"] = query.BuildPaginationMeta(
+			total,
+			isEstimate,
+			len(customerData),
+			nextCursor,
+		)
+	}
+
+	return c.JSON(res)
+}
 
-	customerData, err := handler.CustomerService.FindCustomers(
+func (handler *CustomerHandler) Export(
+	c *fiber.Ctx,
+) error {
+	var query model.SearchCustomerQuery
+	err := c.QueryParser(&query)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{
+				"message": err.Error(),
+			})
+	}
+
+	return streamExport(
+		c,
+		"customers",
+		model.CustomerExportHeader,
+		func(
+			ctx context.Context,
+			writeRow func(values []any) error,
+		) error {
+			return handler.CustomerService.Export(
+				ctx,
+				query,
+				func(customer model.Customer) error {
+					return writeRow(
+						customer.ToExportRow(),
+					)
+				},
+			)
+		},
+	)
+}
+
+func (handler *CustomerHandler) Update(
+	c *fiber.Ctx,
+) error {
+	var body model.CustomerUpdateBody
+	err := c.BodyParser(&body)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{
+				"message": err.Error(),
+			})
+	}
+
+	if !body.IsValid() {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{
+				"message": "request does not pass validation",
+			})
+	}
+
+	customerData, err := handler.CustomerService.Update(
 		c.Context(),
+		c.Params("id"),
 		model.Customer{
-			PhoneNumber: phoneNumber,
-			Name:        name,
+			PhoneNumber: body.PhoneNumber,
+			Name:        body.Name,
 		},
 	)
 	if err != nil {
 		return HandleError(
 			c,
 			ErrorResponse{
-				message: "error get customer",
+				message: "error updating customer",
 				error:   err,
 				detail: fmt.Sprintf(
-					"error get customer: %v",
+					"error updating customer: %v",
 					err.Error(),
 				),
 			},
@@ -132,3 +257,175 @@ func (handler *CustomerHandler) GetCustomers(
 		"data":    customerData,
 	})
 }
+
+func (handler *CustomerHandler) Delete(
+	c *fiber.Ctx,
+) error {
+	err := handler.CustomerService.Delete(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "error deleting customer",
+				error:   err,
+				detail: fmt.Sprintf(
+					"error deleting customer: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+	})
+}
+
+func (handler *CustomerHandler) Erase(
+	c *fiber.Ctx,
+) error {
+	err := handler.CustomerService.Erase(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "error erasing customer",
+				error:   err,
+				detail: fmt.Sprintf(
+					"error erasing customer: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+	})
+}
+
+func (handler *CustomerHandler) ExportData(
+	c *fiber.Ctx,
+) error {
+	data, err := handler.CustomerService.ExportData(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "error exporting customer data",
+				error:   err,
+				detail: fmt.Sprintf(
+					"error exporting customer data: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    data,
+	})
+}
+
+func (handler *CustomerHandler) GetDetail(
+	c *fiber.Ctx,
+) error {
+	detail, err := handler.CustomerService.GetDetail(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "error get customer",
+				error:   err,
+				detail: fmt.Sprintf(
+					"error get customer detail: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    detail,
+	})
+}
+
+func (handler *CustomerHandler) Merge(
+	c *fiber.Ctx,
+) error {
+	var body model.CustomerMergeBody
+	err := c.BodyParser(&body)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{
+				"message": err.Error(),
+			})
+	}
+
+	merge, err := handler.CustomerService.Merge(
+		c.Context(),
+		c.Params("id"),
+		body.DuplicateID,
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "error merging customer",
+				error:   err,
+				detail: fmt.Sprintf(
+					"error merging customer %s into %s: %v",
+					body.DuplicateID,
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    merge.ToResponseBody(),
+	})
+}
+
+func (handler *CustomerHandler) GetDuplicateCandidates(
+	c *fiber.Ctx,
+) error {
+	candidates, err := handler.CustomerService.FindDuplicateCandidates(
+		c.Context(),
+		c.QueryInt("limit", 0),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "error get duplicate customers",
+				error:   err,
+				detail: fmt.Sprintf(
+					"error get duplicate customers: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    candidates,
+	})
+}
@@ -18,7 +18,23 @@ func HandleError(
 			JSON(fiber.Map{
 				"message": err.message,
 			})
-	case constant.ErrConflict:
+	case constant.ErrUnauthorized:
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{
+				"message": err.message,
+			})
+	case constant.ErrConflict,
+		constant.ErrOrderVoided,
+		constant.ErrShiftAlreadyOpen,
+		constant.ErrShiftClosed,
+		constant.ErrDayClosed,
+		constant.ErrPurchaseOrderClosed,
+		constant.ErrLocationExists,
+		constant.ErrLocationInUse,
+		constant.ErrTransferClosed,
+		constant.ErrStockCountOpen,
+		constant.ErrStockCountClosed,
+		constant.ErrStockCountInProgress:
 		return ctx.Status(fiber.StatusConflict).
 			JSON(fiber.Map{
 				"message": err.message,
@@ -27,6 +43,17 @@ func HandleError(
 		constant.ErrInvalidBody,
 		constant.ErrInsufficientFund,
 		constant.ErrInvalidChange,
+		constant.ErrInvalidPhoneNumber,
+		constant.ErrInsufficientPoints,
+		constant.ErrInvalidCoupon,
+		constant.ErrCouponLimitReached,
+		constant.ErrTenderExceedsDue,
+		constant.ErrStoreCreditUnsupported,
+		constant.ErrNoOpenShift,
+		constant.ErrExceedsOrdered,
+		constant.ErrUnsupportedImage,
+		constant.ErrImageTooLarge,
+		constant.ErrTooManyImages,
 		constant.ErrInsufficientStock:
 		return ctx.Status(fiber.StatusBadRequest).
 			JSON(fiber.Map{
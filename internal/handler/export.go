@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+// streamExport writes header followed by every row produced by export as a
+// CSV or XLSX attachment. The body is streamed after the handler returns,
+// so errors past this point can only be logged.
+func streamExport(
+	c *fiber.Ctx,
+	name string,
+	header []string,
+	export func(
+		ctx context.Context,
+		writeRow func(values []any) error,
+	) error,
+) error {
+	format := model.ExportFormat(
+		c.Query("format", string(model.CSV)),
+	)
+	if !format.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "format must be csv or xlsx",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid export format: %s",
+					format,
+				),
+			},
+		)
+	}
+
+	c.Attachment(
+		fmt.Sprintf("%s.%s", name, format),
+	)
+	c.Set(
+		fiber.HeaderContentType,
+		format.ContentType(),
+	)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		var (
+			writer util.SpreadsheetWriter
+			err    error
+		)
+		if format == model.XLSX {
+			writer, err = util.NewXLSXWriter(w, name)
+			if err != nil {
+				log.Printf("failed to start %s export: %v", name, err)
+				return
+			}
+		} else {
+			writer = util.NewCSVWriter(w)
+		}
+
+		headerRow := make([]any, 0, len(header))
+		for _, column := range header {
+			headerRow = append(headerRow, column)
+		}
+		if err := writer.WriteRow(headerRow); err != nil {
+			log.Printf("failed to write %s export: %v", name, err)
+			return
+		}
+
+		written := 0
+		err = export(
+			context.Background(),
+			func(values []any) error {
+				if err := writer.WriteRow(values); err != nil {
+					return err
+				}
+
+				written++
+				if written%500 == 0 {
+					return w.Flush()
+				}
+				return nil
+			},
+		)
+		if err != nil {
+			log.Printf("failed to export %s: %v", name, err)
+		}
+
+		if err := writer.Close(); err != nil {
+			log.Printf("failed to finish %s export: %v", name, err)
+		}
+	})
+
+	return nil
+}
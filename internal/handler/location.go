@@ -0,0 +1,309 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/service"
+)
+
+type LocationHandler struct {
+	LocationService *service.LocationService
+}
+
+func NewLocationHandler(
+	locationService *service.LocationService,
+) *LocationHandler {
+	return &LocationHandler{
+		LocationService: locationService,
+	}
+}
+
+func (handler *LocationHandler) Create(
+	c *fiber.Ctx,
+) error {
+	var body model.LocationRequestBody
+	err := c.BodyParser(&body)
+	if err != nil || !body.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid location body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	location, err := handler.LocationService.Create(
+		c.Context(),
+		body.ToLocation(),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to create location: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    location.ToResponseBody(),
+	})
+}
+
+func (handler *LocationHandler) Search(
+	c *fiber.Ctx,
+) error {
+	var queries model.SearchLocationQuery
+	err := c.QueryParser(&queries)
+	if err != nil || !queries.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid query",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid location query: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	locations, err := handler.LocationService.Search(
+		c.Context(),
+		queries,
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "unable to search locations",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to search locations: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	data := make(
+		[]model.LocationResponseBody,
+		0,
+		len(locations),
+	)
+	for _, location := range locations {
+		data = append(data, location.ToResponseBody())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    data,
+	})
+}
+
+func (handler *LocationHandler) GetByID(
+	c *fiber.Ctx,
+) error {
+	location, err := handler.LocationService.FindByID(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "location not found",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to get location: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    location.ToResponseBody(),
+	})
+}
+
+func (handler *LocationHandler) Update(
+	c *fiber.Ctx,
+) error {
+	var body model.LocationRequestBody
+	err := c.BodyParser(&body)
+	if err != nil || !body.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid location body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	location, err := handler.LocationService.Update(
+		c.Context(),
+		c.Params("id"),
+		body.ToLocation(),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to update location %s: %v",
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    location.ToResponseBody(),
+	})
+}
+
+// Delete refuses the default location and one still holding stock or with
+// transfers in transit.
+func (handler *LocationHandler) Delete(
+	c *fiber.Ctx,
+) error {
+	err := handler.LocationService.Delete(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to delete location %s: %v",
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+	})
+}
+
+// GetStocks lists the stock held at the location in the path.
+func (handler *LocationHandler) GetStocks(
+	c *fiber.Ctx,
+) error {
+	var queries model.SearchLocationStockQuery
+	err := c.QueryParser(&queries)
+	if err != nil || !queries.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid query",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid location stock query: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	stocks, err := handler.LocationService.FindStocks(
+		c.Context(),
+		c.Params("id"),
+		queries,
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to get stock of location %s: %v",
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    toLocationStockBodies(stocks),
+	})
+}
+
+// GetProductStocks is the stock of the product in the path at every
+// location.
+func (handler *LocationHandler) GetProductStocks(
+	c *fiber.Ctx,
+) error {
+	stocks, err := handler.LocationService.FindProductStocks(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to get stock of product %s: %v",
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    toLocationStockBodies(stocks),
+	})
+}
+
+func toLocationStockBodies(
+	stocks []model.LocationStock,
+) []model.LocationStockResponseBody {
+	data := make(
+		[]model.LocationStockResponseBody,
+		0,
+		len(stocks),
+	)
+	for _, stock := range stocks {
+		data = append(data, stock.ToResponseBody())
+	}
+
+	return data
+}
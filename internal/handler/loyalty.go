@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/service"
+)
+
+type LoyaltyHandler struct {
+	LoyaltyService *service.LoyaltyService
+}
+
+func NewLoyaltyHandler(
+	loyaltyService *service.LoyaltyService,
+) *LoyaltyHandler {
+	return &LoyaltyHandler{
+		LoyaltyService: loyaltyService,
+	}
+}
+
+func (handler *LoyaltyHandler) GetPoints(
+	c *fiber.Ctx,
+) error {
+	var queries model.SearchLoyaltyTransactionQuery
+	err := c.QueryParser(&queries)
+	if err != nil || !queries.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid query",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid loyalty points query: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	points, err := handler.LoyaltyService.GetPoints(
+		c.Context(),
+		c.Params("id"),
+		queries,
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "error get loyalty points",
+				error:   err,
+				detail: fmt.Sprintf(
+					"error get loyalty points: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    points,
+	})
+}
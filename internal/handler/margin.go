@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/service"
+)
+
+type MarginHandler struct {
+	MarginService *service.MarginService
+}
+
+func NewMarginHandler(
+	marginService *service.MarginService,
+) *MarginHandler {
+	return &MarginHandler{
+		MarginService: marginService,
+	}
+}
+
+// GetByProduct is the gross margin of every product sold in a date range.
+func (handler *MarginHandler) GetByProduct(
+	c *fiber.Ctx,
+) error {
+	var queries model.ProductMarginQuery
+	err := c.QueryParser(&queries)
+	if err != nil || !queries.IsValid() {
+		return invalidAnalyticsQuery(c, err)
+	}
+
+	margins, err := handler.MarginService.FindByProduct(
+		c.Context(),
+		queries,
+	)
+	if err != nil {
+		return analyticsError(c, "product margins", err)
+	}
+
+	data := make(
+		[]model.ProductMarginResponseBody,
+		0,
+		len(margins),
+	)
+	for _, margin := range margins {
+		data = append(data, margin.ToResponseBody())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    data,
+	})
+}
+
+// GetByCategory is the gross margin of every category in a date range.
+func (handler *MarginHandler) GetByCategory(
+	c *fiber.Ctx,
+) error {
+	var queries model.DateRangeQuery
+	err := c.QueryParser(&queries)
+	if err != nil || !queries.IsValid() {
+		return invalidAnalyticsQuery(c, err)
+	}
+
+	margins, err := handler.MarginService.FindByCategory(
+		c.Context(),
+		queries,
+	)
+	if err != nil {
+		return analyticsError(c, "category margins", err)
+	}
+
+	data := make(
+		[]model.CategoryMarginResponseBody,
+		0,
+		len(margins),
+	)
+	for _, margin := range margins {
+		data = append(data, margin.ToResponseBody())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    data,
+	})
+}
+
+// GetTrend is the gross margin per day, week or month of a date range.
+func (handler *MarginHandler) GetTrend(
+	c *fiber.Ctx,
+) error {
+	var queries model.MarginTrendQuery
+	err := c.QueryParser(&queries)
+	if err != nil || !queries.IsValid() {
+		return invalidAnalyticsQuery(c, err)
+	}
+
+	margins, err := handler.MarginService.FindTrend(
+		c.Context(),
+		queries,
+	)
+	if err != nil {
+		return analyticsError(c, "margin trend", err)
+	}
+
+	data := make(
+		[]model.PeriodMarginResponseBody,
+		0,
+		len(margins),
+	)
+	for _, margin := range margins {
+		data = append(data, margin.ToResponseBody())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    data,
+	})
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/gofiber/fiber/v2"
@@ -71,11 +72,26 @@ func (handlers *OrderHandler) Create(
 			)
 		}
 
+		productModel := model.ProductOrder{
+			ProductID: productId,
+			Quantity:  product.Quantity,
+		}
+		if product.PackID != "" {
+			productModel.PackID = uuid.MustParse(product.PackID)
+		}
 		productModels = append(
 			productModels,
-			model.ProductOrder{
-				ProductID: productId,
-				Quantity:  product.Quantity,
+			productModel,
+		)
+	}
+
+	if body.HasStoreCredit() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				error:   constant.ErrStoreCreditUnsupported,
+				message: constant.ErrStoreCreditUnsupported.Error(),
+				detail:  "store credit tender refused",
 			},
 		)
 	}
@@ -102,13 +118,34 @@ func (handlers *OrderHandler) Create(
 		)
 	}
 
+	var locationId uuid.UUID
+	if body.LocationID != "" {
+		locationId, err = uuid.Parse(body.LocationID)
+		if err != nil {
+			return HandleError(
+				c,
+				ErrorResponse{
+					message: "invalid location id",
+					error:   constant.ErrBadInput,
+					detail: fmt.Sprintf(
+						"invalid location id: %s",
+						body.LocationID,
+					),
+				},
+			)
+		}
+	}
+
 	res, err := handlers.OrderService.Create(
 		c.Context(),
 		model.Order{
-			CustomerID:    customerId,
-			PaymentAmount: body.Paid,
-			Change:        body.Change,
-			ProductOrders: productModels,
+			CustomerID:     customerId,
+			LocationID:     locationId,
+			Payments:       body.ToOrderPayments(),
+			Change:         body.Change,
+			PointsRedeemed: body.RedeemPoints,
+			CouponCode:     body.CouponCode,
+			ProductOrders:  productModels,
 		},
 	)
 	if err != nil {
@@ -131,6 +168,36 @@ func (handlers *OrderHandler) Create(
 	})
 }
 
+// Void cancels the order in the path, putting its stock back and reversing
+// its loyalty points.
+func (h *OrderHandler) Void(
+	ctx *fiber.Ctx,
+) error {
+	res, err := h.OrderService.Void(
+		ctx.Context(),
+		ctx.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error:   err,
+				message: err.Error(),
+				detail: fmt.Sprintf(
+					"unable to void order %s: %v",
+					ctx.Params("id"),
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "success",
+		"data":    res.ToResponseBody(),
+	})
+}
+
 func (h *OrderHandler) Search(
 	ctx *fiber.Ctx,
 ) error {
@@ -146,7 +213,64 @@ func (h *OrderHandler) Search(
 		)
 	}
 
-	orders, err := h.OrderService.Search(
+	return h.search(ctx, queries)
+}
+
+// SearchByCustomer is the order history of the customer in the path, with
+// the same query parameters as Search.
+func (h *OrderHandler) SearchByCustomer(
+	ctx *fiber.Ctx,
+) error {
+	var queries model.SearchOrderQuery
+	err := ctx.QueryParser(&queries)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error:   err,
+				message: err.Error(),
+			},
+		)
+	}
+
+	customerID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error:   constant.ErrNotFound,
+				message: "customer not found",
+				detail: fmt.Sprintf(
+					"invalid customer id: %s",
+					ctx.Params("id"),
+				),
+			},
+		)
+	}
+
+	queries.CustomerID = customerID
+	return h.search(ctx, queries)
+}
+
+func (h *OrderHandler) search(
+	ctx *fiber.Ctx,
+	queries model.SearchOrderQuery,
+) error {
+	if !queries.IsValid() {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error:   constant.ErrBadInput,
+				message: "invalid cursor",
+				detail: fmt.Sprintf(
+					"invalid cursor: %s",
+					queries.Cursor,
+				),
+			},
+		)
+	}
+
+	orders, nextCursor, err := h.OrderService.Search(
 		ctx.Context(),
 		queries,
 	)
@@ -164,8 +288,71 @@ func (h *OrderHandler) Search(
 		)
 	}
 
-	return ctx.JSON(fiber.Map{
+	res := withNextCursor(fiber.Map{
 		"message": "success",
 		"data":    orders,
-	})
+	}, nextCursor)
+
+	if wantsPaginationMeta(ctx) {
+		total, isEstimate, err := h.OrderService.Count(
+			ctx.Context(),
+			queries,
+		)
+		if err != nil {
+			return HandleError(
+				ctx,
+				ErrorResponse{
+					error:   err,
+					message: err.Error(),
+					detail: fmt.Sprintf(
+						"failed to count orders, %v",
+						err,
+					),
+				},
+			)
+		}
+
+		res["meta"] = queries.BuildPaginationMeta(
+			total,
+			isEstimate,
+			len(orders),
+			nextCursor,
+		)
+	}
+
+	return ctx.JSON(res)
+}
+
+func (h *OrderHandler) Export(
+	ctx *fiber.Ctx,
+) error {
+	var queries model.SearchOrderQuery
+	err := ctx.QueryParser(&queries)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error:   err,
+				message: err.Error(),
+			},
+		)
+	}
+
+	return streamExport(
+		ctx,
+		"orders",
+		model.OrderExportHeader,
+		func(
+			c context.Context,
+			writeRow func(values []any) error,
+		) error {
+			return h.OrderService.Export(
+				c,
+				queries,
+				func(row model.OrderExportRow) error {
+					return writeRow(row.ToExportRow())
+				},
+			)
+		},
+	)
 }
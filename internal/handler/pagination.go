@@ -0,0 +1,22 @@
+package handler
+
+import "github.com/gofiber/fiber/v2"
+
+// withNextCursor adds the cursor of the following page to a list response,
+// leaving the response untouched for clients that page by offset.
+func withNextCursor(
+	res fiber.Map,
+	nextCursor string,
+) fiber.Map {
+	if nextCursor != "" {
+		res["nextCursor"] = nextCursor
+	}
+
+	return res
+}
+
+// wantsPaginationMeta reports whether the client asked for the meta block
+// with meta=true. It is opt in because the total costs an extra query.
+func wantsPaginationMeta(c *fiber.Ctx) bool {
+	return c.QueryBool("meta", false)
+}
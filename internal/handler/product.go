@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"fmt"
 	"log"
 
@@ -26,6 +27,14 @@ func (h *ProductHandler) Search(ctx *fiber.Ctx) error {
 		return err
 	}
 
+	if !query.IsValid() {
+		return HandleError(ctx, ErrorResponse{
+			message: "invalid cursor",
+			error:   constant.ErrBadInput,
+			detail:  fmt.Sprintf("invalid cursor: %s", query.Cursor),
+		})
+	}
+
 	products, err := h.productService.Search(ctx.Context(), query)
 	if err != nil {
 		log.Println(err)
@@ -39,10 +48,28 @@ func (h *ProductHandler) Search(ctx *fiber.Ctx) error {
 		response = append(response, r)
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+	nextCursor := query.NextCursor(products)
+	res := withNextCursor(fiber.Map{
 		"message": "success",
 		"data":    response,
-	})
+	}, nextCursor)
+
+	if wantsPaginationMeta(ctx) {
+		total, isEstimate, err := h.productService.Count(ctx.Context(), query)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+
+		res["meta"] = query.BuildPaginationMeta(
+			total,
+			isEstimate,
+			len(products),
+			nextCursor,
+		)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(res)
 }
 
 func (h *ProductHandler) SearchForCustomer(ctx *fiber.Ctx) error {
@@ -55,6 +82,12 @@ func (h *ProductHandler) SearchForCustomer(ctx *fiber.Ctx) error {
 		})
 	}
 
+	if !query.IsValid() {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid cursor",
+		})
+	}
+
 	// should only show product that have isAvailable == true
 	query.IsAvailable = "true"
 	products, err := h.productService.Search(ctx.Context(), query)
@@ -72,10 +105,12 @@ func (h *ProductHandler) SearchForCustomer(ctx *fiber.Ctx) error {
 		response = append(response, r)
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "success",
-		"data":    response,
-	})
+	return ctx.Status(fiber.StatusOK).JSON(
+		withNextCursor(fiber.Map{
+			"message": "success",
+			"data":    response,
+		}, query.NextCursor(products)),
+	)
 }
 
 func (h *ProductHandler) Create(ctx *fiber.Ctx) error {
@@ -114,7 +149,7 @@ func (h *ProductHandler) Create(ctx *fiber.Ctx) error {
 }
 
 func (h *ProductHandler) Update(ctx *fiber.Ctx) error {
-	var product model.Product
+	var product model.UpdateProductBody
 	id := ctx.Params("id")
 	err := ctx.BodyParser(&product)
 	if err != nil {
@@ -159,3 +194,25 @@ func (h *ProductHandler) Delete(ctx *fiber.Ctx) error {
 		"message": "Product updated successfully",
 	})
 }
+
+func (h *ProductHandler) Export(ctx *fiber.Ctx) error {
+	var query model.SearchProductQuery
+	err := ctx.QueryParser(&query)
+	if err != nil {
+		log.Println(err)
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": fmt.Sprintf("unable to parse query: %v", err.Error()),
+		})
+	}
+
+	return streamExport(
+		ctx,
+		"products",
+		model.ProductExportHeader,
+		func(c context.Context, writeRow func(values []any) error) error {
+			return h.productService.Export(c, query, func(product model.Product) error {
+				return writeRow(product.ToExportRow())
+			})
+		},
+	)
+}
@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/service"
+)
+
+type ProductImageHandler struct {
+	ProductImageService *service.ProductImageService
+}
+
+func NewProductImageHandler(
+	productImageService *service.ProductImageService,
+) *ProductImageHandler {
+	return &ProductImageHandler{
+		ProductImageService: productImageService,
+	}
+}
+
+// Upload takes the image from the "image" field of a multipart form.
+func (handler *ProductImageHandler) Upload(
+	c *fiber.Ctx,
+) error {
+	file, err := c.FormFile("image")
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid product image upload: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	if file.Size > handler.ProductImageService.Image.MaxSize {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: constant.ErrImageTooLarge.Error(),
+				error:   constant.ErrImageTooLarge,
+				detail: fmt.Sprintf(
+					"product image of %d bytes",
+					file.Size,
+				),
+			},
+		)
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to open product image upload: %v",
+					err,
+				),
+			},
+		)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to read product image upload: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	image, err := handler.ProductImageService.Upload(
+		c.Context(),
+		c.Params("id"),
+		file.Header.Get("Content-Type"),
+		data,
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to upload image of product %s: %v",
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    image.ToResponseBody(),
+	})
+}
+
+// GetByProduct lists the images of the product in the path in order.
+func (handler *ProductImageHandler) GetByProduct(
+	c *fiber.Ctx,
+) error {
+	images, err := handler.ProductImageService.FindByProduct(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to get images of product %s: %v",
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    toProductImageResponseBodies(images),
+	})
+}
+
+func (handler *ProductImageHandler) Reorder(
+	c *fiber.Ctx,
+) error {
+	var body model.ReorderProductImagesRequestBody
+	err := c.BodyParser(&body)
+	if err != nil || !body.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid product image order body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	images, err := handler.ProductImageService.Reorder(
+		c.Context(),
+		c.Params("id"),
+		body.ToIDs(),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to reorder images of product %s: %v",
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    toProductImageResponseBodies(images),
+	})
+}
+
+func (handler *ProductImageHandler) Delete(
+	c *fiber.Ctx,
+) error {
+	err := handler.ProductImageService.Delete(
+		c.Context(),
+		c.Params("id"),
+		c.Params("imageId"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to delete image %s: %v",
+					c.Params("imageId"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+	})
+}
+
+func toProductImageResponseBodies(
+	images []model.ProductImage,
+) []model.ProductImageResponseBody {
+	data := make([]model.ProductImageResponseBody, 0, len(images))
+	for _, image := range images {
+		data = append(data, image.ToResponseBody())
+	}
+
+	return data
+}
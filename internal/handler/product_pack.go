@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/service"
+)
+
+type ProductPackHandler struct {
+	ProductPackService *service.ProductPackService
+}
+
+func NewProductPackHandler(
+	productPackService *service.ProductPackService,
+) *ProductPackHandler {
+	return &ProductPackHandler{
+		ProductPackService: productPackService,
+	}
+}
+
+func (handler *ProductPackHandler) Create(
+	c *fiber.Ctx,
+) error {
+	var body model.ProductPackRequestBody
+	err := c.BodyParser(&body)
+	if err != nil || !body.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid product pack body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	pack, err := handler.ProductPackService.Create(
+		c.Context(),
+		c.Params("id"),
+		body.ToProductPack(),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to create pack of product %s: %v",
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    pack.ToResponseBody(),
+	})
+}
+
+// GetByProduct lists the packs of the product in the path.
+func (handler *ProductPackHandler) GetByProduct(
+	c *fiber.Ctx,
+) error {
+	packs, err := handler.ProductPackService.FindByProduct(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to get packs of product %s: %v",
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	data := make([]model.ProductPackResponseBody, 0, len(packs))
+	for _, pack := range packs {
+		data = append(data, pack.ToResponseBody())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    data,
+	})
+}
+
+func (handler *ProductPackHandler) Delete(
+	c *fiber.Ctx,
+) error {
+	err := handler.ProductPackService.Delete(
+		c.Context(),
+		c.Params("id"),
+		c.Params("packId"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to delete pack %s: %v",
+					c.Params("packId"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+	})
+}
@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/service"
+)
+
+type PromotionHandler struct {
+	PromotionService *service.PromotionService
+}
+
+func NewPromotionHandler(
+	promotionService *service.PromotionService,
+) *PromotionHandler {
+	return &PromotionHandler{
+		PromotionService: promotionService,
+	}
+}
+
+func (handler *PromotionHandler) Create(
+	c *fiber.Ctx,
+) error {
+	var body model.PromotionRequestBody
+	err := c.BodyParser(&body)
+	if err != nil || !body.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid promotion body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	promotion, err := handler.PromotionService.Create(
+		c.Context(),
+		body.ToPromotion(),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "unable to create promotion",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to create promotion: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    promotion.ToResponseBody(),
+	})
+}
+
+func (handler *PromotionHandler) Search(
+	c *fiber.Ctx,
+) error {
+	var queries model.SearchPromotionQuery
+	err := c.QueryParser(&queries)
+	if err != nil || !queries.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid query",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid promotion query: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	promotions, err := handler.PromotionService.Search(
+		c.Context(),
+		queries,
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "unable to search promotions",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to search promotions: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	data := make(
+		[]model.PromotionResponseBody,
+		0,
+		len(promotions),
+	)
+	for _, promotion := range promotions {
+		data = append(data, promotion.ToResponseBody())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    data,
+	})
+}
+
+func (handler *PromotionHandler) GetByID(
+	c *fiber.Ctx,
+) error {
+	promotion, err := handler.PromotionService.FindByID(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "promotion not found",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to get promotion: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    promotion.ToResponseBody(),
+	})
+}
+
+func (handler *PromotionHandler) Update(
+	c *fiber.Ctx,
+) error {
+	var body model.PromotionRequestBody
+	err := c.BodyParser(&body)
+	if err != nil || !body.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid promotion body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	promotion, err := handler.PromotionService.Update(
+		c.Context(),
+		c.Params("id"),
+		body.ToPromotion(),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "unable to update promotion",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to update promotion: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    promotion.ToResponseBody(),
+	})
+}
+
+func (handler *PromotionHandler) Delete(
+	c *fiber.Ctx,
+) error {
+	err := handler.PromotionService.Delete(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "unable to delete promotion",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to delete promotion: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+	})
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/service"
+)
+
+type PurchaseOrderHandler struct {
+	PurchaseOrderService *service.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(
+	purchaseOrderService *service.PurchaseOrderService,
+) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{
+		PurchaseOrderService: purchaseOrderService,
+	}
+}
+
+func (handler *PurchaseOrderHandler) Create(
+	c *fiber.Ctx,
+) error {
+	var body model.PurchaseOrderRequestBody
+	err := c.BodyParser(&body)
+	if err != nil || !body.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid purchase order body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	po, err := handler.PurchaseOrderService.Create(
+		c.Context(),
+		body.ToPurchaseOrder(),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to create purchase order: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    po.ToResponseBody(),
+	})
+}
+
+func (handler *PurchaseOrderHandler) Search(
+	c *fiber.Ctx,
+) error {
+	var queries model.SearchPurchaseOrderQuery
+	err := c.QueryParser(&queries)
+	if err != nil || !queries.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid query",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid purchase order query: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	pos, err := handler.PurchaseOrderService.Search(
+		c.Context(),
+		queries,
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "unable to search purchase orders",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to search purchase orders: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	data := make(
+		[]model.PurchaseOrderResponseBody,
+		0,
+		len(pos),
+	)
+	for _, po := range pos {
+		data = append(data, po.ToResponseBody())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    data,
+	})
+}
+
+func (handler *PurchaseOrderHandler) GetByID(
+	c *fiber.Ctx,
+) error {
+	po, err := handler.PurchaseOrderService.FindByID(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "purchase order not found",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to get purchase order: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    po.ToResponseBody(),
+	})
+}
+
+// Receive takes in a delivery against the purchase order in the path, a
+// body without lines receives everything still outstanding.
+func (handler *PurchaseOrderHandler) Receive(
+	c *fiber.Ctx,
+) error {
+	var body model.ReceivePurchaseOrderRequestBody
+	err := c.BodyParser(&body)
+	if err != nil || !body.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid receive body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	po, err := handler.PurchaseOrderService.Receive(
+		c.Context(),
+		c.Params("id"),
+		body.ToReceipt(),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to receive purchase order %s: %v",
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    po.ToResponseBody(),
+	})
+}
+
+func (handler *PurchaseOrderHandler) Cancel(
+	c *fiber.Ctx,
+) error {
+	po, err := handler.PurchaseOrderService.Cancel(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to cancel purchase order %s: %v",
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    po.ToResponseBody(),
+	})
+}
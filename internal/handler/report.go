@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/service"
+)
+
+type ReportHandler struct {
+	ReportService *service.ReportService
+}
+
+func NewReportHandler(
+	reportService *service.ReportService,
+) *ReportHandler {
+	return &ReportHandler{
+		ReportService: reportService,
+	}
+}
+
+// GetDaily is the sales summary of the day in the date query, today when
+// it is empty.
+func (handler *ReportHandler) GetDaily(
+	c *fiber.Ctx,
+) error {
+	var queries model.DailyReportQuery
+	err := c.QueryParser(&queries)
+	if err != nil || !queries.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid date",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid daily report query: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	report, err := handler.ReportService.GetDaily(
+		c.Context(),
+		queries.Day(),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "unable to get daily report",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to get daily report: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    report.ToResponseBody(),
+	})
+}
+
+// CloseDay freezes the report of the day in the path, the Z-report.
+func (handler *ReportHandler) CloseDay(
+	c *fiber.Ctx,
+) error {
+	report, err := handler.ReportService.CloseDay(
+		c.Context(),
+		c.Params("date"),
+	)
+	if err != nil {
+		message := err.Error()
+		if err == constant.ErrBadInput {
+			message = "invalid date or day not over yet"
+		}
+
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: message,
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to close day %s: %v",
+					c.Params("date"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    report.ToResponseBody(),
+	})
+}
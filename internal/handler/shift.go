@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/service"
+)
+
+type ShiftHandler struct {
+	ShiftService *service.ShiftService
+}
+
+func NewShiftHandler(
+	shiftService *service.ShiftService,
+) *ShiftHandler {
+	return &ShiftHandler{
+		ShiftService: shiftService,
+	}
+}
+
+// Open starts a shift for the signed in cashier with its opening float.
+func (handler *ShiftHandler) Open(
+	c *fiber.Ctx,
+) error {
+	var body model.OpenShiftRequestBody
+	err := c.BodyParser(&body)
+	if err != nil || !body.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid shift body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	shift, err := handler.ShiftService.Open(
+		c.Context(),
+		body.ToShift(),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to open shift: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    shift.ToResponseBody(),
+	})
+}
+
+func (handler *ShiftHandler) Search(
+	c *fiber.Ctx,
+) error {
+	var queries model.SearchShiftQuery
+	err := c.QueryParser(&queries)
+	if err != nil || !queries.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid query",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid shift query: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	shifts, err := handler.ShiftService.Search(
+		c.Context(),
+		queries,
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "unable to search shifts",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to search shifts: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	data := make(
+		[]model.ShiftResponseBody,
+		0,
+		len(shifts),
+	)
+	for _, shift := range shifts {
+		data = append(data, shift.ToResponseBody())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    data,
+	})
+}
+
+// GetCurrent is the open shift of the signed in cashier.
+func (handler *ShiftHandler) GetCurrent(
+	c *fiber.Ctx,
+) error {
+	shift, err := handler.ShiftService.FindCurrent(c.Context())
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "no open shift",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to get current shift: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    shift.ToResponseBody(),
+	})
+}
+
+func (handler *ShiftHandler) GetByID(
+	c *fiber.Ctx,
+) error {
+	shift, err := handler.ShiftService.FindByID(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "shift not found",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to get shift: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    shift.ToResponseBody(),
+	})
+}
+
+// AddCashMovement records a pay-in or pay-out on the open shift in the
+// path.
+func (handler *ShiftHandler) AddCashMovement(
+	c *fiber.Ctx,
+) error {
+	var body model.CashMovementRequestBody
+	err := c.BodyParser(&body)
+	if err != nil || !body.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid cash movement body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	movement, err := handler.ShiftService.AddCashMovement(
+		c.Context(),
+		c.Params("id"),
+		body.ToCashMovement(),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to add cash movement to shift %s: %v",
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    movement.ToResponseBody(),
+	})
+}
+
+// Close closes the shift in the path with the cash counted in the drawer.
+func (handler *ShiftHandler) Close(
+	c *fiber.Ctx,
+) error {
+	var body model.CloseShiftRequestBody
+	err := c.BodyParser(&body)
+	if err != nil || !body.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid close shift body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	shift, err := handler.ShiftService.Close(
+		c.Context(),
+		c.Params("id"),
+		body.ToShiftClose(),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to close shift %s: %v",
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    shift.ToResponseBody(),
+	})
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/service"
+)
+
+type StockAlertHandler struct {
+	StockAlertService *service.StockAlertService
+}
+
+func NewStockAlertHandler(
+	stockAlertService *service.StockAlertService,
+) *StockAlertHandler {
+	return &StockAlertHandler{
+		StockAlertService: stockAlertService,
+	}
+}
+
+// GetLowStock lists the products at or below their reorder point, the ones
+// furthest below first.
+func (handler *StockAlertHandler) GetLowStock(
+	c *fiber.Ctx,
+) error {
+	var queries model.SearchLowStockQuery
+	err := c.QueryParser(&queries)
+	if err != nil || !queries.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid query",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid low stock query: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	products, err := handler.StockAlertService.FindLowStock(
+		c.Context(),
+		queries,
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "unable to get low stock products",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to get low stock products: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	data := make(
+		[]model.LowStockResponseBody,
+		0,
+		len(products),
+	)
+	for _, product := range products {
+		data = append(data, product.ToResponseBody())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    data,
+	})
+}
@@ -0,0 +1,315 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/service"
+)
+
+type StockCountHandler struct {
+	StockCountService *service.StockCountService
+}
+
+func NewStockCountHandler(
+	stockCountService *service.StockCountService,
+) *StockCountHandler {
+	return &StockCountHandler{
+		StockCountService: stockCountService,
+	}
+}
+
+// Open starts a count of a location, every product of it or a category.
+func (handler *StockCountHandler) Open(
+	c *fiber.Ctx,
+) error {
+	var body model.OpenStockCountRequestBody
+	err := c.BodyParser(&body)
+	if err != nil || !body.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid stock count body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	count, err := handler.StockCountService.Open(
+		c.Context(),
+		body.ToStockCount(),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to open stock count: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    count.ToResponseBody(),
+	})
+}
+
+func (handler *StockCountHandler) Search(
+	c *fiber.Ctx,
+) error {
+	var queries model.SearchStockCountQuery
+	err := c.QueryParser(&queries)
+	if err != nil || !queries.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid query",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid stock count query: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	counts, err := handler.StockCountService.Search(
+		c.Context(),
+		queries,
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "unable to search stock counts",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to search stock counts: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	data := make(
+		[]model.StockCountResponseBody,
+		0,
+		len(counts),
+	)
+	for _, count := range counts {
+		data = append(data, count.ToResponseBody())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    data,
+	})
+}
+
+func (handler *StockCountHandler) GetByID(
+	c *fiber.Ctx,
+) error {
+	count, err := handler.StockCountService.FindByID(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "stock count not found",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to get stock count: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    count.ToResponseBody(),
+	})
+}
+
+// Record sets the counted quantities of the lines in the body.
+func (handler *StockCountHandler) Record(
+	c *fiber.Ctx,
+) error {
+	var body model.RecordStockCountRequestBody
+	err := c.BodyParser(&body)
+	if err != nil || !body.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid stock count lines body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	count, err := handler.StockCountService.Record(
+		c.Context(),
+		c.Params("id"),
+		body.ToEntries(),
+		false,
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to record stock count %s: %v",
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    count.ToResponseBody(),
+	})
+}
+
+// Scan adds a scanned SKU to what was counted of its product.
+func (handler *StockCountHandler) Scan(
+	c *fiber.Ctx,
+) error {
+	var body model.ScanStockCountRequestBody
+	err := c.BodyParser(&body)
+	if err != nil || !body.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid stock count scan body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	count, err := handler.StockCountService.Record(
+		c.Context(),
+		c.Params("id"),
+		[]model.StockCountEntry{body.ToEntry()},
+		true,
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to scan %s into stock count %s: %v",
+					body.SKU,
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    count.ToResponseBody(),
+	})
+}
+
+// Post adjusts the stock of the location to the count in the path.
+func (handler *StockCountHandler) Post(
+	c *fiber.Ctx,
+) error {
+	var body model.PostStockCountRequestBody
+	err := c.BodyParser(&body)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid stock count post body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	count, err := handler.StockCountService.Post(
+		c.Context(),
+		c.Params("id"),
+		body.UncountedAsZero,
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to post stock count %s: %v",
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    count.ToResponseBody(),
+	})
+}
+
+// Cancel closes the count in the path without touching the stock.
+func (handler *StockCountHandler) Cancel(
+	c *fiber.Ctx,
+) error {
+	count, err := handler.StockCountService.Cancel(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to cancel stock count %s: %v",
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    count.ToResponseBody(),
+	})
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/service"
+)
+
+type StockMovementHandler struct {
+	StockMovementService *service.StockMovementService
+}
+
+func NewStockMovementHandler(
+	stockMovementService *service.StockMovementService,
+) *StockMovementHandler {
+	return &StockMovementHandler{
+		StockMovementService: stockMovementService,
+	}
+}
+
+// GetStockMovements is the stock history of the product in the path.
+func (handler *StockMovementHandler) GetByProduct(
+	c *fiber.Ctx,
+) error {
+	var queries model.SearchStockMovementQuery
+	err := c.QueryParser(&queries)
+	if err != nil || !queries.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid query",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid stock movement query: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	movements, err := handler.StockMovementService.FindByProduct(
+		c.Context(),
+		c.Params("id"),
+		queries,
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to get stock movements of product %s: %v",
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	data := make(
+		[]model.StockMovementResponseBody,
+		0,
+		len(movements),
+	)
+	for _, movement := range movements {
+		data = append(data, movement.ToResponseBody())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    data,
+	})
+}
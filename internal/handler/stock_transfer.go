@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/service"
+)
+
+type StockTransferHandler struct {
+	StockTransferService *service.StockTransferService
+}
+
+func NewStockTransferHandler(
+	stockTransferService *service.StockTransferService,
+) *StockTransferHandler {
+	return &StockTransferHandler{
+		StockTransferService: stockTransferService,
+	}
+}
+
+func (handler *StockTransferHandler) Create(
+	c *fiber.Ctx,
+) error {
+	var body model.StockTransferRequestBody
+	err := c.BodyParser(&body)
+	if err != nil || !body.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid stock transfer body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	transfer, err := handler.StockTransferService.Create(
+		c.Context(),
+		body.ToStockTransfer(),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to create stock transfer: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    transfer.ToResponseBody(),
+	})
+}
+
+func (handler *StockTransferHandler) Search(
+	c *fiber.Ctx,
+) error {
+	var queries model.SearchStockTransferQuery
+	err := c.QueryParser(&queries)
+	if err != nil || !queries.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid query",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid stock transfer query: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	transfers, err := handler.StockTransferService.Search(
+		c.Context(),
+		queries,
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "unable to search stock transfers",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to search stock transfers: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	data := make(
+		[]model.StockTransferResponseBody,
+		0,
+		len(transfers),
+	)
+	for _, transfer := range transfers {
+		data = append(data, transfer.ToResponseBody())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    data,
+	})
+}
+
+func (handler *StockTransferHandler) GetByID(
+	c *fiber.Ctx,
+) error {
+	transfer, err := handler.StockTransferService.FindByID(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "stock transfer not found",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to get stock transfer: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    transfer.ToResponseBody(),
+	})
+}
+
+// Receive takes the transfer in the path in at its destination.
+func (handler *StockTransferHandler) Receive(
+	c *fiber.Ctx,
+) error {
+	transfer, err := handler.StockTransferService.Receive(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to receive stock transfer %s: %v",
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    transfer.ToResponseBody(),
+	})
+}
+
+// Cancel puts the stock of the transfer in the path back at its source.
+func (handler *StockTransferHandler) Cancel(
+	c *fiber.Ctx,
+) error {
+	transfer, err := handler.StockTransferService.Cancel(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to cancel stock transfer %s: %v",
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    transfer.ToResponseBody(),
+	})
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/service"
+)
+
+type SupplierHandler struct {
+	SupplierService *service.SupplierService
+}
+
+func NewSupplierHandler(
+	supplierService *service.SupplierService,
+) *SupplierHandler {
+	return &SupplierHandler{
+		SupplierService: supplierService,
+	}
+}
+
+func (handler *SupplierHandler) Create(
+	c *fiber.Ctx,
+) error {
+	var body model.SupplierRequestBody
+	err := c.BodyParser(&body)
+	if err != nil || !body.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid supplier body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	supplier, err := handler.SupplierService.Create(
+		c.Context(),
+		body.ToSupplier(),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "unable to create supplier",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to create supplier: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    supplier.ToResponseBody(),
+	})
+}
+
+func (handler *SupplierHandler) Search(
+	c *fiber.Ctx,
+) error {
+	var queries model.SearchSupplierQuery
+	err := c.QueryParser(&queries)
+	if err != nil || !queries.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid query",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid supplier query: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	suppliers, err := handler.SupplierService.Search(
+		c.Context(),
+		queries,
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "unable to search suppliers",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to search suppliers: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	data := make(
+		[]model.SupplierResponseBody,
+		0,
+		len(suppliers),
+	)
+	for _, supplier := range suppliers {
+		data = append(data, supplier.ToResponseBody())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    data,
+	})
+}
+
+func (handler *SupplierHandler) GetByID(
+	c *fiber.Ctx,
+) error {
+	supplier, err := handler.SupplierService.FindByID(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "supplier not found",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to get supplier: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    supplier.ToResponseBody(),
+	})
+}
+
+func (handler *SupplierHandler) Update(
+	c *fiber.Ctx,
+) error {
+	var body model.SupplierRequestBody
+	err := c.BodyParser(&body)
+	if err != nil || !body.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid supplier body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	supplier, err := handler.SupplierService.Update(
+		c.Context(),
+		c.Params("id"),
+		body.ToSupplier(),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to update supplier %s: %v",
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    supplier.ToResponseBody(),
+	})
+}
+
+func (handler *SupplierHandler) Delete(
+	c *fiber.Ctx,
+) error {
+	err := handler.SupplierService.Delete(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: err.Error(),
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to delete supplier %s: %v",
+					c.Params("id"),
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+	})
+}
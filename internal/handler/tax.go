@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/service"
+)
+
+type TaxRateHandler struct {
+	TaxRateService *service.TaxRateService
+}
+
+func NewTaxRateHandler(
+	taxRateService *service.TaxRateService,
+) *TaxRateHandler {
+	return &TaxRateHandler{
+		TaxRateService: taxRateService,
+	}
+}
+
+func (handler *TaxRateHandler) Create(
+	c *fiber.Ctx,
+) error {
+	var body model.TaxRateRequestBody
+	err := c.BodyParser(&body)
+	if err != nil || !body.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid tax rate body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	taxRate, err := handler.TaxRateService.Create(
+		c.Context(),
+		body.ToTaxRate(),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "unable to create tax rate",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to create tax rate: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    taxRate.ToResponseBody(),
+	})
+}
+
+func (handler *TaxRateHandler) GetAll(
+	c *fiber.Ctx,
+) error {
+	taxRates, err := handler.TaxRateService.FindAll(c.Context())
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "unable to get tax rates",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to get tax rates: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	data := make(
+		[]model.TaxRateResponseBody,
+		0,
+		len(taxRates),
+	)
+	for _, taxRate := range taxRates {
+		data = append(data, taxRate.ToResponseBody())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    data,
+	})
+}
+
+func (handler *TaxRateHandler) Update(
+	c *fiber.Ctx,
+) error {
+	var body model.TaxRateRequestBody
+	err := c.BodyParser(&body)
+	if err != nil || !body.IsValid() {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "invalid body",
+				error:   constant.ErrBadInput,
+				detail: fmt.Sprintf(
+					"invalid tax rate body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	taxRate, err := handler.TaxRateService.Update(
+		c.Context(),
+		c.Params("id"),
+		body.ToTaxRate(),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "unable to update tax rate",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to update tax rate: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    taxRate.ToResponseBody(),
+	})
+}
+
+func (handler *TaxRateHandler) Delete(
+	c *fiber.Ctx,
+) error {
+	err := handler.TaxRateService.Delete(
+		c.Context(),
+		c.Params("id"),
+	)
+	if err != nil {
+		return HandleError(
+			c,
+			ErrorResponse{
+				message: "unable to delete tax rate",
+				error:   err,
+				detail: fmt.Sprintf(
+					"unable to delete tax rate: %v",
+					err.Error(),
+				),
+			},
+		)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+	})
+}
@@ -0,0 +1,241 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+// The analytics reports read the hourly sales rollups, they leave out
+// voided orders and lag behind checkout by up to the refresh interval.
+
+// defaultAnalyticsDays is the range of a report without dates.
+const defaultAnalyticsDays = 30
+
+// DateRangeQuery is a range of whole days in the store time zone, both
+// ends included. It is the last 30 days up to today when empty.
+type DateRangeQuery struct {
+	From string `query:"from"`
+	To   string `query:"to"`
+}
+
+func (drq DateRangeQuery) IsValid() bool {
+	for _, date := range []string{drq.From, drq.To} {
+		if date == "" {
+			continue
+		}
+		if _, err := util.ParseDay(date); err != nil {
+			return false
+		}
+	}
+
+	from, to := drq.Bounds()
+	return from.Before(to)
+}
+
+// Bounds returns the start of the first day and the end of the last day of
+// the range.
+func (drq DateRangeQuery) Bounds() (time.Time, time.Time) {
+	to := util.StartOfDay(util.Now())
+	if drq.To != "" {
+		to, _ = util.ParseDay(drq.To)
+	}
+	to = to.AddDate(0, 0, 1)
+
+	from := to.AddDate(0, 0, -defaultAnalyticsDays)
+	if drq.From != "" {
+		from, _ = util.ParseDay(drq.From)
+	}
+
+	return from, to
+}
+
+const (
+	RankByRevenue = "revenue"
+	RankByUnits   = "units"
+)
+
+type TopProductsQuery struct {
+	DateRangeQuery
+	By    string `query:"by"`
+	Limit int    `query:"limit"`
+}
+
+func (tpq TopProductsQuery) IsValid() bool {
+	switch tpq.By {
+	case "", RankByRevenue, RankByUnits:
+	default:
+		return false
+	}
+
+	return tpq.Limit >= 0 && tpq.DateRangeQuery.IsValid()
+}
+
+func (tpq TopProductsQuery) BuildOrderByClause() []string {
+	if tpq.By == RankByUnits {
+		return []string{"sum(s.units) desc", "p.id"}
+	}
+
+	return []string{"sum(s.revenue) desc", "p.id"}
+}
+
+type ProductSales struct {
+	Name       string          `json:"name"`
+	SKU        string          `json:"sku"`
+	Category   ProductCategory `json:"category"`
+	ProductID  uuid.UUID       `json:"productId"`
+	Units      float64         `json:"units"`
+	OrderCount int             `json:"orderCount"`
+	Revenue    float64         `json:"revenue"`
+}
+
+// SlowMoversQuery looks for products not sold in the last Days days.
+type SlowMoversQuery struct {
+	Days   int `query:"days"`
+	Limit  int `query:"limit"`
+	Offset int `query:"offset"`
+}
+
+func (smq SlowMoversQuery) IsValid() bool {
+	return smq.Days > 0 &&
+		smq.Limit >= 0 &&
+		smq.Offset >= 0
+}
+
+// Since is the start of the first of the days.
+func (smq SlowMoversQuery) Since() time.Time {
+	return util.StartOfDay(util.Now()).AddDate(0, 0, 1-smq.Days)
+}
+
+func (smq SlowMoversQuery) BuildWhereClauseAndParams() ([]string, []interface{}) {
+	return []string{
+		"coalesce(last.sold_hour < $%d, true)",
+	}, []interface{}{smq.Since()}
+}
+
+func (smq SlowMoversQuery) BuildPagination() (string, []interface{}) {
+	return util.DefaultPaginationBuilder(smq.Limit, smq.Offset)
+}
+
+// BuildOrderByClause puts the products never sold first, then the ones
+// sold longest ago.
+func (smq SlowMoversQuery) BuildOrderByClause() []string {
+	return []string{
+		"last.sold_hour asc nulls first",
+		"p.created_at",
+		"p.id",
+	}
+}
+
+type SlowMover struct {
+	// LastSoldAt is the hour of the last sale, zero when never sold
+	LastSoldAt time.Time
+	Name       string
+	SKU        string
+	Category   ProductCategory
+	ProductID  uuid.UUID
+	Stock      float64
+}
+
+func (sm SlowMover) ToResponseBody() SlowMoverResponseBody {
+	body := SlowMoverResponseBody{
+		ProductID: sm.ProductID.String(),
+		Name:      sm.Name,
+		SKU:       sm.SKU,
+		Category:  sm.Category,
+		Stock:     sm.Stock,
+	}
+	if !sm.LastSoldAt.IsZero() {
+		body.LastSoldAt = util.ToISO8601(sm.LastSoldAt)
+	}
+
+	return body
+}
+
+type SlowMoverResponseBody struct {
+	ProductID  string          `json:"productId"`
+	Name       string          `json:"name"`
+	SKU        string          `json:"sku"`
+	Category   ProductCategory `json:"category"`
+	LastSoldAt string          `json:"lastSoldAt,omitempty"`
+	Stock      float64         `json:"stock"`
+}
+
+// HeatmapCell is the sales of an hour of a weekday over a range, Weekday
+// runs from 1 for Monday to 7 for Sunday.
+type HeatmapCell struct {
+	Weekday    int     `json:"weekday"`
+	Hour       int     `json:"hour"`
+	OrderCount int     `json:"orderCount"`
+	Revenue    float64 `json:"revenue"`
+}
+
+// NewHeatmap is every hour of the week, Monday 00:00 first.
+func NewHeatmap() []HeatmapCell {
+	cells := make([]HeatmapCell, 0, 7*24)
+	for weekday := 1; weekday <= 7; weekday++ {
+		for hour := 0; hour < 24; hour++ {
+			cells = append(cells, HeatmapCell{
+				Weekday: weekday,
+				Hour:    hour,
+			})
+		}
+	}
+
+	return cells
+}
+
+const (
+	TrendByDay   = "day"
+	TrendByWeek  = "week"
+	TrendByMonth = "month"
+)
+
+type CategoryTrendQuery struct {
+	DateRangeQuery
+	Interval string `query:"interval"`
+}
+
+func (ctq CategoryTrendQuery) IsValid() bool {
+	switch ctq.Interval {
+	case "", TrendByDay, TrendByWeek, TrendByMonth:
+	default:
+		return false
+	}
+
+	return ctq.DateRangeQuery.IsValid()
+}
+
+func (ctq CategoryTrendQuery) TruncateTo() string {
+	if ctq.Interval == "" {
+		return TrendByDay
+	}
+
+	return ctq.Interval
+}
+
+// CategoryTrend is the sales of a category in the period starting at
+// Period.
+type CategoryTrend struct {
+	Period   time.Time
+	Category ProductCategory
+	Units    float64
+	Revenue  float64
+}
+
+func (ct CategoryTrend) ToResponseBody() CategoryTrendResponseBody {
+	return CategoryTrendResponseBody{
+		Period:   util.FormatDay(ct.Period),
+		Category: ct.Category,
+		Units:    ct.Units,
+		Revenue:  ct.Revenue,
+	}
+}
+
+type CategoryTrendResponseBody struct {
+	Period   string          `json:"period"`
+	Category ProductCategory `json:"category"`
+	Units    float64         `json:"units"`
+	Revenue  float64         `json:"revenue"`
+}
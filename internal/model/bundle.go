@@ -0,0 +1,137 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+// BundleComponent is a product a bundle is made of and how many of it one
+// bundle holds. The other fields are filled in at checkout.
+type BundleComponent struct {
+	ProductID uuid.UUID `json:"productId"`
+	Quantity  float64   `json:"quantity"`
+	Name      string    `json:"-"`
+	Price     float64   `json:"-"`
+	Cost      float64   `json:"-"`
+	IsDeleted bool      `json:"-"`
+}
+
+// ProductOrderComponent is a component a bundle line took out of stock. It
+// is credited with a share of the net price and tax of the line, see
+// AllocateBundle.
+type ProductOrderComponent struct {
+	ProductID uuid.UUID
+	Quantity  float64
+	NetPrice  float64
+	// CouponDiscount is the share of the coupon of the line, NetPrice is
+	// after it
+	CouponDiscount float64
+	TaxAmount      float64
+	// Cost is the unit cost of the component at checkout
+	Cost float64
+}
+
+func componentsAreValid(components []BundleComponent) bool {
+	if len(components) > 20 {
+		return false
+	}
+
+	productIDs := make(map[uuid.UUID]bool, len(components))
+	for _, component := range components {
+		if component.ProductID == uuid.Nil ||
+			productIDs[component.ProductID] {
+			return false
+		}
+		productIDs[component.ProductID] = true
+
+		if component.Quantity <= 0 || component.Quantity > 1000 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sameComponents(a, b []BundleComponent) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	quantities := make(map[uuid.UUID]float64, len(a))
+	for _, component := range a {
+		quantities[component.ProductID] = component.Quantity
+	}
+	for _, component := range b {
+		if quantities[component.ProductID] != component.Quantity {
+			return false
+		}
+	}
+
+	return true
+}
+
+// BundleCost is the cost of one bundle, the cost of its components.
+func BundleCost(components []BundleComponent) float64 {
+	var cost float64
+	for _, component := range components {
+		cost += component.Quantity * component.Cost
+	}
+
+	return util.RoundMoney(cost)
+}
+
+// AllocateBundle splits a bundle line, after its discounts and tax, over
+// the components it took out of stock. Each component is credited in
+// proportion to what its units would sell for on their own, the last one
+// takes what rounding leaves over so the shares add up to the line.
+func AllocateBundle(
+	line ProductOrder,
+	components []BundleComponent,
+) []ProductOrderComponent {
+	var weight float64
+	for _, component := range components {
+		weight += component.Quantity * component.Price
+	}
+
+	netPrice := util.RoundMoney(
+		line.TotalPrice - line.DiscountAmount - line.CouponDiscount,
+	)
+	netLeft, taxLeft := netPrice, line.TaxAmount
+	couponLeft := line.CouponDiscount
+
+	allocated := make([]ProductOrderComponent, 0, len(components))
+	for i, component := range components {
+		allocation := ProductOrderComponent{
+			ProductID: component.ProductID,
+			Quantity: util.RoundQuantity(
+				component.Quantity*line.Quantity,
+				util.QuantityDecimals,
+			),
+			Cost: component.Cost,
+		}
+
+		if i == len(components)-1 {
+			allocation.NetPrice = util.RoundMoney(netLeft)
+			allocation.TaxAmount = util.RoundMoney(taxLeft)
+			allocation.CouponDiscount = util.RoundMoney(couponLeft)
+		} else {
+			share := 1 / float64(len(components))
+			if weight > 0 {
+				share = component.Quantity * component.Price / weight
+			}
+
+			allocation.NetPrice = util.RoundMoney(netPrice * share)
+			allocation.TaxAmount = util.RoundMoney(line.TaxAmount * share)
+			allocation.CouponDiscount = util.RoundMoney(
+				line.CouponDiscount * share,
+			)
+			netLeft -= allocation.NetPrice
+			taxLeft -= allocation.TaxAmount
+			couponLeft -= allocation.CouponDiscount
+		}
+
+		allocated = append(allocated, allocation)
+	}
+
+	return allocated
+}
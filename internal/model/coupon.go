@@ -0,0 +1,334 @@
+package model
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type CouponType string
+
+const (
+	// CouponPercentage takes Value percent off the eligible lines
+	CouponPercentage CouponType = "percentage"
+	// CouponFixed takes Value off the eligible lines, never below zero
+	CouponFixed CouponType = "fixed"
+)
+
+func (ct CouponType) IsValid() bool {
+	switch ct {
+	case CouponPercentage, CouponFixed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Coupon is a discount on the whole basket, applied when the customer
+// hands over its code. Usage limits are unlimited when nil.
+type Coupon struct {
+	StartsAt           time.Time
+	EndsAt             time.Time
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	Code               string
+	Type               CouponType
+	Category           ProductCategory
+	ProductID          *uuid.UUID
+	MaxUses            *int
+	MaxUsesPerCustomer *int
+	Value              float64
+	MinBasketAmount    float64
+	UsedCount          int
+	IsActive           bool
+	ID                 uuid.UUID
+	CreatedBy          uuid.UUID
+}
+
+// NormalizeCouponCode makes codes case insensitive.
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// IsRunning compares wall clocks in the store time zone, the window is
+// stored as a timestamp without zone like the orders are.
+func (c Coupon) IsRunning(at time.Time) bool {
+	if !c.IsActive {
+		return false
+	}
+
+	at = util.WallClock(at.In(util.Location()))
+	if !c.StartsAt.IsZero() && at.Before(util.WallClock(c.StartsAt)) {
+		return false
+	}
+	if !c.EndsAt.IsZero() && !at.Before(util.WallClock(c.EndsAt)) {
+		return false
+	}
+
+	return true
+}
+
+func (c Coupon) AppliesTo(product Product) bool {
+	if c.ProductID != nil {
+		return *c.ProductID == product.ID
+	}
+	if c.Category != "" {
+		return c.Category == product.Category
+	}
+
+	return true
+}
+
+// Discount is the amount taken off the eligible part of a basket, that is
+// the lines in the scope of the coupon after their promotions.
+func (c Coupon) Discount(eligibleAmount float64) float64 {
+	var discount float64
+	switch c.Type {
+	case CouponPercentage:
+		discount = eligibleAmount * c.Value / 100
+	case CouponFixed:
+		discount = math.Min(c.Value, eligibleAmount)
+	}
+
+	return util.RoundMoney(discount)
+}
+
+func (c Coupon) ToResponseBody() CouponResponseBody {
+	body := CouponResponseBody{
+		ID:                 c.ID.String(),
+		Code:               c.Code,
+		Type:               string(c.Type),
+		Category:           c.Category,
+		Value:              c.Value,
+		MinBasketAmount:    c.MinBasketAmount,
+		MaxUses:            c.MaxUses,
+		MaxUsesPerCustomer: c.MaxUsesPerCustomer,
+		UsedCount:          c.UsedCount,
+		IsActive:           c.IsActive,
+		CreatedAt:          util.ToISO8601(c.CreatedAt),
+	}
+	if c.ProductID != nil {
+		body.ProductID = c.ProductID.String()
+	}
+	if !c.StartsAt.IsZero() {
+		body.StartsAt = util.ToISO8601(c.StartsAt)
+	}
+	if !c.EndsAt.IsZero() {
+		body.EndsAt = util.ToISO8601(c.EndsAt)
+	}
+
+	return body
+}
+
+type CouponResponseBody struct {
+	ID                 string          `json:"id"`
+	Code               string          `json:"code"`
+	Type               string          `json:"type"`
+	ProductID          string          `json:"productId,omitempty"`
+	Category           ProductCategory `json:"category,omitempty"`
+	StartsAt           string          `json:"startsAt,omitempty"`
+	EndsAt             string          `json:"endsAt,omitempty"`
+	CreatedAt          string          `json:"createdAt"`
+	MaxUses            *int            `json:"maxUses"`
+	MaxUsesPerCustomer *int            `json:"maxUsesPerCustomer"`
+	Value              float64         `json:"value"`
+	MinBasketAmount    float64         `json:"minBasketAmount"`
+	UsedCount          int             `json:"usedCount"`
+	IsActive           bool            `json:"isActive"`
+}
+
+type CouponRequestBody struct {
+	Code               string          `json:"code"`
+	Type               string          `json:"type"`
+	ProductID          string          `json:"productId"`
+	Category           ProductCategory `json:"category"`
+	StartsAt           string          `json:"startsAt"`
+	EndsAt             string          `json:"endsAt"`
+	MaxUses            *int            `json:"maxUses"`
+	MaxUsesPerCustomer *int            `json:"maxUsesPerCustomer"`
+	IsActive           *bool           `json:"isActive"`
+	Value              float64         `json:"value"`
+	MinBasketAmount    float64         `json:"minBasketAmount"`
+}
+
+func (body CouponRequestBody) IsValid() bool {
+	if codeLen := len(NormalizeCouponCode(body.Code)); codeLen < 3 ||
+		codeLen > 50 {
+		return false
+	}
+
+	switch CouponType(body.Type) {
+	case CouponPercentage:
+		if body.Value <= 0 || body.Value > 100 {
+			return false
+		}
+	case CouponFixed:
+		if body.Value <= 0 {
+			return false
+		}
+	default:
+		return false
+	}
+
+	if body.MinBasketAmount < 0 {
+		return false
+	}
+
+	for _, limit := range []*int{
+		body.MaxUses,
+		body.MaxUsesPerCustomer,
+	} {
+		if limit != nil && *limit < 1 {
+			return false
+		}
+	}
+
+	if body.ProductID != "" {
+		if body.Category != "" {
+			return false
+		}
+		if _, err := uuid.Parse(body.ProductID); err != nil {
+			return false
+		}
+	}
+
+	if body.Category != "" &&
+		!body.Category.IsValid() {
+		return false
+	}
+
+	var startsAt, endsAt time.Time
+	for _, date := range []struct {
+		value string
+		t     *time.Time
+	}{
+		{body.StartsAt, &startsAt},
+		{body.EndsAt, &endsAt},
+	} {
+		if date.value == "" {
+			continue
+		}
+		t, err := util.ParseStoreTime(date.value)
+		if err != nil {
+			return false
+		}
+		*date.t = t
+	}
+
+	return startsAt.IsZero() ||
+		endsAt.IsZero() ||
+		startsAt.Before(endsAt)
+}
+
+// ToCoupon expects a body that passed IsValid.
+func (body CouponRequestBody) ToCoupon() Coupon {
+	coupon := Coupon{
+		Code:               NormalizeCouponCode(body.Code),
+		Type:               CouponType(body.Type),
+		Category:           body.Category,
+		MaxUses:            body.MaxUses,
+		MaxUsesPerCustomer: body.MaxUsesPerCustomer,
+		Value:              body.Value,
+		MinBasketAmount:    body.MinBasketAmount,
+		IsActive:           body.IsActive == nil || *body.IsActive,
+	}
+
+	if body.ProductID != "" {
+		productID := uuid.MustParse(body.ProductID)
+		coupon.ProductID = &productID
+	}
+	if body.StartsAt != "" {
+		coupon.StartsAt, _ = util.ParseStoreTime(body.StartsAt)
+	}
+	if body.EndsAt != "" {
+		coupon.EndsAt, _ = util.ParseStoreTime(body.EndsAt)
+	}
+
+	return coupon
+}
+
+// CouponRedemption is a use of a coupon by an order, saved together with
+// the order. Code and VoidedAt are only read back, for the data export.
+type CouponRedemption struct {
+	CreatedAt      time.Time
+	VoidedAt       time.Time
+	Code           string
+	ID             uuid.UUID
+	CouponID       uuid.UUID
+	OrderID        uuid.UUID
+	CustomerID     uuid.UUID
+	DiscountAmount float64
+}
+
+func (cr CouponRedemption) ToResponseBody() CouponRedemptionBody {
+	body := CouponRedemptionBody{
+		ID:             cr.ID.String(),
+		CouponID:       cr.CouponID.String(),
+		Code:           cr.Code,
+		OrderID:        cr.OrderID.String(),
+		DiscountAmount: cr.DiscountAmount,
+		CreatedAt:      util.ToISO8601(cr.CreatedAt),
+	}
+	if !cr.VoidedAt.IsZero() {
+		body.VoidedAt = util.ToISO8601(cr.VoidedAt)
+	}
+
+	return body
+}
+
+type CouponRedemptionBody struct {
+	ID             string  `json:"id"`
+	CouponID       string  `json:"couponId"`
+	Code           string  `json:"code"`
+	OrderID        string  `json:"orderId"`
+	DiscountAmount float64 `json:"discountAmount"`
+	CreatedAt      string  `json:"createdAt"`
+	VoidedAt       string  `json:"voidedAt,omitempty"`
+}
+
+type SearchCouponQuery struct {
+	Code     string `query:"code"`
+	IsActive string `query:"isActive"`
+	Limit    int    `query:"limit"`
+	Offset   int    `query:"offset"`
+}
+
+func (scq SearchCouponQuery) IsValid() bool {
+	return (scq.IsActive == "" ||
+		BooleanString(scq.IsActive).IsValid()) &&
+		scq.Limit >= 0 &&
+		scq.Offset >= 0
+}
+
+func (scq SearchCouponQuery) BuildWhereClauseAndParams() ([]string, []interface{}) {
+	var (
+		sqlClause []string
+		params    []interface{}
+	)
+
+	if code := NormalizeCouponCode(scq.Code); code != "" {
+		sqlClause = append(sqlClause, "upper(cp.code) like $%d || '%%'")
+		params = append(params, code)
+	}
+
+	if BooleanString(scq.IsActive).IsValid() {
+		sqlClause = append(sqlClause, "cp.is_active = $%d")
+		params = append(params, BooleanString(scq.IsActive).ToBool())
+	}
+
+	return sqlClause, params
+}
+
+func (scq SearchCouponQuery) BuildPagination() (string, []interface{}) {
+	return util.DefaultPaginationBuilder(scq.Limit, scq.Offset)
+}
+
+func (scq SearchCouponQuery) BuildOrderByClause() []string {
+	return []string{
+		"cp.created_at desc",
+		"cp.id desc",
+	}
+}
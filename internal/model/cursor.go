@@ -0,0 +1,91 @@
+package model
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+)
+
+const cursorTimeLayout = "2006-01-02 15:04:05.999999"
+
+// Cursor points at the last row of a page. Rows are ordered by
+// (created_at, id), so the next page starts right after this pair.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (c Cursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString(
+		[]byte(fmt.Sprintf(
+			"%s|%s",
+			c.CreatedAt.Format(cursorTimeLayout),
+			c.ID.String(),
+		)),
+	)
+}
+
+func ParseCursor(token string) (Cursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, constant.ErrBadInput
+	}
+
+	createdAt, id, ok := strings.Cut(string(decoded), "|")
+	if !ok {
+		return Cursor{}, constant.ErrBadInput
+	}
+
+	parsedTime, err := time.Parse(cursorTimeLayout, createdAt)
+	if err != nil {
+		return Cursor{}, constant.ErrBadInput
+	}
+
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return Cursor{}, constant.ErrBadInput
+	}
+
+	return Cursor{
+		CreatedAt: parsedTime,
+		ID:        parsedID,
+	}, nil
+}
+
+// BuildWhereClause returns a keyset clause taking a single placeholder, so it
+// fits the one param per clause convention of util.BuildQueryStringAndParams.
+// The pair is sent as text[] and cast back on the database side.
+func (c Cursor) BuildWhereClause(
+	createdAtColumn string,
+	idColumn string,
+	order OrderBy,
+) (string, interface{}) {
+	comparison := "<"
+	if order == Asc {
+		comparison = ">"
+	}
+
+	return fmt.Sprintf(
+		"(%s, %s) %s (($%%[1]d::text[])[1]::timestamp, ($%%[1]d::text[])[2]::uuid)",
+		createdAtColumn,
+		idColumn,
+		comparison,
+	), []string{
+		c.CreatedAt.Format(cursorTimeLayout),
+		c.ID.String(),
+	}
+}
+
+// cursorOrder is the direction of a created_at sort parameter, falling back
+// to newest first.
+func cursorOrder(createdAt string) OrderBy {
+	if order := OrderBy(createdAt); order.IsValid() {
+		return order
+	}
+
+	return Desc
+}
@@ -1,14 +1,70 @@
 package model
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/nozzlium/eniqilo_store/internal/util"
 )
 
 type Customer struct {
-	Name        string
-	PhoneNumber string
-	ID          uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	DeletedAt        time.Time
+	ErasedAt         time.Time
+	Name             string
+	PhoneNumber      string
+	PhoneCountryCode string
+	PhoneRegion      string
+	ID               uuid.UUID
+}
+
+// ErasedCustomerName replaces the name of a customer whose personal data
+// was erased, their orders stay for accounting.
+const ErasedCustomerName = "Erased Customer"
+
+func (c Customer) ToProfile() CustomerProfile {
+	profile := CustomerProfile{
+		ID:               c.ID.String(),
+		Name:             c.Name,
+		PhoneNumber:      c.PhoneNumber,
+		PhoneCountryCode: c.PhoneCountryCode,
+		PhoneRegion:      c.PhoneRegion,
+		CreatedAt:        util.ToISO8601(c.CreatedAt),
+		UpdatedAt:        util.ToISO8601(c.UpdatedAt),
+	}
+	if !c.DeletedAt.IsZero() {
+		profile.DeletedAt = util.ToISO8601(c.DeletedAt)
+	}
+	if !c.ErasedAt.IsZero() {
+		profile.ErasedAt = util.ToISO8601(c.ErasedAt)
+	}
+
+	return profile
+}
+
+type CustomerProfile struct {
+	ID               string `json:"id"`
+	Name             string `json:"name"`
+	PhoneNumber      string `json:"phoneNumber"`
+	PhoneCountryCode string `json:"phoneCountryCode,omitempty"`
+	PhoneRegion      string `json:"phoneRegion,omitempty"`
+	CreatedAt        string `json:"createdAt"`
+	UpdatedAt        string `json:"updatedAt"`
+	DeletedAt        string `json:"deletedAt,omitempty"`
+	ErasedAt         string `json:"erasedAt,omitempty"`
+}
+
+// CustomerDataExport is everything stored about a single customer: the
+// profile, orders, loyalty ledger, coupon uses and the merges the customer
+// took part in on either side.
+type CustomerDataExport struct {
+	Profile           CustomerProfile             `json:"profile"`
+	Orders            []OrderResponseBody         `json:"orders"`
+	LoyaltyLedger     []LoyaltyTransactionBody    `json:"loyaltyLedger"`
+	CouponRedemptions []CouponRedemptionBody      `json:"couponRedemptions"`
+	Merges            []CustomerMergeResponseBody `json:"merges"`
 }
 
 type CustomerData struct {
@@ -17,6 +73,230 @@ type CustomerData struct {
 	Name        string `json:"name"`
 }
 
+type CustomerSortBy string
+
+const (
+	CustomerSortByName      CustomerSortBy = "name"
+	CustomerSortByCreatedAt CustomerSortBy = "createdAt"
+)
+
+func (sb CustomerSortBy) IsValid() bool {
+	switch sb {
+	case CustomerSortByName, CustomerSortByCreatedAt:
+		return true
+	default:
+		return false
+	}
+}
+
+type SearchCustomerQuery struct {
+	PhoneNumber    string  `query:"phoneNumber"`
+	Name           string  `query:"name"`
+	SortBy         string  `query:"sortBy"`
+	Order          string  `query:"order"`
+	RegisteredFrom string  `query:"registeredFrom"`
+	RegisteredTo   string  `query:"registeredTo"`
+	PurchasedSince string  `query:"purchasedSince"`
+	Cursor         string  `query:"cursor"`
+	MinSpend       float64 `query:"minSpend"`
+	// the list is always paged, by util.DefaultPageLimit when no limit is
+	// asked for
+	Limit  int `query:"limit"`
+	Offset int `query:"offset"`
+}
+
+func (scq SearchCustomerQuery) IsValid() bool {
+	if scq.Cursor != "" {
+		if _, err := ParseCursor(scq.Cursor); err != nil {
+			return false
+		}
+	}
+
+	if scq.SortBy != "" &&
+		!CustomerSortBy(scq.SortBy).IsValid() {
+		return false
+	}
+
+	if scq.Order != "" &&
+		!OrderBy(scq.Order).IsValid() {
+		return false
+	}
+
+	for _, date := range []string{
+		scq.RegisteredFrom,
+		scq.RegisteredTo,
+		scq.PurchasedSince,
+	} {
+		if date == "" {
+			continue
+		}
+		if _, _, err := util.ParseDate(date); err != nil {
+			return false
+		}
+	}
+
+	return scq.MinSpend >= 0 &&
+		scq.Limit >= 0 &&
+		scq.Offset >= 0
+}
+
+// IsKeysetOrdered reports whether results are sorted by (created_at, id)
+// only, which is when a next page cursor can be handed out.
+func (scq SearchCustomerQuery) IsKeysetOrdered() bool {
+	return scq.Cursor != "" ||
+		CustomerSortBy(scq.SortBy) != CustomerSortByName
+}
+
+func (scq SearchCustomerQuery) NextCursor(customers []Customer) string {
+	if !scq.IsKeysetOrdered() ||
+		len(customers) == 0 ||
+		len(customers) < util.PageLimit(scq.Limit) {
+		return ""
+	}
+
+	last := customers[len(customers)-1]
+	return Cursor{
+		CreatedAt: last.CreatedAt,
+		ID:        last.ID,
+	}.Encode()
+}
+
+func (scq SearchCustomerQuery) BuildPaginationMeta(
+	total int64,
+	totalIsEstimate bool,
+	pageSize int,
+	nextCursor string,
+) PaginationMeta {
+	offset := scq.Offset
+	if scq.Cursor != "" {
+		offset = 0
+	}
+
+	return NewPaginationMeta(
+		total,
+		totalIsEstimate,
+		util.PageLimit(scq.Limit),
+		offset,
+		pageSize,
+		nextCursor,
+		scq.Cursor != "",
+	)
+}
+
+func (scq SearchCustomerQuery) BuildWhereClauseAndParams() ([]string, []interface{}) {
+	var (
+		sqlClause []string
+		params    []interface{}
+	)
+
+	// numbers are stored in E.164, so only the digits are compared
+	if digits := util.PhoneNumberDigits(scq.PhoneNumber); digits != "" {
+		params = append(params, "+"+digits)
+		sqlClause = append(
+			sqlClause,
+			"c.phone_number like $%d || '%%'",
+		)
+	}
+
+	if scq.Name != "" {
+		params = append(params, scq.Name)
+		sqlClause = append(
+			sqlClause,
+			"c.name ilike '%%' || $%d || '%%'",
+		)
+	}
+
+	if from, _, err := util.ParseDate(scq.RegisteredFrom); err == nil {
+		params = append(params, from)
+		sqlClause = append(
+			sqlClause,
+			"c.created_at >= $%d",
+		)
+	}
+
+	if to, isDateOnly, err := util.ParseDate(scq.RegisteredTo); err == nil {
+		// a plain date covers the whole day
+		if isDateOnly {
+			params = append(params, to.AddDate(0, 0, 1))
+			sqlClause = append(
+				sqlClause,
+				"c.created_at < $%d",
+			)
+		} else {
+			params = append(params, to)
+			sqlClause = append(
+				sqlClause,
+				"c.created_at <= $%d",
+			)
+		}
+	}
+
+	if since, _, err := util.ParseDate(scq.PurchasedSince); err == nil {
+		params = append(params, since)
+		sqlClause = append(
+			sqlClause,
+			`exists (
+        select 1 from orders o
+        where o.customer_id = c.id and o.voided_at is null
+          and o.created_at >= $%d
+      )`,
+		)
+	}
+
+	if scq.MinSpend > 0 {
+		params = append(params, scq.MinSpend)
+		sqlClause = append(
+			sqlClause,
+			`(
+        select coalesce(sum(o.total_price), 0) from orders o
+        where o.customer_id = c.id and o.voided_at is null
+      ) >= $%d`,
+		)
+	}
+
+	if cursor, err := ParseCursor(scq.Cursor); err == nil {
+		clause, param := cursor.BuildWhereClause(
+			"c.created_at",
+			"c.id",
+			cursorOrder(scq.Order),
+		)
+		params = append(params, param)
+		sqlClause = append(sqlClause, clause)
+	}
+
+	return sqlClause, params
+}
+
+func (scq SearchCustomerQuery) BuildPagination() (string, []interface{}) {
+	// the cursor already skips the previous pages
+	if scq.Cursor != "" {
+		return util.DefaultPaginationBuilder(scq.Limit, 0)
+	}
+
+	return util.DefaultPaginationBuilder(scq.Limit, scq.Offset)
+}
+
+func (scq SearchCustomerQuery) BuildOrderByClause() []string {
+	if scq.Cursor == "" &&
+		CustomerSortBy(scq.SortBy) == CustomerSortByName {
+		order := Asc
+		if OrderBy(scq.Order).IsValid() {
+			order = OrderBy(scq.Order)
+		}
+
+		return []string{
+			fmt.Sprintf("c.name %s", order),
+			fmt.Sprintf("c.id %s", order),
+		}
+	}
+
+	order := cursorOrder(scq.Order)
+	return []string{
+		fmt.Sprintf("c.created_at %s", order),
+		fmt.Sprintf("c.id %s", order),
+	}
+}
+
 type CustomerRegisterBody struct {
 	PhoneNumber string `json:"phoneNumber"`
 	Name        string `json:"name"`
@@ -28,11 +308,6 @@ func (body CustomerRegisterBody) IsValid() bool {
 		return false
 	}
 
-	if phoneLen := len(body.PhoneNumber); phoneLen < 10 ||
-		phoneLen > 16 {
-		return false
-	}
-
 	if !util.ValidatePhoneNumber(
 		body.PhoneNumber,
 	) {
@@ -41,3 +316,104 @@ func (body CustomerRegisterBody) IsValid() bool {
 
 	return true
 }
+
+type CustomerUpdateBody struct {
+	PhoneNumber string `json:"phoneNumber"`
+	Name        string `json:"name"`
+}
+
+func (body CustomerUpdateBody) IsValid() bool {
+	return CustomerRegisterBody(body).IsValid()
+}
+
+type CustomerStats struct {
+	FirstPurchaseAt time.Time
+	LastPurchaseAt  time.Time
+	LifetimeSpend   float64
+	AverageBasket   float64
+	OrderCount      int
+}
+
+func (cs CustomerStats) ToResponseBody() CustomerStatsBody {
+	body := CustomerStatsBody{
+		LifetimeSpend: cs.LifetimeSpend,
+		AverageBasket: cs.AverageBasket,
+		OrderCount:    cs.OrderCount,
+	}
+	if cs.OrderCount > 0 {
+		body.FirstPurchaseAt = util.ToISO8601(cs.FirstPurchaseAt)
+		body.LastPurchaseAt = util.ToISO8601(cs.LastPurchaseAt)
+	}
+
+	return body
+}
+
+type CustomerStatsBody struct {
+	FirstPurchaseAt string  `json:"firstPurchaseAt,omitempty"`
+	LastPurchaseAt  string  `json:"lastPurchaseAt,omitempty"`
+	LifetimeSpend   float64 `json:"lifetimeSpend"`
+	AverageBasket   float64 `json:"averageBasket"`
+	OrderCount      int     `json:"orderCount"`
+}
+
+type CustomerTopProduct struct {
+	ProductID  string  `json:"productId"`
+	Name       string  `json:"name"`
+	Quantity   float64 `json:"quantity"`
+	TotalSpend float64 `json:"totalSpend"`
+}
+
+type CustomerDetail struct {
+	Profile     CustomerProfile      `json:"profile"`
+	Stats       CustomerStatsBody    `json:"stats"`
+	TopProducts []CustomerTopProduct `json:"topProducts"`
+}
+
+type CustomerMerge struct {
+	CreatedAt            time.Time
+	DuplicateName        string
+	DuplicatePhoneNumber string
+	OrdersMoved          int
+	ID                   uuid.UUID
+	SurvivorID           uuid.UUID
+	DuplicateID          uuid.UUID
+	MergedBy             uuid.UUID
+}
+
+func (cm CustomerMerge) ToResponseBody() CustomerMergeResponseBody {
+	return CustomerMergeResponseBody{
+		MergeID:              cm.ID.String(),
+		SurvivorID:           cm.SurvivorID.String(),
+		DuplicateID:          cm.DuplicateID.String(),
+		DuplicateName:        cm.DuplicateName,
+		DuplicatePhoneNumber: cm.DuplicatePhoneNumber,
+		OrdersMoved:          cm.OrdersMoved,
+		CreatedAt:            util.ToISO8601(cm.CreatedAt),
+	}
+}
+
+type CustomerMergeBody struct {
+	DuplicateID string `json:"duplicateId"`
+}
+
+type CustomerMergeResponseBody struct {
+	MergeID     string `json:"mergeId"`
+	SurvivorID  string `json:"survivorId"`
+	DuplicateID string `json:"duplicateId"`
+	// DuplicateName and DuplicatePhoneNumber are what the duplicate was
+	// when it was merged
+	DuplicateName        string `json:"duplicateName,omitempty"`
+	DuplicatePhoneNumber string `json:"duplicatePhoneNumber,omitempty"`
+	CreatedAt            string `json:"createdAt"`
+	OrdersMoved          int    `json:"ordersMoved"`
+}
+
+// DuplicateCustomerCandidate is a pair of customers that are likely the
+// same person, either because their phone numbers share the same digits or
+// because their names are very similar.
+type DuplicateCustomerCandidate struct {
+	Customer       CustomerData `json:"customer"`
+	Duplicate      CustomerData `json:"duplicate"`
+	NameSimilarity float64      `json:"nameSimilarity"`
+	SamePhone      bool         `json:"samePhone"`
+}
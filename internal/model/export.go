@@ -0,0 +1,151 @@
+package model
+
+import "github.com/nozzlium/eniqilo_store/internal/util"
+
+type ExportFormat string
+
+const (
+	CSV  ExportFormat = "csv"
+	XLSX ExportFormat = "xlsx"
+)
+
+func (ef ExportFormat) IsValid() bool {
+	switch ef {
+	case CSV, XLSX:
+		return true
+	default:
+		return false
+	}
+}
+
+func (ef ExportFormat) ContentType() string {
+	switch ef {
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+var (
+	ProductExportHeader = []string{
+		"id",
+		"name",
+		"sku",
+		"category",
+		"price",
+		"stock",
+		"unit",
+		"location",
+		"isAvailable",
+		"notes",
+		"imageUrl",
+		"createdAt",
+	}
+
+	CustomerExportHeader = []string{
+		"id",
+		"name",
+		"phoneNumber",
+		"createdAt",
+	}
+
+	OrderExportHeader = []string{
+		"transactionId",
+		"customerId",
+		"customerName",
+		"productId",
+		"productName",
+		"sku",
+		"quantity",
+		"price",
+		"itemTotal",
+		"itemDiscount",
+		"itemNet",
+		"itemTax",
+		"orderGross",
+		"orderDiscount",
+		"orderSubtotal",
+		"orderTax",
+		"orderTotal",
+		"paid",
+		"change",
+		"createdAt",
+	}
+)
+
+// OrderExportRow is one order line joined with its order, used when exporting
+// the checkout history.
+type OrderExportRow struct {
+	CreatedAt     string
+	OrderID       string
+	CustomerID    string
+	CustomerName  string
+	ProductID     string
+	ProductName   string
+	SKU           string
+	Quantity      float64
+	Price         float64
+	ItemTotal     float64
+	ItemDiscount  float64
+	ItemNet       float64
+	ItemTax       float64
+	OrderGross    float64
+	OrderDiscount float64
+	OrderSubtotal float64
+	OrderTax      float64
+	OrderTotal    float64
+	PaymentAmount float64
+	Change        float64
+}
+
+func (row OrderExportRow) ToExportRow() []any {
+	return []any{
+		row.OrderID,
+		row.CustomerID,
+		row.CustomerName,
+		row.ProductID,
+		row.ProductName,
+		row.SKU,
+		row.Quantity,
+		row.Price,
+		row.ItemTotal,
+		row.ItemDiscount,
+		row.ItemNet,
+		row.ItemTax,
+		row.OrderGross,
+		row.OrderDiscount,
+		row.OrderSubtotal,
+		row.OrderTax,
+		row.OrderTotal,
+		row.PaymentAmount,
+		row.Change,
+		row.CreatedAt,
+	}
+}
+
+func (p Product) ToExportRow() []any {
+	return []any{
+		p.ID.String(),
+		p.Name,
+		p.SKU,
+		string(p.Category),
+		p.Price,
+		p.Stock,
+		string(p.Unit),
+		p.Location,
+		p.IsAvailable,
+		p.Notes,
+		p.ImageURL,
+		util.ToISO8601(p.CreatedAt),
+	}
+}
+
+func (c Customer) ToExportRow() []any {
+	return []any{
+		c.ID.String(),
+		c.Name,
+		c.PhoneNumber,
+		util.ToISO8601(c.CreatedAt),
+	}
+}
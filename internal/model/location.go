@@ -0,0 +1,175 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+// Location is a place stock is kept, a shelf, a storeroom or a branch.
+// Checkouts and deliveries that do not name a location use the default one.
+type Location struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Name      string
+	Address   string
+	ID        uuid.UUID
+	IsDefault bool
+}
+
+func (l Location) ToResponseBody() LocationResponseBody {
+	return LocationResponseBody{
+		ID:        l.ID.String(),
+		Name:      l.Name,
+		Address:   l.Address,
+		IsDefault: l.IsDefault,
+		CreatedAt: util.ToISO8601(l.CreatedAt),
+		UpdatedAt: util.ToISO8601(l.UpdatedAt),
+	}
+}
+
+type LocationResponseBody struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Address   string `json:"address,omitempty"`
+	IsDefault bool   `json:"isDefault"`
+	CreatedAt string `json:"createdAt"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+// LocationRequestBody creates or replaces a location. Making a location the
+// default takes it off the previous one, the default cannot be unset
+// without naming another.
+type LocationRequestBody struct {
+	Name      string `json:"name"`
+	Address   string `json:"address"`
+	IsDefault bool   `json:"isDefault"`
+}
+
+func (body LocationRequestBody) IsValid() bool {
+	if nameLen := len(body.Name); nameLen < 1 ||
+		nameLen > 100 {
+		return false
+	}
+
+	return len(body.Address) <= 200
+}
+
+func (body LocationRequestBody) ToLocation() Location {
+	return Location{
+		Name:      body.Name,
+		Address:   body.Address,
+		IsDefault: body.IsDefault,
+	}
+}
+
+type SearchLocationQuery struct {
+	Name   string `query:"name"`
+	Limit  int    `query:"limit"`
+	Offset int    `query:"offset"`
+}
+
+func (slq SearchLocationQuery) IsValid() bool {
+	return slq.Limit >= 0 && slq.Offset >= 0
+}
+
+func (slq SearchLocationQuery) BuildWhereClauseAndParams() ([]string, []interface{}) {
+	var (
+		sqlClause []string
+		params    []interface{}
+	)
+
+	if slq.Name != "" {
+		sqlClause = append(sqlClause, "l.name ilike $%d")
+		params = append(params, fmt.Sprintf("%%%s%%", slq.Name))
+	}
+
+	return sqlClause, params
+}
+
+func (slq SearchLocationQuery) BuildPagination() (string, []interface{}) {
+	return util.DefaultPaginationBuilder(slq.Limit, slq.Offset)
+}
+
+func (slq SearchLocationQuery) BuildOrderByClause() []string {
+	return []string{
+		"l.is_default desc",
+		"l.name",
+		"l.id",
+	}
+}
+
+// LocationStock is the stock of a product at a location.
+type LocationStock struct {
+	LocationName string
+	ProductName  string
+	SKU          string
+	LocationID   uuid.UUID
+	ProductID    uuid.UUID
+	Stock        float64
+}
+
+func (ls LocationStock) ToResponseBody() LocationStockResponseBody {
+	return LocationStockResponseBody{
+		LocationID:   ls.LocationID.String(),
+		LocationName: ls.LocationName,
+		ProductID:    ls.ProductID.String(),
+		ProductName:  ls.ProductName,
+		SKU:          ls.SKU,
+		Stock:        ls.Stock,
+	}
+}
+
+type LocationStockResponseBody struct {
+	LocationID   string  `json:"locationId"`
+	LocationName string  `json:"locationName"`
+	ProductID    string  `json:"productId"`
+	ProductName  string  `json:"productName"`
+	SKU          string  `json:"sku"`
+	Stock        float64 `json:"stock"`
+}
+
+// SearchLocationStockQuery lists the stock held at a location, products
+// without any are left out unless withEmpty is set.
+type SearchLocationStockQuery struct {
+	Name       string    `query:"name"`
+	WithEmpty  bool      `query:"withEmpty"`
+	Limit      int       `query:"limit"`
+	Offset     int       `query:"offset"`
+	LocationID uuid.UUID `query:"-"`
+}
+
+func (slsq SearchLocationStockQuery) IsValid() bool {
+	return slsq.Limit >= 0 && slsq.Offset >= 0
+}
+
+func (slsq SearchLocationStockQuery) BuildWhereClauseAndParams() ([]string, []interface{}) {
+	sqlClause := []string{
+		"ps.location_id = $%d",
+		"($%d or ps.stock > 0)",
+	}
+	params := []interface{}{
+		slsq.LocationID,
+		slsq.WithEmpty,
+	}
+
+	if slsq.Name != "" {
+		sqlClause = append(sqlClause, "p.name ilike $%d")
+		params = append(params, fmt.Sprintf("%%%s%%", slsq.Name))
+	}
+
+	return sqlClause, params
+}
+
+func (slsq SearchLocationStockQuery) BuildPagination() (string, []interface{}) {
+	return util.DefaultPaginationBuilder(slsq.Limit, slsq.Offset)
+}
+
+func (slsq SearchLocationStockQuery) BuildOrderByClause() []string {
+	return []string{
+		"p.name",
+		"p.id",
+	}
+}
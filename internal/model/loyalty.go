@@ -0,0 +1,81 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type LoyaltyTransactionType string
+
+const (
+	LoyaltyEarn     LoyaltyTransactionType = "earn"
+	LoyaltyRedeem   LoyaltyTransactionType = "redeem"
+	LoyaltyReversal LoyaltyTransactionType = "reversal"
+)
+
+// LoyaltyTransaction is one entry of a customer's points ledger. Points
+// are negative for redemptions, so the balance is the sum of the ledger.
+type LoyaltyTransaction struct {
+	CreatedAt  time.Time
+	Type       LoyaltyTransactionType
+	OrderID    *uuid.UUID
+	ID         uuid.UUID
+	CustomerID uuid.UUID
+	Points     int
+}
+
+func (lt LoyaltyTransaction) ToResponseBody() LoyaltyTransactionBody {
+	body := LoyaltyTransactionBody{
+		ID:        lt.ID.String(),
+		Type:      string(lt.Type),
+		Points:    lt.Points,
+		CreatedAt: util.ToISO8601(lt.CreatedAt),
+	}
+	if lt.OrderID != nil {
+		body.OrderID = lt.OrderID.String()
+	}
+
+	return body
+}
+
+type LoyaltyTransactionBody struct {
+	ID        string `json:"id"`
+	OrderID   string `json:"orderId,omitempty"`
+	Type      string `json:"type"`
+	CreatedAt string `json:"createdAt"`
+	Points    int    `json:"points"`
+}
+
+type LoyaltyPointsBody struct {
+	Transactions []LoyaltyTransactionBody `json:"transactions"`
+	Balance      int                      `json:"balance"`
+}
+
+type SearchLoyaltyTransactionQuery struct {
+	Limit      int       `query:"limit"`
+	Offset     int       `query:"offset"`
+	CustomerID uuid.UUID `query:"-"`
+}
+
+func (sltq SearchLoyaltyTransactionQuery) IsValid() bool {
+	return sltq.Limit >= 0 &&
+		sltq.Offset >= 0
+}
+
+func (sltq SearchLoyaltyTransactionQuery) BuildWhereClauseAndParams() ([]string, []interface{}) {
+	return []string{"lpt.customer_id = $%d"},
+		[]interface{}{sltq.CustomerID}
+}
+
+func (sltq SearchLoyaltyTransactionQuery) BuildPagination() (string, []interface{}) {
+	return util.DefaultPaginationBuilder(sltq.Limit, sltq.Offset)
+}
+
+func (sltq SearchLoyaltyTransactionQuery) BuildOrderByClause() []string {
+	return []string{
+		"lpt.created_at desc",
+		"lpt.id desc",
+	}
+}
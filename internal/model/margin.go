@@ -0,0 +1,199 @@
+package model
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+// The margin reports read the orders themselves, leaving out voided ones.
+// Revenue is the line prices after promotions, before order coupons and
+// without tax, cost the unit costs the lines were sold at.
+
+type Margin struct {
+	Units   float64
+	Revenue float64
+	Cost    float64
+}
+
+func (m Margin) GrossMargin() float64 {
+	return util.RoundMoney(m.Revenue - m.Cost)
+}
+
+// MarginPercent is the gross margin as a percentage of revenue, 0 without
+// revenue.
+func (m Margin) MarginPercent() float64 {
+	if m.Revenue == 0 {
+		return 0
+	}
+
+	return math.Round((m.Revenue-m.Cost)/m.Revenue*10000) / 100
+}
+
+func (m Margin) ToBody() MarginBody {
+	return MarginBody{
+		Units:         m.Units,
+		Revenue:       util.RoundMoney(m.Revenue),
+		Cost:          util.RoundMoney(m.Cost),
+		GrossMargin:   m.GrossMargin(),
+		MarginPercent: m.MarginPercent(),
+	}
+}
+
+type MarginBody struct {
+	Units         float64 `json:"units"`
+	Revenue       float64 `json:"revenue"`
+	Cost          float64 `json:"cost"`
+	GrossMargin   float64 `json:"grossMargin"`
+	MarginPercent float64 `json:"marginPercent"`
+}
+
+const (
+	MarginSortByMargin  = "margin"
+	MarginSortByRevenue = "revenue"
+	MarginSortByPercent = "percent"
+)
+
+type ProductMarginQuery struct {
+	DateRangeQuery
+	Category string `query:"category"`
+	SortBy   string `query:"sortBy"`
+	Order    string `query:"order"`
+	Limit    int    `query:"limit"`
+	Offset   int    `query:"offset"`
+}
+
+func (pmq ProductMarginQuery) IsValid() bool {
+	switch pmq.SortBy {
+	case "", MarginSortByMargin, MarginSortByRevenue, MarginSortByPercent:
+	default:
+		return false
+	}
+
+	if pmq.Order != "" && !OrderBy(pmq.Order).IsValid() {
+		return false
+	}
+
+	if pmq.Category != "" && !ProductCategory(pmq.Category).IsValid() {
+		return false
+	}
+
+	return pmq.Limit >= 0 &&
+		pmq.Offset >= 0 &&
+		pmq.DateRangeQuery.IsValid()
+}
+
+// BuildOrderByClause puts the products with the highest gross margin first
+// unless asked otherwise, asc finds the ones losing money.
+func (pmq ProductMarginQuery) BuildOrderByClause() []string {
+	order := Desc
+	if OrderBy(pmq.Order).IsValid() {
+		order = OrderBy(pmq.Order)
+	}
+
+	var column string
+	switch pmq.SortBy {
+	case MarginSortByRevenue:
+		column = "sum(l.revenue)"
+	case MarginSortByPercent:
+		column = "(sum(l.revenue) - sum(l.cost)) / nullif(sum(l.revenue), 0)"
+	default:
+		column = "sum(l.revenue) - sum(l.cost)"
+	}
+
+	return []string{
+		column + " " + string(order) + " nulls last",
+		"p.id",
+	}
+}
+
+type ProductMargin struct {
+	Name      string
+	SKU       string
+	Category  ProductCategory
+	ProductID uuid.UUID
+	Margin
+}
+
+func (pm ProductMargin) ToResponseBody() ProductMarginResponseBody {
+	return ProductMarginResponseBody{
+		ProductID:  pm.ProductID.String(),
+		Name:       pm.Name,
+		SKU:        pm.SKU,
+		Category:   pm.Category,
+		MarginBody: pm.Margin.ToBody(),
+	}
+}
+
+type ProductMarginResponseBody struct {
+	ProductID string          `json:"productId"`
+	Name      string          `json:"name"`
+	SKU       string          `json:"sku"`
+	Category  ProductCategory `json:"category"`
+	MarginBody
+}
+
+type CategoryMargin struct {
+	Category ProductCategory
+	Margin
+}
+
+func (cm CategoryMargin) ToResponseBody() CategoryMarginResponseBody {
+	return CategoryMarginResponseBody{
+		Category:   cm.Category,
+		MarginBody: cm.Margin.ToBody(),
+	}
+}
+
+type CategoryMarginResponseBody struct {
+	Category ProductCategory `json:"category"`
+	MarginBody
+}
+
+type MarginTrendQuery struct {
+	DateRangeQuery
+	Interval string `query:"interval"`
+	Category string `query:"category"`
+}
+
+func (mtq MarginTrendQuery) IsValid() bool {
+	switch mtq.Interval {
+	case "", TrendByDay, TrendByWeek, TrendByMonth:
+	default:
+		return false
+	}
+
+	if mtq.Category != "" && !ProductCategory(mtq.Category).IsValid() {
+		return false
+	}
+
+	return mtq.DateRangeQuery.IsValid()
+}
+
+func (mtq MarginTrendQuery) TruncateTo() string {
+	if mtq.Interval == "" {
+		return TrendByDay
+	}
+
+	return mtq.Interval
+}
+
+// PeriodMargin is the margin of the period starting at Period.
+type PeriodMargin struct {
+	Period time.Time
+	Margin
+}
+
+func (pm PeriodMargin) ToResponseBody() PeriodMarginResponseBody {
+	return PeriodMarginResponseBody{
+		Period:     util.FormatDay(pm.Period),
+		MarginBody: pm.Margin.ToBody(),
+	}
+}
+
+type PeriodMarginResponseBody struct {
+	Period string `json:"period"`
+	MarginBody
+}
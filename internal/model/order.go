@@ -9,36 +9,74 @@ import (
 )
 
 type Order struct {
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	DeletedAt     time.Time
-	ProductOrders []ProductOrder
-	ID            uuid.UUID
-	CustomerID    uuid.UUID
-	TotalPrice    float64
-	PaymentAmount float64
-	Change        float64
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	DeletedAt           time.Time
+	VoidedAt            time.Time
+	ProductOrders       []ProductOrder
+	LoyaltyTransactions []LoyaltyTransaction
+	Payments            []OrderPayment
+	CouponRedemption    *CouponRedemption
+	CouponCode          string
+	ID                  uuid.UUID
+	CustomerID          uuid.UUID
+	// CreatedBy is the cashier who rang the order up, nil for orders from
+	// before cashiers were recorded. ShiftID is nil when no shift was open.
+	CreatedBy *uuid.UUID
+	ShiftID   *uuid.UUID
+	// LocationID is where the stock of the order was taken from
+	LocationID uuid.UUID
+	// GrossPrice is the sum of the lines before discounts. DiscountAmount
+	// covers both the promotions of the lines and CouponDiscount.
+	GrossPrice     float64
+	DiscountAmount float64
+	CouponDiscount float64
+	// TotalPrice is what the order costs, Subtotal plus TaxAmount
+	Subtotal         float64
+	TaxAmount        float64
+	PricesIncludeTax bool
+	TotalPrice       float64
+	PaymentAmount    float64
+	Change           float64
+	// PointsAmount is the part of TotalPrice paid with PointsRedeemed
+	PointsAmount   float64
+	PointsRedeemed int
+	PointsEarned   int
 }
 
 func (order Order) ToResponseBody() OrderResponseBody {
 	productDetails := make(
-		[]ProductDetailBody,
+		[]OrderLineBody,
 		0,
 		len(order.ProductOrders),
 	)
 	for _, product := range order.ProductOrders {
+		line := OrderLineBody{
+			ProductID:  product.ProductID.String(),
+			Quantity:   product.Quantity,
+			Price:      product.Price,
+			GrossPrice: product.TotalPrice,
+			Discount:   product.DiscountAmount,
+			Coupon:     product.CouponDiscount,
+			NetPrice: util.RoundMoney(
+				product.TotalPrice -
+					product.DiscountAmount -
+					product.CouponDiscount,
+			),
+			TaxRate: product.TaxRate,
+			Tax:     product.TaxAmount,
+		}
+		if product.PromotionID != nil {
+			line.PromotionID = product.PromotionID.String()
+		}
+
 		productDetails = append(
 			productDetails,
-			ProductDetailBody{
-				ProductID: product.ProductID.String(),
-				Quantity: int(
-					product.Quantity,
-				),
-			},
+			line,
 		)
 	}
 
-	return OrderResponseBody{
+	body := OrderResponseBody{
 		TransactionId:  order.ID.String(),
 		CustomerID:     order.CustomerID.String(),
 		Paid:           order.PaymentAmount,
@@ -47,15 +85,62 @@ func (order Order) ToResponseBody() OrderResponseBody {
 		CreatedAt: util.ToISO8601(
 			order.CreatedAt,
 		),
+		GrossPrice:     order.GrossPrice,
+		Discount:       order.DiscountAmount,
+		CouponCode:     order.CouponCode,
+		CouponDiscount: order.CouponDiscount,
+		Subtotal:       order.Subtotal,
+		Tax:            order.TaxAmount,
+		GrandTotal:     order.TotalPrice,
+		TotalPrice:     order.TotalPrice,
+		TaxIncluded:    order.PricesIncludeTax,
+		PointsRedeemed: order.PointsRedeemed,
+		PointsAmount:   order.PointsAmount,
+		PointsEarned:   order.PointsEarned,
+	}
+	if !order.VoidedAt.IsZero() {
+		body.VoidedAt = util.ToISO8601(order.VoidedAt)
+	}
+	if order.CreatedBy != nil {
+		body.CashierID = order.CreatedBy.String()
+	}
+	if order.ShiftID != nil {
+		body.ShiftID = order.ShiftID.String()
 	}
+	if order.LocationID != uuid.Nil {
+		body.LocationID = order.LocationID.String()
+	}
+
+	body.Payments = make([]PaymentBody, 0, len(order.Payments))
+	for _, payment := range order.Payments {
+		body.Payments = append(body.Payments, payment.ToResponseBody())
+	}
+
+	return body
 }
 
 type ProductOrder struct {
-	OrderID    uuid.UUID
-	ProductID  uuid.UUID
-	Quantity   int
-	Price      float64
-	TotalPrice float64
+	PromotionID *uuid.UUID
+	OrderID     uuid.UUID
+	ProductID   uuid.UUID
+	// PackID is the pack the line was rung up in, uuid.Nil for units. The
+	// quantity is in packs until checkout turns it into units.
+	PackID   uuid.UUID
+	Quantity float64
+	Price    float64
+	// Cost is the unit cost of the product at checkout
+	Cost float64
+	// TotalPrice is quantity * price, before DiscountAmount is taken off
+	TotalPrice     float64
+	DiscountAmount float64
+	// CouponDiscount is the share of the order coupon taken off this line,
+	// its net price is after it
+	CouponDiscount float64
+	TaxRate        float64
+	TaxAmount      float64
+	// Components are what a bundle line took out of stock, nil for lines
+	// that are not bundles
+	Components []ProductOrderComponent
 }
 
 type OrderRequestBody struct {
@@ -63,52 +148,258 @@ type OrderRequestBody struct {
 	ProductDetails []ProductDetailBody `json:"productDetails"`
 	Paid           float64             `json:"paid"`
 	Change         float64             `json:"change"`
+	CouponCode     string              `json:"couponCode"`
+	// Tenders replaces Paid and RedeemPoints when the order is not paid in
+	// cash only
+	Tenders      []TenderBody `json:"tenders"`
+	RedeemPoints int          `json:"redeemPoints"`
+	// LocationID is the location the terminal is set up at, the stock is
+	// taken from the default location without it
+	LocationID string `json:"locationId"`
 }
 
 func (body OrderRequestBody) IsValid() bool {
-	return body.Paid > 0 &&
-		body.Change >= 0
+	if body.Change < 0 {
+		return false
+	}
+
+	if len(body.Tenders) > 0 {
+		if body.Paid != 0 || body.RedeemPoints != 0 {
+			return false
+		}
+
+		for _, tender := range body.Tenders {
+			if !tender.IsValid() {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	// an order paid in full with points has nothing paid in cash
+	return (body.Paid > 0 || body.RedeemPoints > 0) &&
+		body.Paid >= 0 &&
+		body.RedeemPoints >= 0
 }
 
+// HasStoreCredit reports whether one of the tenders is store credit, which
+// is refused with its own error rather than as an invalid body.
+func (body OrderRequestBody) HasStoreCredit() bool {
+	for _, tender := range body.Tenders {
+		if PaymentMethod(tender.Method) == PaymentStoreCredit {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ToOrderPayments turns Paid into a cash tender when no tenders were given.
+func (body OrderRequestBody) ToOrderPayments() []OrderPayment {
+	if len(body.Tenders) == 0 {
+		if body.Paid == 0 {
+			return nil
+		}
+
+		return []OrderPayment{{
+			Method:         PaymentCash,
+			TenderedAmount: body.Paid,
+		}}
+	}
+
+	payments := make([]OrderPayment, 0, len(body.Tenders))
+	for _, tender := range body.Tenders {
+		payments = append(payments, tender.ToOrderPayment())
+	}
+
+	return payments
+}
+
+// ProductDetailBody is a line of an order. The quantity is in the unit of
+// the product, or in packs of it when packId is set.
 type ProductDetailBody struct {
-	ProductID string `json:"productId"`
-	Quantity  int    `json:"quantity"`
+	ProductID string  `json:"productId"`
+	PackID    string  `json:"packId"`
+	Quantity  float64 `json:"quantity"`
 }
 
 func (body ProductDetailBody) IsValid() bool {
-	return body.Quantity > 0
+	if body.PackID != "" {
+		if _, err := uuid.Parse(body.PackID); err != nil {
+			return false
+		}
+	}
+
+	return body.Quantity > 0 && body.Quantity <= 100000
+}
+
+// OrderLineBody is a line of a receipt, the request fields of
+// ProductDetailBody plus what the line was charged.
+type OrderLineBody struct {
+	ProductID   string  `json:"productId"`
+	PromotionID string  `json:"promotionId,omitempty"`
+	Quantity    float64 `json:"quantity"`
+	Price       float64 `json:"price"`
+	GrossPrice  float64 `json:"grossPrice"`
+	Discount    float64 `json:"discount"`
+	Coupon      float64 `json:"couponDiscount,omitempty"`
+	NetPrice    float64 `json:"netPrice"`
+	TaxRate     float64 `json:"taxRate"`
+	Tax         float64 `json:"tax"`
 }
 
 type OrderResponseBody struct {
-	CreatedAt      string              `json:"createdAt"`
-	TransactionId  string              `json:"transactionId"`
-	CustomerID     string              `json:"customerId"`
-	ProductDetails []ProductDetailBody `json:"productDetails"`
-	Paid           float64             `json:"paid"`
-	Change         float64             `json:"change"`
+	CreatedAt      string          `json:"createdAt"`
+	VoidedAt       string          `json:"voidedAt,omitempty"`
+	TransactionId  string          `json:"transactionId"`
+	CustomerID     string          `json:"customerId"`
+	CashierID      string          `json:"cashierId,omitempty"`
+	ShiftID        string          `json:"shiftId,omitempty"`
+	LocationID     string          `json:"locationId,omitempty"`
+	CouponCode     string          `json:"couponCode,omitempty"`
+	ProductDetails []OrderLineBody `json:"productDetails"`
+	GrossPrice     float64         `json:"grossPrice"`
+	Discount       float64         `json:"discount"`
+	CouponDiscount float64         `json:"couponDiscount"`
+	Subtotal       float64         `json:"subtotal"`
+	Tax            float64         `json:"tax"`
+	GrandTotal     float64         `json:"grandTotal"`
+	TotalPrice     float64         `json:"totalPrice"`
+	TaxIncluded    bool            `json:"taxIncluded"`
+	Paid           float64         `json:"paid"`
+	Change         float64         `json:"change"`
+	Payments       []PaymentBody   `json:"payments"`
+	PointsAmount   float64         `json:"pointsAmount"`
+	PointsRedeemed int             `json:"pointsRedeemed"`
+	PointsEarned   int             `json:"pointsEarned"`
+}
+
+// OrderVoid is the outcome of voiding an order: its stock is back on the
+// shelf and the points it earned or used are reversed.
+type OrderVoid struct {
+	VoidedAt       time.Time
+	ID             uuid.UUID
+	CustomerID     uuid.UUID
+	PointsReversed int
+}
+
+func (ov OrderVoid) ToResponseBody() OrderVoidResponseBody {
+	return OrderVoidResponseBody{
+		TransactionId:  ov.ID.String(),
+		CustomerID:     ov.CustomerID.String(),
+		VoidedAt:       util.ToISO8601(ov.VoidedAt),
+		PointsReversed: ov.PointsReversed,
+	}
+}
+
+type OrderVoidResponseBody struct {
+	TransactionId  string `json:"transactionId"`
+	CustomerID     string `json:"customerId"`
+	VoidedAt       string `json:"voidedAt"`
+	PointsReversed int    `json:"pointsReversed"`
 }
 
 type SearchOrderQuery struct {
 	CreatedAt  string    `query:"createdAt"`
+	Cursor     string    `query:"cursor"`
 	Limit      int       `query:"limit"`
 	Offset     int       `query:"offset"`
 	CustomerID uuid.UUID `query:"customerId"`
+	ShiftID    uuid.UUID `query:"shiftId"`
+}
+
+func (soq SearchOrderQuery) IsValid() bool {
+	if soq.Cursor == "" {
+		return true
+	}
+
+	_, err := ParseCursor(soq.Cursor)
+	return err == nil
+}
+
+func (soq SearchOrderQuery) NextCursor(orders []Order) string {
+	if len(orders) == 0 ||
+		len(orders) < util.PageLimit(soq.Limit) {
+		return ""
+	}
+
+	last := orders[len(orders)-1]
+	return Cursor{
+		CreatedAt: last.CreatedAt,
+		ID:        last.ID,
+	}.Encode()
+}
+
+func (soq SearchOrderQuery) BuildPaginationMeta(
+	total int64,
+	totalIsEstimate bool,
+	pageSize int,
+	nextCursor string,
+) PaginationMeta {
+	offset := soq.Offset
+	if soq.Cursor != "" || offset < 0 {
+		offset = 0
+	}
+
+	return NewPaginationMeta(
+		total,
+		totalIsEstimate,
+		util.PageLimit(soq.Limit),
+		offset,
+		pageSize,
+		nextCursor,
+		soq.Cursor != "",
+	)
 }
 
 func (soq SearchOrderQuery) BuildWhereClauseAndParams() ([]string, []interface{}) {
-	if soq.CustomerID == uuid.Nil {
-		return []string{}, []interface{}{}
+	sqlClause := []string{}
+	params := []interface{}{}
+
+	if soq.CustomerID != uuid.Nil {
+		sqlClause = append(sqlClause, "o.customer_id = $%d")
+		params = append(params, soq.CustomerID)
+	}
+
+	if soq.ShiftID != uuid.Nil {
+		sqlClause = append(sqlClause, "o.shift_id = $%d")
+		params = append(params, soq.ShiftID)
 	}
-	return []string{"o.customer_id = $%d"}, []interface{}{soq.CustomerID}
+
+	if cursor, err := ParseCursor(soq.Cursor); err == nil {
+		clause, param := cursor.BuildWhereClause(
+			"o.created_at",
+			"o.id",
+			cursorOrder(soq.CreatedAt),
+		)
+		sqlClause = append(sqlClause, clause)
+		params = append(params, param)
+	}
+
+	return sqlClause, params
 }
 
 func (soq SearchOrderQuery) BuildPagination() (string, []interface{}) {
+	// the cursor already skips the previous pages
+	if soq.Cursor != "" {
+		return util.DefaultPaginationBuilder(soq.Limit, 0)
+	}
+
 	return util.DefaultPaginationBuilder(soq.Limit, soq.Offset)
 }
 
 func (soq SearchOrderQuery) BuildOrderByClause() []string {
 	var sqlClause []string
 
+	if soq.Cursor != "" {
+		order := cursorOrder(soq.CreatedAt)
+		return []string{
+			fmt.Sprintf("o.created_at %s", order),
+			fmt.Sprintf("o.id %s", order),
+		}
+	}
+
 	if soq.CreatedAt != "" ||
 		OrderBy(
 			soq.CreatedAt,
@@ -127,6 +418,12 @@ func (soq SearchOrderQuery) BuildOrderByClause() []string {
 		)
 	}
 
+	// tie breaker so that cursors taken from this ordering stay stable
+	sqlClause = append(
+		sqlClause,
+		fmt.Sprintf("o.id %s", cursorOrder(soq.CreatedAt)),
+	)
+
 	return sqlClause
 }
 
@@ -160,5 +457,10 @@ func (sodq SearchOrderDetailQuery) BuildOrderByClause() []string {
 		)
 	}
 
+	sqlClause = append(
+		sqlClause,
+		fmt.Sprintf("o.id %s", cursorOrder(sodq.CreatedAt)),
+	)
+
 	return sqlClause
 }
@@ -0,0 +1,41 @@
+package model
+
+// PaginationMeta describes where a list page sits in the whole result set.
+// Total is an estimate from the planner when TotalIsEstimate is set.
+type PaginationMeta struct {
+	NextCursor      string `json:"nextCursor,omitempty"`
+	Total           int64  `json:"total"`
+	Limit           int    `json:"limit"`
+	Offset          int    `json:"offset"`
+	TotalIsEstimate bool   `json:"totalIsEstimate"`
+	HasMore         bool   `json:"hasMore"`
+}
+
+func NewPaginationMeta(
+	total int64,
+	totalIsEstimate bool,
+	limit int,
+	offset int,
+	pageSize int,
+	nextCursor string,
+	usesCursor bool,
+) PaginationMeta {
+	meta := PaginationMeta{
+		NextCursor:      nextCursor,
+		Total:           total,
+		Limit:           limit,
+		Offset:          offset,
+		TotalIsEstimate: totalIsEstimate,
+	}
+
+	switch {
+	case usesCursor:
+		meta.HasMore = nextCursor != ""
+	case totalIsEstimate:
+		meta.HasMore = limit > 0 && pageSize == limit
+	default:
+		meta.HasMore = int64(offset+pageSize) < total
+	}
+
+	return meta
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PaymentMethod string
+
+const (
+	PaymentCash    PaymentMethod = "cash"
+	PaymentCard    PaymentMethod = "card"
+	PaymentEWallet PaymentMethod = "ewallet"
+	PaymentQRIS    PaymentMethod = "qris"
+	// PaymentStoreCredit is in the payment_method enum but not a valid
+	// tender, customers have no store credit balance to pay it from
+	PaymentStoreCredit PaymentMethod = "store_credit"
+	// PaymentLoyaltyPoints is paid with points of the customer, its amount
+	// is the value of the points
+	PaymentLoyaltyPoints PaymentMethod = "loyalty_points"
+)
+
+func (pm PaymentMethod) IsValid() bool {
+	switch pm {
+	case PaymentCash,
+		PaymentCard,
+		PaymentEWallet,
+		PaymentQRIS,
+		PaymentLoyaltyPoints:
+		return true
+	default:
+		return false
+	}
+}
+
+// OrderPayment is one tender of an order. Amount is what it paid towards
+// the order, TenderedAmount is what was handed over, they only differ for
+// cash that was given change.
+type OrderPayment struct {
+	CreatedAt      time.Time
+	Method         PaymentMethod
+	Reference      string
+	ID             uuid.UUID
+	OrderID        uuid.UUID
+	Amount         float64
+	TenderedAmount float64
+}
+
+func (op OrderPayment) ToResponseBody() PaymentBody {
+	return PaymentBody{
+		Method:    string(op.Method),
+		Amount:    op.Amount,
+		Tendered:  op.TenderedAmount,
+		Reference: op.Reference,
+	}
+}
+
+type PaymentBody struct {
+	Method    string  `json:"method"`
+	Reference string  `json:"reference,omitempty"`
+	Amount    float64 `json:"amount"`
+	Tendered  float64 `json:"tendered"`
+}
+
+type TenderBody struct {
+	Method    string  `json:"method"`
+	Reference string  `json:"reference"`
+	Amount    float64 `json:"amount"`
+}
+
+func (body TenderBody) IsValid() bool {
+	return PaymentMethod(body.Method).IsValid() &&
+		body.Amount > 0 &&
+		len(body.Reference) <= 100
+}
+
+func (body TenderBody) ToOrderPayment() OrderPayment {
+	return OrderPayment{
+		Method:         PaymentMethod(body.Method),
+		Reference:      body.Reference,
+		TenderedAmount: body.Amount,
+	}
+}
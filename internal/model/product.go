@@ -46,8 +46,14 @@ func (b BooleanString) ToBool() bool {
 	return b == True
 }
 
+// AvailableStockColumn is the stock a product can be sold from, for a
+// bundle how many of it the stock of its components makes up.
+const AvailableStockColumn = "(case when is_bundle then bundle_stock(id) else stock end)"
+
 type SearchProductQuery struct {
 	ID          string `query:"id"`
+	Search      string `query:"search"`
+	Relevance   string `query:"relevance"`
 	Name        string `query:"name"`
 	Category    string `query:"category"`
 	SKU         string `query:"sku"`
@@ -55,16 +61,95 @@ type SearchProductQuery struct {
 	CreatedAt   string `query:"createdAt"`
 	IsAvailable string `query:"isAvailable"`
 	InStock     string `query:"inStock"`
+	Cursor      string `query:"cursor"`
 	Limit       int    `query:"limit"`
 	Offset      int    `query:"offset"`
 }
 
+func (spq SearchProductQuery) IsValid() bool {
+	if spq.Cursor == "" {
+		return true
+	}
+
+	_, err := ParseCursor(spq.Cursor)
+	return err == nil
+}
+
+// IsKeysetOrdered reports whether results are sorted by (created_at, id)
+// only, which is when a next page cursor can be handed out.
+func (spq SearchProductQuery) IsKeysetOrdered() bool {
+	if spq.Cursor != "" {
+		return true
+	}
+
+	return spq.Price == "" &&
+		(spq.Search == "" || !OrderBy(spq.Relevance).IsValid())
+}
+
+func (spq SearchProductQuery) NextCursor(products []Product) string {
+	if !spq.IsKeysetOrdered() ||
+		len(products) == 0 ||
+		len(products) < util.PageLimit(spq.Limit) {
+		return ""
+	}
+
+	last := products[len(products)-1]
+	return Cursor{
+		CreatedAt: last.CreatedAt,
+		ID:        last.ID,
+	}.Encode()
+}
+
+func (spq SearchProductQuery) BuildPaginationMeta(
+	total int64,
+	totalIsEstimate bool,
+	pageSize int,
+	nextCursor string,
+) PaginationMeta {
+	offset := spq.Offset
+	if spq.Cursor != "" || offset < 0 {
+		offset = 0
+	}
+
+	return NewPaginationMeta(
+		total,
+		totalIsEstimate,
+		util.PageLimit(spq.Limit),
+		offset,
+		pageSize,
+		nextCursor,
+		spq.Cursor != "",
+	)
+}
+
 func (spq SearchProductQuery) BuildWhereClauseAndParams() ([]string, []interface{}) {
+	sqlClause, params, _ := spq.buildWhereClauseAndParams()
+	return sqlClause, params
+}
+
+// SearchParamIndex is the placeholder number the search term gets in
+// BuildWhereClauseAndParams, 0 when there is no search.
+func (spq SearchProductQuery) SearchParamIndex() int {
+	_, _, index := spq.buildWhereClauseAndParams()
+	return index
+}
+
+func (spq SearchProductQuery) buildWhereClauseAndParams() ([]string, []interface{}, int) {
 	var (
-		sqlClause []string
-		params    []interface{}
+		sqlClause   []string
+		params      []interface{}
+		searchIndex int
 	)
 
+	if spq.Search != "" {
+		params = append(params, spq.Search)
+		searchIndex = len(params)
+		sqlClause = append(
+			sqlClause,
+			"(search_vector @@ websearch_to_tsquery('simple', $%[1]d) or $%[1]d <%% name or $%[1]d <%% sku)",
+		)
+	}
+
 	if spq.ID != "" {
 		params = append(params, spq.ID)
 		sqlClause = append(
@@ -116,10 +201,10 @@ func (spq SearchProductQuery) BuildWhereClauseAndParams() ([]string, []interface
 		if inStock == True {
 			sqlClause = append(
 				sqlClause,
-				"stock > $%d",
+				AvailableStockColumn+" > $%d",
 			)
 		} else {
-			sqlClause = append(sqlClause, "stock = $%d")
+			sqlClause = append(sqlClause, AvailableStockColumn+" = $%d")
 		}
 	}
 
@@ -134,18 +219,26 @@ func (spq SearchProductQuery) BuildWhereClauseAndParams() ([]string, []interface
 		)
 	}
 
-	return sqlClause, params
+	if cursor, err := ParseCursor(spq.Cursor); err == nil {
+		clause, param := cursor.BuildWhereClause(
+			"created_at",
+			"id",
+			cursorOrder(spq.CreatedAt),
+		)
+		params = append(params, param)
+		sqlClause = append(sqlClause, clause)
+	}
+
+	return sqlClause, params, searchIndex
 }
 
 func (spq SearchProductQuery) BuildPagination() (string, []interface{}) {
 	var params []interface{}
 
-	limit := 5
+	limit := util.PageLimit(spq.Limit)
 	offset := 0
-	if spq.Limit > 0 {
-		limit = spq.Limit
-	}
-	if spq.Offset > 0 {
+	// the cursor already skips the previous pages
+	if spq.Offset > 0 && spq.Cursor == "" {
 		offset = spq.Offset
 	}
 	params = append(
@@ -157,9 +250,43 @@ func (spq SearchProductQuery) BuildPagination() (string, []interface{}) {
 	return "limit $%d offset $%d", params
 }
 
+// BuildScoreColumn returns the relevance of a row to the search term, a mix
+// of full-text rank and trigram similarity so typos still score. searchParam
+// is the placeholder number the search term is bound to.
+func (spq SearchProductQuery) BuildScoreColumn(searchParam int) string {
+	if spq.Search == "" {
+		return "0::real"
+	}
+
+	return fmt.Sprintf(
+		"(ts_rank(search_vector, websearch_to_tsquery('simple', $%[1]d)) + word_similarity($%[1]d, name))",
+		searchParam,
+	)
+}
+
 func (spq SearchProductQuery) BuildOrderByClause() []string {
 	var sqlClause []string
 
+	if spq.Cursor != "" {
+		order := cursorOrder(spq.CreatedAt)
+		return []string{
+			fmt.Sprintf("created_at %s", order),
+			fmt.Sprintf("id %s", order),
+		}
+	}
+
+	if spq.Search != "" &&
+		OrderBy(spq.Relevance).IsValid() {
+		sqlClause = append(
+			sqlClause,
+			fmt.Sprintf(
+				"%s %s",
+				spq.BuildScoreColumn(spq.SearchParamIndex()),
+				spq.Relevance,
+			),
+		)
+	}
+
 	if spq.Price != "" ||
 		OrderBy(spq.Price).IsValid() {
 		sqlClause = append(
@@ -183,6 +310,12 @@ func (spq SearchProductQuery) BuildOrderByClause() []string {
 		)
 	}
 
+	// tie breaker so that cursors taken from this ordering stay stable
+	sqlClause = append(
+		sqlClause,
+		fmt.Sprintf("id %s", cursorOrder(spq.CreatedAt)),
+	)
+
 	return sqlClause
 }
 
@@ -250,8 +383,11 @@ type SearchProductResponse struct {
 	Location    string          `json:"location"`
 	CreatedAt   string          `json:"createdAt"`
 	IsAvailable bool            `json:"isAvailable"`
-	Stock       int             `json:"stock"`
+	IsBundle    bool            `json:"isBundle"`
+	Unit        UnitOfMeasure   `json:"unit"`
+	Stock       float64         `json:"stock"`
 	Price       float64         `json:"price"`
+	Score       float64         `json:"score,omitempty"`
 }
 
 func (spr *SearchProductResponse) FromProduct(product Product) {
@@ -263,8 +399,11 @@ func (spr *SearchProductResponse) FromProduct(product Product) {
 	spr.ImageURL = product.ImageURL
 	spr.Location = product.Location
 	spr.IsAvailable = product.IsAvailable
+	spr.IsBundle = product.IsBundle
+	spr.Unit = product.Unit
 	spr.Stock = product.Stock
 	spr.Price = product.Price
+	spr.Score = product.Score
 	spr.ID = product.ID.String()
 }
 
@@ -279,12 +418,28 @@ type Product struct {
 	ImageURL    string          `json:"imageUrl"`
 	Location    string          `json:"location"`
 	IsAvailable bool            `json:"isAvailable"`
-	Stock       int             `json:"stock"`
-	Price       float64         `json:"price"`
-	CreatedBy   uuid.UUID       `json:"createdBy"`
-	UpdatedBy   uuid.UUID       `json:"updatedBy"`
-	DeletedBy   uuid.UUID       `json:"deletedBy"`
-	ID          uuid.UUID       `json:"id"`
+	// Unit is what Stock and the quantities of the product are counted in,
+	// a piece when left out on create and kept when left out on update
+	Unit  UnitOfMeasure `json:"unit"`
+	Stock float64       `json:"stock"`
+	// a checkout that leaves Stock at or below ReorderPoint raises a low
+	// stock alert suggesting to order ReorderQuantity more, 0 turns it off
+	ReorderPoint    float64 `json:"reorderPoint"`
+	ReorderQuantity float64 `json:"reorderQuantity"`
+	Price           float64 `json:"price"`
+	// Cost is what a unit costs the store, it is set by hand or by
+	// receiving purchase orders and copied onto the order lines at checkout
+	Cost  float64 `json:"cost"`
+	Score float64 `json:"-"`
+	// a product created with Components is a bundle. It holds no stock of
+	// its own, Stock is how many bundles the components make up and the
+	// stock and reorder point sent for it are ignored.
+	IsBundle   bool              `json:"isBundle"`
+	Components []BundleComponent `json:"components"`
+	CreatedBy  uuid.UUID         `json:"createdBy"`
+	UpdatedBy  uuid.UUID         `json:"updatedBy"`
+	DeletedBy  uuid.UUID         `json:"deletedBy"`
+	ID         uuid.UUID         `json:"id"`
 }
 
 func (p *Product) IsValid() bool {
@@ -298,9 +453,12 @@ func (p *Product) IsValid() bool {
 		return false
 	}
 
-	if _, err := url.ParseRequestURI(p.ImageURL); err != nil {
-		log.Println(err)
-		return false
+	// left out when the images are uploaded, the first one becomes it
+	if p.ImageURL != "" {
+		if _, err := url.ParseRequestURI(p.ImageURL); err != nil {
+			log.Println(err)
+			return false
+		}
 	}
 
 	if !p.Category.IsValid() {
@@ -316,17 +474,88 @@ func (p *Product) IsValid() bool {
 		return false
 	}
 
-	if stock := p.Stock; stock < 0 ||
-		stock > 100000 {
+	if p.Cost < 0 {
+		return false
+	}
+
+	if p.Unit != "" && !p.Unit.IsValid() {
 		return false
 	}
 
+	for _, quantity := range []float64{
+		p.Stock,
+		p.ReorderPoint,
+		p.ReorderQuantity,
+	} {
+		if quantity < 0 || quantity > 100000 {
+			return false
+		}
+	}
+
 	if len(p.Location) < 1 ||
 		len(p.Location) > 200 {
 		return false
 	}
 
-	return true
+	return componentsAreValid(p.Components)
+}
+
+// UpdateProductBody is the body of a product update. The fields declared
+// here shadow the ones of Product, they are pointers so that leaving them out
+// keeps what is stored instead of zeroing it.
+type UpdateProductBody struct {
+	Product
+	ReorderPoint    *float64 `json:"reorderPoint"`
+	ReorderQuantity *float64 `json:"reorderQuantity"`
+	// Cost is kept up to date by receiving purchase orders, most edits
+	// don't send it
+	Cost *float64 `json:"cost"`
+}
+
+func (b *UpdateProductBody) IsValid() bool {
+	if b.Cost != nil && *b.Cost < 0 {
+		return false
+	}
+
+	for _, quantity := range []*float64{
+		b.ReorderPoint,
+		b.ReorderQuantity,
+	} {
+		if quantity != nil && (*quantity < 0 || *quantity > 100000) {
+			return false
+		}
+	}
+
+	return b.Product.IsValid()
+}
+
+// ToProduct is the product the body turns existing into, with what was
+// left out taken from existing.
+func (b UpdateProductBody) ToProduct(existing Product) Product {
+	product := b.Product
+	product.ReorderPoint = existing.ReorderPoint
+	if b.ReorderPoint != nil {
+		product.ReorderPoint = *b.ReorderPoint
+	}
+	product.ReorderQuantity = existing.ReorderQuantity
+	if b.ReorderQuantity != nil {
+		product.ReorderQuantity = *b.ReorderQuantity
+	}
+	product.Cost = existing.Cost
+	if b.Cost != nil {
+		product.Cost = *b.Cost
+	}
+
+	return product
+}
+
+// HasValidQuantities reports whether the stock and reorder quantities fit
+// the unit of the product. It is checked once the unit is known, which on
+// update may be the one the product already had.
+func (p *Product) HasValidQuantities() bool {
+	return p.Unit.IsValidQuantity(p.Stock) &&
+		p.Unit.IsValidQuantity(p.ReorderPoint) &&
+		p.Unit.IsValidQuantity(p.ReorderQuantity)
 }
 
 func (p *Product) CompareAndUpdate(product Product) error {
@@ -347,7 +576,7 @@ func (p *Product) CompareAndUpdate(product Product) error {
 		hasUpdatedData = true
 		p.Notes = product.Notes
 	}
-	if product.ImageURL != p.ImageURL {
+	if product.ImageURL != "" && product.ImageURL != p.ImageURL {
 		hasUpdatedData = true
 		p.ImageURL = product.ImageURL
 	}
@@ -359,6 +588,10 @@ func (p *Product) CompareAndUpdate(product Product) error {
 		hasUpdatedData = true
 		p.IsAvailable = product.IsAvailable
 	}
+	if product.Unit != p.Unit {
+		hasUpdatedData = true
+		p.Unit = product.Unit
+	}
 	if product.Stock != p.Stock {
 		hasUpdatedData = true
 		p.Stock = product.Stock
@@ -367,6 +600,24 @@ func (p *Product) CompareAndUpdate(product Product) error {
 		hasUpdatedData = true
 		p.Price = product.Price
 	}
+	if product.ReorderPoint != p.ReorderPoint {
+		hasUpdatedData = true
+		p.ReorderPoint = product.ReorderPoint
+	}
+	if product.ReorderQuantity != p.ReorderQuantity {
+		hasUpdatedData = true
+		p.ReorderQuantity = product.ReorderQuantity
+	}
+	if product.Cost != p.Cost {
+		hasUpdatedData = true
+		p.Cost = product.Cost
+	}
+	// components left out of an update are kept
+	if product.Components != nil &&
+		!sameComponents(product.Components, p.Components) {
+		hasUpdatedData = true
+		p.Components = product.Components
+	}
 
 	if !hasUpdatedData {
 		return fmt.Errorf("no data updated")
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+// MaxProductImages is how many images a product may have.
+const MaxProductImages = 10
+
+// ImageFormats are the content types accepted for product images and the
+// extension they are stored with.
+var ImageFormats = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// ProductImage is an image uploaded for a product, along with a thumbnail
+// of it. Images are shown by Position, the first one is also the ImageURL
+// of the product. StorageKey and ThumbnailKey are where the files are in
+// the image storage.
+type ProductImage struct {
+	CreatedAt    time.Time
+	URL          string
+	ThumbnailURL string
+	StorageKey   string
+	ThumbnailKey string
+	ContentType  string
+	ID           uuid.UUID
+	ProductID    uuid.UUID
+	CreatedBy    uuid.UUID
+	Position     int
+	Size         int
+	Width        int
+	Height       int
+}
+
+func (pi ProductImage) ToResponseBody() ProductImageResponseBody {
+	return ProductImageResponseBody{
+		ID:           pi.ID.String(),
+		ProductID:    pi.ProductID.String(),
+		Position:     pi.Position,
+		URL:          pi.URL,
+		ThumbnailURL: pi.ThumbnailURL,
+		ContentType:  pi.ContentType,
+		Size:         pi.Size,
+		Width:        pi.Width,
+		Height:       pi.Height,
+		CreatedAt:    util.ToISO8601(pi.CreatedAt),
+	}
+}
+
+type ProductImageResponseBody struct {
+	ID           string `json:"id"`
+	ProductID    string `json:"productId"`
+	Position     int    `json:"position"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl"`
+	ContentType  string `json:"contentType"`
+	Size         int    `json:"size"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	CreatedAt    string `json:"createdAt"`
+}
+
+// ReorderProductImagesRequestBody lists every image of the product in the
+// order they are to be shown.
+type ReorderProductImagesRequestBody struct {
+	ImageIDs []string `json:"imageIds"`
+}
+
+func (body ReorderProductImagesRequestBody) IsValid() bool {
+	if len(body.ImageIDs) == 0 ||
+		len(body.ImageIDs) > MaxProductImages {
+		return false
+	}
+
+	seen := make(map[uuid.UUID]bool, len(body.ImageIDs))
+	for _, imageID := range body.ImageIDs {
+		id, err := uuid.Parse(imageID)
+		if err != nil || seen[id] {
+			return false
+		}
+		seen[id] = true
+	}
+
+	return true
+}
+
+// ToIDs expects a body that passed IsValid.
+func (body ReorderProductImagesRequestBody) ToIDs() []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(body.ImageIDs))
+	for _, imageID := range body.ImageIDs {
+		ids = append(ids, uuid.MustParse(imageID))
+	}
+
+	return ids
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+// ProductPack is a pack of a product that holds Quantity units of it, a
+// case of 24 for instance. Stock is always kept in units, quantities given
+// in packs are turned into units with Units.
+type ProductPack struct {
+	CreatedAt time.Time
+	Name      string
+	SKU       string
+	ID        uuid.UUID
+	ProductID uuid.UUID
+	Quantity  float64
+}
+
+// Units is how many units of the product the packs hold. Packs only come
+// whole, ok is false for a fraction of one.
+func (pp ProductPack) Units(packs float64) (float64, bool) {
+	if !UnitPiece.IsValidQuantity(packs) {
+		return 0, false
+	}
+
+	return util.RoundQuantity(
+		packs*pp.Quantity,
+		util.QuantityDecimals,
+	), true
+}
+
+func (pp ProductPack) ToResponseBody() ProductPackResponseBody {
+	return ProductPackResponseBody{
+		ID:        pp.ID.String(),
+		ProductID: pp.ProductID.String(),
+		Name:      pp.Name,
+		SKU:       pp.SKU,
+		Quantity:  pp.Quantity,
+		CreatedAt: util.ToISO8601(pp.CreatedAt),
+	}
+}
+
+type ProductPackResponseBody struct {
+	ID        string  `json:"id"`
+	ProductID string  `json:"productId"`
+	Name      string  `json:"name"`
+	SKU       string  `json:"sku"`
+	Quantity  float64 `json:"quantity"`
+	CreatedAt string  `json:"createdAt"`
+}
+
+type ProductPackRequestBody struct {
+	Name     string  `json:"name"`
+	SKU      string  `json:"sku"`
+	Quantity float64 `json:"quantity"`
+}
+
+func (body ProductPackRequestBody) IsValid() bool {
+	if nameLen := len(body.Name); nameLen < 1 || nameLen > 30 {
+		return false
+	}
+
+	if skuLen := len(body.SKU); skuLen < 1 || skuLen > 30 {
+		return false
+	}
+
+	return body.Quantity > 0 && body.Quantity <= 100000
+}
+
+func (body ProductPackRequestBody) ToProductPack() ProductPack {
+	return ProductPack{
+		Name:     body.Name,
+		SKU:      body.SKU,
+		Quantity: body.Quantity,
+	}
+}
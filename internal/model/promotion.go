@@ -0,0 +1,312 @@
+package model
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type PromotionType string
+
+const (
+	// PromotionPercentage takes Value percent off the line
+	PromotionPercentage PromotionType = "percentage"
+	// PromotionFixed takes Value off every unit, never below zero
+	PromotionFixed PromotionType = "fixed"
+	// PromotionBuyXGetY gives GetQuantity units free for every
+	// BuyQuantity units bought of the same product
+	PromotionBuyXGetY PromotionType = "buy_x_get_y"
+)
+
+func (pt PromotionType) IsValid() bool {
+	switch pt {
+	case PromotionPercentage,
+		PromotionFixed,
+		PromotionBuyXGetY:
+		return true
+	default:
+		return false
+	}
+}
+
+// Promotion is a discount applied automatically at checkout. It is scoped
+// to a product, to a category or, with neither set, to every product, and
+// only runs between StartsAt and EndsAt when those are set.
+type Promotion struct {
+	StartsAt    time.Time
+	EndsAt      time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Name        string
+	Type        PromotionType
+	Category    ProductCategory
+	ProductID   *uuid.UUID
+	Value       float64
+	BuyQuantity int
+	GetQuantity int
+	IsActive    bool
+	ID          uuid.UUID
+	CreatedBy   uuid.UUID
+}
+
+// IsRunning compares wall clocks in the store time zone, as FindRunning
+// does in SQL, since the window is stored as a timestamp without zone.
+func (p Promotion) IsRunning(at time.Time) bool {
+	if !p.IsActive {
+		return false
+	}
+
+	at = util.WallClock(at.In(util.Location()))
+	if !p.StartsAt.IsZero() && at.Before(util.WallClock(p.StartsAt)) {
+		return false
+	}
+	if !p.EndsAt.IsZero() && !at.Before(util.WallClock(p.EndsAt)) {
+		return false
+	}
+
+	return true
+}
+
+func (p Promotion) AppliesTo(product Product) bool {
+	if p.ProductID != nil {
+		return *p.ProductID == product.ID
+	}
+	if p.Category != "" {
+		return p.Category == product.Category
+	}
+
+	return true
+}
+
+// Discount is the amount taken off a line of quantity units sold at price.
+func (p Promotion) Discount(quantity float64, price float64) float64 {
+	var discount float64
+	switch p.Type {
+	case PromotionPercentage:
+		discount = quantity * price * p.Value / 100
+	case PromotionFixed:
+		discount = quantity * math.Min(p.Value, price)
+	case PromotionBuyXGetY:
+		groupSize := p.BuyQuantity + p.GetQuantity
+		if groupSize == 0 {
+			return 0
+		}
+		// only whole units make up the groups
+		free := int(quantity) / groupSize * p.GetQuantity
+		discount = float64(free) * price
+	}
+
+	return util.RoundMoney(discount)
+}
+
+// BestPromotion picks the promotion giving the biggest discount on a line,
+// promotions don't stack.
+func BestPromotion(
+	promotions []Promotion,
+	product Product,
+	quantity float64,
+	at time.Time,
+) (*Promotion, float64) {
+	var (
+		best         *Promotion
+		bestDiscount float64
+	)
+	for i, promotion := range promotions {
+		if !promotion.IsRunning(at) ||
+			!promotion.AppliesTo(product) {
+			continue
+		}
+
+		discount := promotion.Discount(quantity, product.Price)
+		if discount > bestDiscount {
+			best = &promotions[i]
+			bestDiscount = discount
+		}
+	}
+
+	return best, bestDiscount
+}
+
+func (p Promotion) ToResponseBody() PromotionResponseBody {
+	body := PromotionResponseBody{
+		ID:          p.ID.String(),
+		Name:        p.Name,
+		Type:        string(p.Type),
+		Category:    p.Category,
+		Value:       p.Value,
+		BuyQuantity: p.BuyQuantity,
+		GetQuantity: p.GetQuantity,
+		IsActive:    p.IsActive,
+		CreatedAt:   util.ToISO8601(p.CreatedAt),
+	}
+	if p.ProductID != nil {
+		body.ProductID = p.ProductID.String()
+	}
+	if !p.StartsAt.IsZero() {
+		body.StartsAt = util.ToISO8601(p.StartsAt)
+	}
+	if !p.EndsAt.IsZero() {
+		body.EndsAt = util.ToISO8601(p.EndsAt)
+	}
+
+	return body
+}
+
+type PromotionResponseBody struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Type        string          `json:"type"`
+	ProductID   string          `json:"productId,omitempty"`
+	Category    ProductCategory `json:"category,omitempty"`
+	StartsAt    string          `json:"startsAt,omitempty"`
+	EndsAt      string          `json:"endsAt,omitempty"`
+	CreatedAt   string          `json:"createdAt"`
+	Value       float64         `json:"value"`
+	BuyQuantity int             `json:"buyQuantity"`
+	GetQuantity int             `json:"getQuantity"`
+	IsActive    bool            `json:"isActive"`
+}
+
+type PromotionRequestBody struct {
+	Name        string          `json:"name"`
+	Type        string          `json:"type"`
+	ProductID   string          `json:"productId"`
+	Category    ProductCategory `json:"category"`
+	StartsAt    string          `json:"startsAt"`
+	EndsAt      string          `json:"endsAt"`
+	Value       float64         `json:"value"`
+	BuyQuantity int             `json:"buyQuantity"`
+	GetQuantity int             `json:"getQuantity"`
+	IsActive    *bool           `json:"isActive"`
+}
+
+func (body PromotionRequestBody) IsValid() bool {
+	if nameLen := len(body.Name); nameLen < 1 ||
+		nameLen > 100 {
+		return false
+	}
+
+	switch PromotionType(body.Type) {
+	case PromotionPercentage:
+		if body.Value <= 0 || body.Value > 100 {
+			return false
+		}
+	case PromotionFixed:
+		if body.Value <= 0 {
+			return false
+		}
+	case PromotionBuyXGetY:
+		if body.BuyQuantity < 1 || body.GetQuantity < 1 {
+			return false
+		}
+	default:
+		return false
+	}
+
+	if body.ProductID != "" {
+		if body.Category != "" {
+			return false
+		}
+		if _, err := uuid.Parse(body.ProductID); err != nil {
+			return false
+		}
+	}
+
+	if body.Category != "" &&
+		!body.Category.IsValid() {
+		return false
+	}
+
+	var startsAt, endsAt time.Time
+	for _, date := range []struct {
+		value string
+		t     *time.Time
+	}{
+		{body.StartsAt, &startsAt},
+		{body.EndsAt, &endsAt},
+	} {
+		if date.value == "" {
+			continue
+		}
+		t, err := util.ParseStoreTime(date.value)
+		if err != nil {
+			return false
+		}
+		*date.t = t
+	}
+
+	return startsAt.IsZero() ||
+		endsAt.IsZero() ||
+		startsAt.Before(endsAt)
+}
+
+// ToPromotion expects a body that passed IsValid.
+func (body PromotionRequestBody) ToPromotion() Promotion {
+	promotion := Promotion{
+		Name:     body.Name,
+		Type:     PromotionType(body.Type),
+		Category: body.Category,
+		Value:    body.Value,
+		IsActive: body.IsActive == nil || *body.IsActive,
+	}
+
+	// only buy x get y promotions count units
+	if promotion.Type == PromotionBuyXGetY {
+		promotion.Value = 0
+		promotion.BuyQuantity = body.BuyQuantity
+		promotion.GetQuantity = body.GetQuantity
+	}
+
+	if body.ProductID != "" {
+		productID := uuid.MustParse(body.ProductID)
+		promotion.ProductID = &productID
+	}
+	if body.StartsAt != "" {
+		promotion.StartsAt, _ = util.ParseStoreTime(body.StartsAt)
+	}
+	if body.EndsAt != "" {
+		promotion.EndsAt, _ = util.ParseStoreTime(body.EndsAt)
+	}
+
+	return promotion
+}
+
+type SearchPromotionQuery struct {
+	IsActive string `query:"isActive"`
+	Limit    int    `query:"limit"`
+	Offset   int    `query:"offset"`
+}
+
+func (spq SearchPromotionQuery) IsValid() bool {
+	return (spq.IsActive == "" ||
+		BooleanString(spq.IsActive).IsValid()) &&
+		spq.Limit >= 0 &&
+		spq.Offset >= 0
+}
+
+func (spq SearchPromotionQuery) BuildWhereClauseAndParams() ([]string, []interface{}) {
+	var (
+		sqlClause []string
+		params    []interface{}
+	)
+
+	if BooleanString(spq.IsActive).IsValid() {
+		sqlClause = append(sqlClause, "pr.is_active = $%d")
+		params = append(params, BooleanString(spq.IsActive).ToBool())
+	}
+
+	return sqlClause, params
+}
+
+func (spq SearchPromotionQuery) BuildPagination() (string, []interface{}) {
+	return util.DefaultPaginationBuilder(spq.Limit, spq.Offset)
+}
+
+func (spq SearchPromotionQuery) BuildOrderByClause() []string {
+	return []string{
+		"pr.created_at desc",
+		"pr.id desc",
+	}
+}
@@ -0,0 +1,421 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type PurchaseOrderStatus string
+
+const (
+	PurchaseOrderOrdered           PurchaseOrderStatus = "ordered"
+	PurchaseOrderPartiallyReceived PurchaseOrderStatus = "partially_received"
+	PurchaseOrderReceived          PurchaseOrderStatus = "received"
+	PurchaseOrderCancelled         PurchaseOrderStatus = "cancelled"
+)
+
+func (pos PurchaseOrderStatus) IsValid() bool {
+	switch pos {
+	case PurchaseOrderOrdered,
+		PurchaseOrderPartiallyReceived,
+		PurchaseOrderReceived,
+		PurchaseOrderCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsOpen reports whether stock can still be received against the order.
+func (pos PurchaseOrderStatus) IsOpen() bool {
+	return pos == PurchaseOrderOrdered ||
+		pos == PurchaseOrderPartiallyReceived
+}
+
+type PurchaseOrder struct {
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	ExpectedAt   time.Time
+	Status       PurchaseOrderStatus
+	Notes        string
+	SupplierName string
+	LocationName string
+	Lines        []PurchaseOrderLine
+	ID           uuid.UUID
+	SupplierID   uuid.UUID
+	// LocationID is where the delivery is received
+	LocationID uuid.UUID
+	CreatedBy  uuid.UUID
+	// TotalCost is what the whole order costs at the ordered unit costs
+	TotalCost float64
+}
+
+func (po PurchaseOrder) ComputeTotalCost() float64 {
+	var total float64
+	for _, line := range po.Lines {
+		total += line.Quantity * line.UnitCost
+	}
+
+	return util.RoundMoney(total)
+}
+
+// NextStatus is the status of the order once its lines hold the received
+// quantities.
+func (po PurchaseOrder) NextStatus() PurchaseOrderStatus {
+	var (
+		complete = true
+		started  = false
+	)
+	for _, line := range po.Lines {
+		if line.Remaining() > 0 {
+			complete = false
+		}
+		if line.ReceivedQuantity > 0 {
+			started = true
+		}
+	}
+
+	switch {
+	case complete:
+		return PurchaseOrderReceived
+	case started:
+		return PurchaseOrderPartiallyReceived
+	default:
+		return PurchaseOrderOrdered
+	}
+}
+
+type PurchaseOrderLine struct {
+	ProductName string
+	SKU         string
+	ProductID   uuid.UUID
+	// PackID is the pack the line was ordered in, the quantity is in packs
+	// until the order is saved in units
+	PackID           uuid.UUID
+	Quantity         float64
+	ReceivedQuantity float64
+	UnitCost         float64
+}
+
+func (pol PurchaseOrderLine) Remaining() float64 {
+	return util.RoundQuantity(
+		pol.Quantity-pol.ReceivedQuantity,
+		util.QuantityDecimals,
+	)
+}
+
+func (po PurchaseOrder) ToResponseBody() PurchaseOrderResponseBody {
+	body := PurchaseOrderResponseBody{
+		ID:           po.ID.String(),
+		SupplierID:   po.SupplierID.String(),
+		SupplierName: po.SupplierName,
+		LocationID:   po.LocationID.String(),
+		LocationName: po.LocationName,
+		Status:       string(po.Status),
+		Notes:        po.Notes,
+		CreatedBy:    po.CreatedBy.String(),
+		CreatedAt:    util.ToISO8601(po.CreatedAt),
+		UpdatedAt:    util.ToISO8601(po.UpdatedAt),
+		TotalCost:    po.TotalCost,
+	}
+	if !po.ExpectedAt.IsZero() {
+		body.ExpectedAt = util.ToISO8601(po.ExpectedAt)
+	}
+	if po.Lines != nil {
+		body.Lines = make(
+			[]PurchaseOrderLineResponseBody,
+			0,
+			len(po.Lines),
+		)
+		for _, line := range po.Lines {
+			body.Lines = append(
+				body.Lines,
+				PurchaseOrderLineResponseBody{
+					ProductID:        line.ProductID.String(),
+					Name:             line.ProductName,
+					SKU:              line.SKU,
+					Quantity:         line.Quantity,
+					ReceivedQuantity: line.ReceivedQuantity,
+					UnitCost:         line.UnitCost,
+				},
+			)
+		}
+	}
+
+	return body
+}
+
+type PurchaseOrderResponseBody struct {
+	ID           string                          `json:"id"`
+	SupplierID   string                          `json:"supplierId"`
+	SupplierName string                          `json:"supplierName"`
+	LocationID   string                          `json:"locationId"`
+	LocationName string                          `json:"locationName"`
+	Status       string                          `json:"status"`
+	Notes        string                          `json:"notes,omitempty"`
+	ExpectedAt   string                          `json:"expectedAt,omitempty"`
+	CreatedBy    string                          `json:"createdBy"`
+	CreatedAt    string                          `json:"createdAt"`
+	UpdatedAt    string                          `json:"updatedAt"`
+	Lines        []PurchaseOrderLineResponseBody `json:"lines,omitempty"`
+	TotalCost    float64                         `json:"totalCost"`
+}
+
+type PurchaseOrderLineResponseBody struct {
+	ProductID        string  `json:"productId"`
+	Name             string  `json:"name"`
+	SKU              string  `json:"sku"`
+	Quantity         float64 `json:"quantity"`
+	ReceivedQuantity float64 `json:"receivedQuantity"`
+	UnitCost         float64 `json:"unitCost"`
+}
+
+type PurchaseOrderRequestBody struct {
+	SupplierID string `json:"supplierId"`
+	// LocationID is where the delivery goes, the default location without it
+	LocationID string `json:"locationId"`
+	Notes      string `json:"notes"`
+	// ExpectedAt is when the delivery is due, a date or an ISO 8601 time
+	ExpectedAt string                         `json:"expectedAt"`
+	Lines      []PurchaseOrderLineRequestBody `json:"lines"`
+}
+
+// PurchaseOrderLineRequestBody orders quantity units of the product, or
+// packs of it when packId is set. The unit cost is of a single unit either
+// way.
+type PurchaseOrderLineRequestBody struct {
+	ProductID string   `json:"productId"`
+	PackID    string   `json:"packId"`
+	Quantity  float64  `json:"quantity"`
+	UnitCost  *float64 `json:"unitCost"`
+}
+
+func (body PurchaseOrderRequestBody) IsValid() bool {
+	if _, err := uuid.Parse(body.SupplierID); err != nil {
+		return false
+	}
+
+	if body.LocationID != "" {
+		if _, err := uuid.Parse(body.LocationID); err != nil {
+			return false
+		}
+	}
+
+	if body.ExpectedAt != "" {
+		if _, _, err := util.ParseDate(body.ExpectedAt); err != nil {
+			return false
+		}
+	}
+
+	if len(body.Notes) > 200 || len(body.Lines) == 0 {
+		return false
+	}
+
+	productIDs := make(map[uuid.UUID]bool, len(body.Lines))
+	for _, line := range body.Lines {
+		productID, err := uuid.Parse(line.ProductID)
+		if err != nil || productIDs[productID] {
+			return false
+		}
+		productIDs[productID] = true
+
+		if line.PackID != "" {
+			if _, err := uuid.Parse(line.PackID); err != nil {
+				return false
+			}
+		}
+		if line.Quantity <= 0 || line.Quantity > 100000 {
+			return false
+		}
+		if line.UnitCost == nil || *line.UnitCost < 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ToPurchaseOrder expects a body that passed IsValid.
+func (body PurchaseOrderRequestBody) ToPurchaseOrder() PurchaseOrder {
+	po := PurchaseOrder{
+		SupplierID: uuid.MustParse(body.SupplierID),
+		Status:     PurchaseOrderOrdered,
+		Notes:      body.Notes,
+		Lines:      make([]PurchaseOrderLine, 0, len(body.Lines)),
+	}
+	if body.LocationID != "" {
+		po.LocationID = uuid.MustParse(body.LocationID)
+	}
+	if body.ExpectedAt != "" {
+		po.ExpectedAt, _, _ = util.ParseDate(body.ExpectedAt)
+	}
+	for _, line := range body.Lines {
+		poLine := PurchaseOrderLine{
+			ProductID: uuid.MustParse(line.ProductID),
+			Quantity:  line.Quantity,
+			UnitCost:  util.RoundMoney(*line.UnitCost),
+		}
+		if line.PackID != "" {
+			poLine.PackID = uuid.MustParse(line.PackID)
+		}
+		po.Lines = append(po.Lines, poLine)
+	}
+	po.TotalCost = po.ComputeTotalCost()
+
+	return po
+}
+
+// PurchaseOrderReceipt is a delivery against a purchase order. A receipt
+// without lines takes in everything still outstanding.
+type PurchaseOrderReceipt struct {
+	ReceivedAt      time.Time
+	Note            string
+	Lines           []PurchaseOrderReceiptLine
+	PurchaseOrderID uuid.UUID
+	ReceivedBy      uuid.UUID
+	// AverageCost moves the product cost to the weighted average of the
+	// stock on hand and the delivery, otherwise the product takes the unit
+	// cost of the delivery
+	AverageCost bool
+}
+
+type PurchaseOrderReceiptLine struct {
+	// UnitCost overrides the ordered unit cost, e.g. when the invoice
+	// differs from the order
+	UnitCost  *float64
+	ProductID uuid.UUID
+	// PackID is the pack the quantity is counted in, uuid.Nil for units
+	PackID   uuid.UUID
+	Quantity float64
+}
+
+type ReceivePurchaseOrderRequestBody struct {
+	Note  string                                `json:"note"`
+	Lines []ReceivePurchaseOrderLineRequestBody `json:"lines"`
+}
+
+type ReceivePurchaseOrderLineRequestBody struct {
+	ProductID string   `json:"productId"`
+	PackID    string   `json:"packId"`
+	Quantity  float64  `json:"quantity"`
+	UnitCost  *float64 `json:"unitCost"`
+}
+
+func (body ReceivePurchaseOrderRequestBody) IsValid() bool {
+	if len(body.Note) > 200 {
+		return false
+	}
+
+	productIDs := make(map[uuid.UUID]bool, len(body.Lines))
+	for _, line := range body.Lines {
+		productID, err := uuid.Parse(line.ProductID)
+		if err != nil || productIDs[productID] {
+			return false
+		}
+		productIDs[productID] = true
+
+		if line.PackID != "" {
+			if _, err := uuid.Parse(line.PackID); err != nil {
+				return false
+			}
+		}
+		if line.Quantity <= 0 || line.Quantity > 100000 {
+			return false
+		}
+		if line.UnitCost != nil && *line.UnitCost < 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ToReceipt expects a body that passed IsValid.
+func (body ReceivePurchaseOrderRequestBody) ToReceipt() PurchaseOrderReceipt {
+	receipt := PurchaseOrderReceipt{
+		Note:  body.Note,
+		Lines: make([]PurchaseOrderReceiptLine, 0, len(body.Lines)),
+	}
+	for _, line := range body.Lines {
+		receiptLine := PurchaseOrderReceiptLine{
+			ProductID: uuid.MustParse(line.ProductID),
+			Quantity:  line.Quantity,
+		}
+		if line.PackID != "" {
+			receiptLine.PackID = uuid.MustParse(line.PackID)
+		}
+		if line.UnitCost != nil {
+			unitCost := util.RoundMoney(*line.UnitCost)
+			receiptLine.UnitCost = &unitCost
+		}
+		receipt.Lines = append(receipt.Lines, receiptLine)
+	}
+
+	return receipt
+}
+
+type SearchPurchaseOrderQuery struct {
+	SupplierID string `query:"supplierId"`
+	LocationID string `query:"locationId"`
+	Status     string `query:"status"`
+	Limit      int    `query:"limit"`
+	Offset     int    `query:"offset"`
+}
+
+func (spoq SearchPurchaseOrderQuery) IsValid() bool {
+	if spoq.SupplierID != "" {
+		if _, err := uuid.Parse(spoq.SupplierID); err != nil {
+			return false
+		}
+	}
+
+	if spoq.LocationID != "" {
+		if _, err := uuid.Parse(spoq.LocationID); err != nil {
+			return false
+		}
+	}
+
+	if spoq.Status != "" &&
+		!PurchaseOrderStatus(spoq.Status).IsValid() {
+		return false
+	}
+
+	return spoq.Limit >= 0 && spoq.Offset >= 0
+}
+
+func (spoq SearchPurchaseOrderQuery) BuildWhereClauseAndParams() ([]string, []interface{}) {
+	var (
+		sqlClause []string
+		params    []interface{}
+	)
+
+	if supplierID, err := uuid.Parse(spoq.SupplierID); err == nil {
+		sqlClause = append(sqlClause, "po.supplier_id = $%d")
+		params = append(params, supplierID)
+	}
+
+	if locationID, err := uuid.Parse(spoq.LocationID); err == nil {
+		sqlClause = append(sqlClause, "po.location_id = $%d")
+		params = append(params, locationID)
+	}
+
+	if status := PurchaseOrderStatus(spoq.Status); status.IsValid() {
+		sqlClause = append(sqlClause, "po.status = $%d")
+		params = append(params, string(status))
+	}
+
+	return sqlClause, params
+}
+
+func (spoq SearchPurchaseOrderQuery) BuildPagination() (string, []interface{}) {
+	return util.DefaultPaginationBuilder(spoq.Limit, spoq.Offset)
+}
+
+func (spoq SearchPurchaseOrderQuery) BuildOrderByClause() []string {
+	return []string{
+		"po.created_at desc",
+		"po.id desc",
+	}
+}
@@ -0,0 +1,141 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+// DailyReport is the Z-report of a business day. Sales are the orders
+// created on the day, refunds the orders voided on it whenever they were
+// created, so a day no longer changes once it is over. The counts and
+// breakdowns are net of refunds unless named otherwise.
+type DailyReport struct {
+	Date       time.Time
+	ClosedAt   time.Time
+	TimeZone   string
+	Categories []CategorySales
+	Cashiers   []CashierSales
+	Payments   []PaymentMethodTotal
+	ClosedBy   *uuid.UUID
+	// GrossSales is the total price of the orders of the day, Refunds the
+	// total price of the orders voided on it, both tax included
+	GrossSales  float64
+	Refunds     float64
+	NetSales    float64
+	Discounts   float64
+	Tax         float64
+	OrderCount  int
+	RefundCount int
+	ItemsSold   float64
+}
+
+func (dr DailyReport) IsClosed() bool {
+	return !dr.ClosedAt.IsZero()
+}
+
+// AverageBasket is the average total price of the orders of the day.
+func (dr DailyReport) AverageBasket() float64 {
+	if dr.OrderCount == 0 {
+		return 0
+	}
+
+	return util.RoundMoney(dr.GrossSales / float64(dr.OrderCount))
+}
+
+// CategorySales is the part of a day sold in a category. Sales are the
+// line prices after promotions, before order coupons and without tax added
+// on top.
+type CategorySales struct {
+	Category ProductCategory `json:"category"`
+	Quantity float64         `json:"quantity"`
+	Sales    float64         `json:"sales"`
+}
+
+// CashierSales is the part of a day rung up by a cashier, orders from
+// before cashiers were recorded have no CashierID.
+type CashierSales struct {
+	CashierID   string  `json:"cashierId,omitempty"`
+	Name        string  `json:"name"`
+	OrderCount  int     `json:"orderCount"`
+	RefundCount int     `json:"refundCount"`
+	NetSales    float64 `json:"netSales"`
+}
+
+type PaymentMethodTotal struct {
+	Method string  `json:"method"`
+	Amount float64 `json:"amount"`
+}
+
+func (dr DailyReport) ToResponseBody() DailyReportResponseBody {
+	body := DailyReportResponseBody{
+		Date:          util.FormatDay(dr.Date),
+		TimeZone:      dr.TimeZone,
+		Closed:        dr.IsClosed(),
+		GrossSales:    dr.GrossSales,
+		Refunds:       dr.Refunds,
+		NetSales:      dr.NetSales,
+		Discounts:     dr.Discounts,
+		Tax:           dr.Tax,
+		OrderCount:    dr.OrderCount,
+		RefundCount:   dr.RefundCount,
+		ItemsSold:     dr.ItemsSold,
+		AverageBasket: dr.AverageBasket(),
+		Categories:    dr.Categories,
+		Cashiers:      dr.Cashiers,
+		Payments:      dr.Payments,
+	}
+	if dr.IsClosed() {
+		body.ClosedAt = util.ToISO8601(dr.ClosedAt)
+	}
+	if dr.ClosedBy != nil {
+		body.ClosedBy = dr.ClosedBy.String()
+	}
+
+	return body
+}
+
+type DailyReportResponseBody struct {
+	Date          string               `json:"date"`
+	TimeZone      string               `json:"timeZone"`
+	ClosedAt      string               `json:"closedAt,omitempty"`
+	ClosedBy      string               `json:"closedBy,omitempty"`
+	Categories    []CategorySales      `json:"categories"`
+	Cashiers      []CashierSales       `json:"cashiers"`
+	Payments      []PaymentMethodTotal `json:"payments"`
+	GrossSales    float64              `json:"grossSales"`
+	Refunds       float64              `json:"refunds"`
+	NetSales      float64              `json:"netSales"`
+	Discounts     float64              `json:"discounts"`
+	Tax           float64              `json:"tax"`
+	AverageBasket float64              `json:"averageBasket"`
+	OrderCount    int                  `json:"orderCount"`
+	RefundCount   int                  `json:"refundCount"`
+	ItemsSold     float64              `json:"itemsSold"`
+	Closed        bool                 `json:"closed"`
+}
+
+type DailyReportQuery struct {
+	// Date is a plain date in the store time zone, today when empty
+	Date string `query:"date"`
+}
+
+func (drq DailyReportQuery) IsValid() bool {
+	if drq.Date == "" {
+		return true
+	}
+
+	_, err := util.ParseDay(drq.Date)
+	return err == nil
+}
+
+// Day expects a query that passed IsValid.
+func (drq DailyReportQuery) Day() time.Time {
+	if drq.Date == "" {
+		return util.StartOfDay(util.Now())
+	}
+
+	day, _ := util.ParseDay(drq.Date)
+	return day
+}
@@ -0,0 +1,277 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+// Shift is the time a cashier works a cash drawer, from the opening float
+// to the count of the drawer at close.
+type Shift struct {
+	OpenedAt      time.Time
+	ClosedAt      time.Time
+	Notes         string
+	CashMovements []CashMovement
+	ClosedBy      *uuid.UUID
+	// CountedCash and Variance are only known once the shift is closed
+	CountedCash *float64
+	Variance    *float64
+	ID          uuid.UUID
+	CashierID   uuid.UUID
+	// CashSales is the cash kept from the orders of the shift that were not
+	// voided, change already taken off
+	OpeningFloat float64
+	CashSales    float64
+	PayIns       float64
+	PayOuts      float64
+	ExpectedCash float64
+}
+
+func (s Shift) IsOpen() bool {
+	return s.ClosedAt.IsZero()
+}
+
+// ComputeExpectedCash is what should be in the drawer.
+func (s Shift) ComputeExpectedCash() float64 {
+	return util.RoundMoney(
+		s.OpeningFloat + s.CashSales + s.PayIns - s.PayOuts,
+	)
+}
+
+func (s Shift) ToResponseBody() ShiftResponseBody {
+	body := ShiftResponseBody{
+		ID:           s.ID.String(),
+		CashierID:    s.CashierID.String(),
+		Status:       ShiftOpen,
+		Notes:        s.Notes,
+		OpenedAt:     util.ToISO8601(s.OpenedAt),
+		OpeningFloat: s.OpeningFloat,
+		CashSales:    s.CashSales,
+		PayIns:       s.PayIns,
+		PayOuts:      s.PayOuts,
+		ExpectedCash: s.ExpectedCash,
+		CountedCash:  s.CountedCash,
+		Variance:     s.Variance,
+	}
+	if !s.IsOpen() {
+		body.Status = ShiftClosed
+		body.ClosedAt = util.ToISO8601(s.ClosedAt)
+	}
+	if s.ClosedBy != nil {
+		body.ClosedBy = s.ClosedBy.String()
+	}
+	if s.CashMovements != nil {
+		body.CashMovements = make(
+			[]CashMovementResponseBody,
+			0,
+			len(s.CashMovements),
+		)
+		for _, movement := range s.CashMovements {
+			body.CashMovements = append(
+				body.CashMovements,
+				movement.ToResponseBody(),
+			)
+		}
+	}
+
+	return body
+}
+
+const (
+	ShiftOpen   = "open"
+	ShiftClosed = "closed"
+)
+
+type ShiftResponseBody struct {
+	ID            string                     `json:"id"`
+	CashierID     string                     `json:"cashierId"`
+	Status        string                     `json:"status"`
+	Notes         string                     `json:"notes,omitempty"`
+	OpenedAt      string                     `json:"openedAt"`
+	ClosedAt      string                     `json:"closedAt,omitempty"`
+	ClosedBy      string                     `json:"closedBy,omitempty"`
+	CashMovements []CashMovementResponseBody `json:"cashMovements,omitempty"`
+	CountedCash   *float64                   `json:"countedCash"`
+	Variance      *float64                   `json:"variance"`
+	OpeningFloat  float64                    `json:"openingFloat"`
+	CashSales     float64                    `json:"cashSales"`
+	PayIns        float64                    `json:"payIns"`
+	PayOuts       float64                    `json:"payOuts"`
+	ExpectedCash  float64                    `json:"expectedCash"`
+}
+
+type OpenShiftRequestBody struct {
+	Notes        string  `json:"notes"`
+	OpeningFloat float64 `json:"openingFloat"`
+}
+
+func (body OpenShiftRequestBody) IsValid() bool {
+	return body.OpeningFloat >= 0 &&
+		len(body.Notes) <= 200
+}
+
+func (body OpenShiftRequestBody) ToShift() Shift {
+	return Shift{
+		Notes:        body.Notes,
+		OpeningFloat: util.RoundMoney(body.OpeningFloat),
+	}
+}
+
+type CloseShiftRequestBody struct {
+	Notes       string   `json:"notes"`
+	CountedCash *float64 `json:"countedCash"`
+}
+
+func (body CloseShiftRequestBody) IsValid() bool {
+	return body.CountedCash != nil &&
+		*body.CountedCash >= 0 &&
+		len(body.Notes) <= 200
+}
+
+// ShiftClose is the count of the drawer when the shift is closed.
+type ShiftClose struct {
+	ClosedAt    time.Time
+	Notes       string
+	ID          uuid.UUID
+	ClosedBy    uuid.UUID
+	CountedCash float64
+}
+
+// ToShiftClose expects a body that passed IsValid.
+func (body CloseShiftRequestBody) ToShiftClose() ShiftClose {
+	return ShiftClose{
+		Notes:       body.Notes,
+		CountedCash: util.RoundMoney(*body.CountedCash),
+	}
+}
+
+type CashMovementType string
+
+const (
+	// CashPayIn is cash put in the drawer outside of a sale, e.g. more change
+	CashPayIn CashMovementType = "pay_in"
+	// CashPayOut is cash taken out of the drawer, e.g. to pay a supplier
+	CashPayOut CashMovementType = "pay_out"
+)
+
+func (cmt CashMovementType) IsValid() bool {
+	switch cmt {
+	case CashPayIn, CashPayOut:
+		return true
+	default:
+		return false
+	}
+}
+
+type CashMovement struct {
+	CreatedAt time.Time
+	Type      CashMovementType
+	Reason    string
+	ID        uuid.UUID
+	ShiftID   uuid.UUID
+	CreatedBy uuid.UUID
+	Amount    float64
+}
+
+func (cm CashMovement) ToResponseBody() CashMovementResponseBody {
+	return CashMovementResponseBody{
+		ID:        cm.ID.String(),
+		Type:      string(cm.Type),
+		Reason:    cm.Reason,
+		CreatedBy: cm.CreatedBy.String(),
+		CreatedAt: util.ToISO8601(cm.CreatedAt),
+		Amount:    cm.Amount,
+	}
+}
+
+type CashMovementResponseBody struct {
+	ID        string  `json:"id"`
+	Type      string  `json:"type"`
+	Reason    string  `json:"reason"`
+	CreatedBy string  `json:"createdBy"`
+	CreatedAt string  `json:"createdAt"`
+	Amount    float64 `json:"amount"`
+}
+
+type CashMovementRequestBody struct {
+	Type   string  `json:"type"`
+	Reason string  `json:"reason"`
+	Amount float64 `json:"amount"`
+}
+
+func (body CashMovementRequestBody) IsValid() bool {
+	if reasonLen := len(body.Reason); reasonLen < 1 ||
+		reasonLen > 200 {
+		return false
+	}
+
+	return CashMovementType(body.Type).IsValid() &&
+		body.Amount > 0
+}
+
+func (body CashMovementRequestBody) ToCashMovement() CashMovement {
+	return CashMovement{
+		Type:   CashMovementType(body.Type),
+		Reason: body.Reason,
+		Amount: util.RoundMoney(body.Amount),
+	}
+}
+
+type SearchShiftQuery struct {
+	CashierID string `query:"cashierId"`
+	Status    string `query:"status"`
+	Limit     int    `query:"limit"`
+	Offset    int    `query:"offset"`
+}
+
+func (ssq SearchShiftQuery) IsValid() bool {
+	if ssq.CashierID != "" {
+		if _, err := uuid.Parse(ssq.CashierID); err != nil {
+			return false
+		}
+	}
+
+	switch ssq.Status {
+	case "", ShiftOpen, ShiftClosed:
+	default:
+		return false
+	}
+
+	return ssq.Limit >= 0 && ssq.Offset >= 0
+}
+
+func (ssq SearchShiftQuery) BuildWhereClauseAndParams() ([]string, []interface{}) {
+	var (
+		sqlClause []string
+		params    []interface{}
+	)
+
+	if cashierID, err := uuid.Parse(ssq.CashierID); err == nil {
+		sqlClause = append(sqlClause, "s.cashier_id = $%d")
+		params = append(params, cashierID)
+	}
+
+	switch ssq.Status {
+	case ShiftOpen:
+		sqlClause = append(sqlClause, "(s.closed_at is null) = $%d")
+		params = append(params, true)
+	case ShiftClosed:
+		sqlClause = append(sqlClause, "(s.closed_at is null) = $%d")
+		params = append(params, false)
+	}
+
+	return sqlClause, params
+}
+
+func (ssq SearchShiftQuery) BuildPagination() (string, []interface{}) {
+	return util.DefaultPaginationBuilder(ssq.Limit, ssq.Offset)
+}
+
+func (ssq SearchShiftQuery) BuildOrderByClause() []string {
+	return []string{
+		"s.opened_at desc",
+		"s.id desc",
+	}
+}
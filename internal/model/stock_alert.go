@@ -0,0 +1,136 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+// StockAlert is raised once when a checkout leaves a product at or below
+// its reorder point, it is sent to the notifier in the background.
+type StockAlert struct {
+	CreatedAt       time.Time
+	Name            string
+	SKU             string
+	ID              uuid.UUID
+	ProductID       uuid.UUID
+	Stock           float64
+	ReorderPoint    float64
+	ReorderQuantity float64
+	Attempts        int
+}
+
+// StockAlertEvent is what notifiers send out, e.g. as a webhook body.
+type StockAlertEvent struct {
+	Event           string  `json:"event"`
+	ID              string  `json:"id"`
+	ProductID       string  `json:"productId"`
+	Name            string  `json:"name"`
+	SKU             string  `json:"sku"`
+	CreatedAt       string  `json:"createdAt"`
+	Stock           float64 `json:"stock"`
+	ReorderPoint    float64 `json:"reorderPoint"`
+	ReorderQuantity float64 `json:"reorderQuantity"`
+}
+
+const StockAlertLowStock = "product.low_stock"
+
+func (sa StockAlert) ToEvent() StockAlertEvent {
+	return StockAlertEvent{
+		Event:           StockAlertLowStock,
+		ID:              sa.ID.String(),
+		ProductID:       sa.ProductID.String(),
+		Name:            sa.Name,
+		SKU:             sa.SKU,
+		CreatedAt:       util.ToISO8601(sa.CreatedAt),
+		Stock:           sa.Stock,
+		ReorderPoint:    sa.ReorderPoint,
+		ReorderQuantity: sa.ReorderQuantity,
+	}
+}
+
+// LowStockProduct is a product at or below its reorder point.
+type LowStockProduct struct {
+	// AlertedAt is when the alert of the current crossing was raised, zero
+	// when the stock got low without a checkout, e.g. by an edit
+	AlertedAt       time.Time
+	Name            string
+	SKU             string
+	Category        ProductCategory
+	ID              uuid.UUID
+	Stock           float64
+	ReorderPoint    float64
+	ReorderQuantity float64
+}
+
+func (lsp LowStockProduct) ToResponseBody() LowStockResponseBody {
+	body := LowStockResponseBody{
+		ID:              lsp.ID.String(),
+		Name:            lsp.Name,
+		SKU:             lsp.SKU,
+		Category:        lsp.Category,
+		Stock:           lsp.Stock,
+		ReorderPoint:    lsp.ReorderPoint,
+		ReorderQuantity: lsp.ReorderQuantity,
+	}
+	if !lsp.AlertedAt.IsZero() {
+		body.AlertedAt = util.ToISO8601(lsp.AlertedAt)
+	}
+
+	return body
+}
+
+type LowStockResponseBody struct {
+	ID              string          `json:"id"`
+	Name            string          `json:"name"`
+	SKU             string          `json:"sku"`
+	Category        ProductCategory `json:"category"`
+	AlertedAt       string          `json:"alertedAt,omitempty"`
+	Stock           float64         `json:"stock"`
+	ReorderPoint    float64         `json:"reorderPoint"`
+	ReorderQuantity float64         `json:"reorderQuantity"`
+}
+
+type SearchLowStockQuery struct {
+	Category string `query:"category"`
+	Limit    int    `query:"limit"`
+	Offset   int    `query:"offset"`
+}
+
+func (slsq SearchLowStockQuery) IsValid() bool {
+	return (slsq.Category == "" ||
+		ProductCategory(slsq.Category).IsValid()) &&
+		slsq.Limit >= 0 &&
+		slsq.Offset >= 0
+}
+
+func (slsq SearchLowStockQuery) BuildWhereClauseAndParams() ([]string, []interface{}) {
+	var (
+		sqlClause []string
+		params    []interface{}
+	)
+
+	if ProductCategory(slsq.Category).IsValid() {
+		sqlClause = append(sqlClause, "p.category = $%d")
+		params = append(
+			params,
+			ProductCategory(slsq.Category).ToDBEnumType(),
+		)
+	}
+
+	return sqlClause, params
+}
+
+func (slsq SearchLowStockQuery) BuildPagination() (string, []interface{}) {
+	return util.DefaultPaginationBuilder(slsq.Limit, slsq.Offset)
+}
+
+// BuildOrderByClause puts the products furthest below their reorder point
+// first.
+func (slsq SearchLowStockQuery) BuildOrderByClause() []string {
+	return []string{
+		"p.stock - p.reorder_point",
+		"p.id",
+	}
+}
@@ -0,0 +1,379 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type StockCountStatus string
+
+const (
+	StockCountOpen      StockCountStatus = "open"
+	StockCountPosted    StockCountStatus = "posted"
+	StockCountCancelled StockCountStatus = "cancelled"
+)
+
+func (scs StockCountStatus) IsValid() bool {
+	switch scs {
+	case StockCountOpen, StockCountPosted, StockCountCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// StockCount is a physical count of the stock at a location, of a single
+// category when Category is set. Its lines are the products counted, taken
+// when the count is opened. Posting it adjusts the stock to what was counted.
+type StockCount struct {
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	ClosedAt     time.Time
+	Status       StockCountStatus
+	Category     ProductCategory
+	Notes        string
+	LocationName string
+	Lines        []StockCountLine
+	ClosedBy     *uuid.UUID
+	ID           uuid.UUID
+	LocationID   uuid.UUID
+	CreatedBy    uuid.UUID
+	LineCount    int
+	CountedCount int
+}
+
+// StockCountLine is a product of a count. Expected is its stock when the
+// count was opened, StockAtCount its stock when it was last counted and
+// Stock its stock now. Counted and StockAtCount are nil until it is counted.
+type StockCountLine struct {
+	CountedAt    time.Time
+	ProductName  string
+	SKU          string
+	Unit         UnitOfMeasure
+	Counted      *float64
+	StockAtCount *float64
+	CountedBy    *uuid.UUID
+	ProductID    uuid.UUID
+	Expected     float64
+	Stock        float64
+	Cost         float64
+}
+
+// Variance is what the count found more, or less when negative, than the
+// stock when the line was counted. Posting adjusts the stock by it.
+func (scl StockCountLine) Variance() float64 {
+	if scl.Counted == nil || scl.StockAtCount == nil {
+		return 0
+	}
+
+	return util.RoundQuantity(
+		*scl.Counted-*scl.StockAtCount,
+		util.QuantityDecimals,
+	)
+}
+
+// SoldSinceCounted is how much the stock went down after the line was
+// counted, the sales that posting the count keeps.
+func (scl StockCountLine) SoldSinceCounted() float64 {
+	if scl.StockAtCount == nil {
+		return 0
+	}
+
+	return util.RoundQuantity(
+		*scl.StockAtCount-scl.Stock,
+		util.QuantityDecimals,
+	)
+}
+
+func (sc StockCount) ToResponseBody() StockCountResponseBody {
+	body := StockCountResponseBody{
+		ID:           sc.ID.String(),
+		LocationID:   sc.LocationID.String(),
+		LocationName: sc.LocationName,
+		Category:     sc.Category,
+		Status:       string(sc.Status),
+		Notes:        sc.Notes,
+		CreatedBy:    sc.CreatedBy.String(),
+		CreatedAt:    util.ToISO8601(sc.CreatedAt),
+		UpdatedAt:    util.ToISO8601(sc.UpdatedAt),
+		LineCount:    sc.LineCount,
+		CountedCount: sc.CountedCount,
+	}
+	if !sc.ClosedAt.IsZero() {
+		body.ClosedAt = util.ToISO8601(sc.ClosedAt)
+	}
+	if sc.ClosedBy != nil {
+		body.ClosedBy = sc.ClosedBy.String()
+	}
+	if sc.Lines != nil {
+		var varianceValue float64
+		body.Lines = make(
+			[]StockCountLineResponseBody,
+			0,
+			len(sc.Lines),
+		)
+		for _, line := range sc.Lines {
+			lineBody := StockCountLineResponseBody{
+				ProductID:        line.ProductID.String(),
+				Name:             line.ProductName,
+				SKU:              line.SKU,
+				Unit:             line.Unit,
+				Expected:         line.Expected,
+				Counted:          line.Counted,
+				StockAtCount:     line.StockAtCount,
+				Stock:            line.Stock,
+				Variance:         line.Variance(),
+				SoldSinceCounted: line.SoldSinceCounted(),
+			}
+			if !line.CountedAt.IsZero() {
+				lineBody.CountedAt = util.ToISO8601(line.CountedAt)
+			}
+			if line.CountedBy != nil {
+				lineBody.CountedBy = line.CountedBy.String()
+			}
+			body.Lines = append(body.Lines, lineBody)
+
+			if variance := line.Variance(); variance != 0 {
+				body.VarianceLines++
+				body.VarianceUnits = util.RoundQuantity(
+					body.VarianceUnits+variance,
+					util.QuantityDecimals,
+				)
+				varianceValue += variance * line.Cost
+			}
+		}
+		body.VarianceValue = util.RoundMoney(varianceValue)
+	}
+
+	return body
+}
+
+type StockCountResponseBody struct {
+	ID           string          `json:"id"`
+	LocationID   string          `json:"locationId"`
+	LocationName string          `json:"locationName"`
+	Category     ProductCategory `json:"category,omitempty"`
+	Status       string          `json:"status"`
+	Notes        string          `json:"notes,omitempty"`
+	CreatedBy    string          `json:"createdBy"`
+	CreatedAt    string          `json:"createdAt"`
+	UpdatedAt    string          `json:"updatedAt"`
+	ClosedBy     string          `json:"closedBy,omitempty"`
+	ClosedAt     string          `json:"closedAt,omitempty"`
+	LineCount    int             `json:"lineCount"`
+	CountedCount int             `json:"countedCount"`
+	// the variance totals cover the lines counted so far, the value is at
+	// the current product costs
+	VarianceLines int                          `json:"varianceLines,omitempty"`
+	VarianceUnits float64                      `json:"varianceUnits,omitempty"`
+	VarianceValue float64                      `json:"varianceValue,omitempty"`
+	Lines         []StockCountLineResponseBody `json:"lines,omitempty"`
+}
+
+type StockCountLineResponseBody struct {
+	ProductID        string        `json:"productId"`
+	Name             string        `json:"name"`
+	SKU              string        `json:"sku"`
+	Unit             UnitOfMeasure `json:"unit"`
+	CountedBy        string        `json:"countedBy,omitempty"`
+	CountedAt        string        `json:"countedAt,omitempty"`
+	Expected         float64       `json:"expected"`
+	Counted          *float64      `json:"counted"`
+	StockAtCount     *float64      `json:"stockAtCount"`
+	Stock            float64       `json:"stock"`
+	Variance         float64       `json:"variance"`
+	SoldSinceCounted float64       `json:"soldSinceCounted"`
+}
+
+// OpenStockCountRequestBody opens a count of the location, the default one
+// without it, of every product or of a category.
+type OpenStockCountRequestBody struct {
+	LocationID string `json:"locationId"`
+	Category   string `json:"category"`
+	Notes      string `json:"notes"`
+}
+
+func (body OpenStockCountRequestBody) IsValid() bool {
+	if body.LocationID != "" {
+		if _, err := uuid.Parse(body.LocationID); err != nil {
+			return false
+		}
+	}
+
+	if body.Category != "" &&
+		!ProductCategory(body.Category).IsValid() {
+		return false
+	}
+
+	return len(body.Notes) <= 200
+}
+
+// ToStockCount expects a body that passed IsValid.
+func (body OpenStockCountRequestBody) ToStockCount() StockCount {
+	count := StockCount{
+		Category: ProductCategory(body.Category),
+		Status:   StockCountOpen,
+		Notes:    body.Notes,
+	}
+	if body.LocationID != "" {
+		count.LocationID = uuid.MustParse(body.LocationID)
+	}
+
+	return count
+}
+
+// StockCountEntry is a quantity counted of a product, given by its id or by
+// its SKU as read by a scanner.
+type StockCountEntry struct {
+	SKU       string
+	ProductID uuid.UUID
+	Quantity  float64
+}
+
+// StockCountRecord records entries against a count. Add is set for scans,
+// which add to what was counted so far instead of replacing it.
+type StockCountRecord struct {
+	RecordedAt time.Time
+	Entries    []StockCountEntry
+	CountID    uuid.UUID
+	RecordedBy uuid.UUID
+	Add        bool
+}
+
+// RecordStockCountRequestBody sets the counted quantities of the lines,
+// each identified by either productId or sku.
+type RecordStockCountRequestBody struct {
+	Lines []RecordStockCountLineRequestBody `json:"lines"`
+}
+
+type RecordStockCountLineRequestBody struct {
+	ProductID string   `json:"productId"`
+	SKU       string   `json:"sku"`
+	Counted   *float64 `json:"counted"`
+}
+
+func (body RecordStockCountRequestBody) IsValid() bool {
+	if len(body.Lines) == 0 {
+		return false
+	}
+
+	for _, line := range body.Lines {
+		if (line.ProductID == "") == (line.SKU == "") {
+			return false
+		}
+		if line.ProductID != "" {
+			if _, err := uuid.Parse(line.ProductID); err != nil {
+				return false
+			}
+		}
+		if line.Counted == nil || *line.Counted < 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ToEntries expects a body that passed IsValid.
+func (body RecordStockCountRequestBody) ToEntries() []StockCountEntry {
+	entries := make([]StockCountEntry, 0, len(body.Lines))
+	for _, line := range body.Lines {
+		entry := StockCountEntry{
+			SKU:      line.SKU,
+			Quantity: *line.Counted,
+		}
+		if line.ProductID != "" {
+			entry.ProductID = uuid.MustParse(line.ProductID)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// ScanStockCountRequestBody adds quantity, 1 when left out, to what was
+// counted of the product with the SKU. A negative quantity takes back a
+// scan made by mistake.
+type ScanStockCountRequestBody struct {
+	SKU      string  `json:"sku"`
+	Quantity float64 `json:"quantity"`
+}
+
+func (body ScanStockCountRequestBody) IsValid() bool {
+	return body.SKU != "" &&
+		len(body.SKU) <= 30 &&
+		body.Quantity >= -100000 &&
+		body.Quantity <= 100000
+}
+
+func (body ScanStockCountRequestBody) ToEntry() StockCountEntry {
+	quantity := body.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+
+	return StockCountEntry{
+		SKU:      body.SKU,
+		Quantity: quantity,
+	}
+}
+
+// PostStockCountRequestBody posts the count. Lines left uncounted keep
+// their stock unless uncountedAsZero is set, then they are counted as none.
+type PostStockCountRequestBody struct {
+	UncountedAsZero bool `json:"uncountedAsZero"`
+}
+
+type SearchStockCountQuery struct {
+	LocationID string `query:"locationId"`
+	Status     string `query:"status"`
+	Limit      int    `query:"limit"`
+	Offset     int    `query:"offset"`
+}
+
+func (sscq SearchStockCountQuery) IsValid() bool {
+	if sscq.LocationID != "" {
+		if _, err := uuid.Parse(sscq.LocationID); err != nil {
+			return false
+		}
+	}
+
+	if sscq.Status != "" &&
+		!StockCountStatus(sscq.Status).IsValid() {
+		return false
+	}
+
+	return sscq.Limit >= 0 && sscq.Offset >= 0
+}
+
+func (sscq SearchStockCountQuery) BuildWhereClauseAndParams() ([]string, []interface{}) {
+	var (
+		sqlClause []string
+		params    []interface{}
+	)
+
+	if locationID, err := uuid.Parse(sscq.LocationID); err == nil {
+		sqlClause = append(sqlClause, "sc.location_id = $%d")
+		params = append(params, locationID)
+	}
+
+	if status := StockCountStatus(sscq.Status); status.IsValid() {
+		sqlClause = append(sqlClause, "sc.status = $%d")
+		params = append(params, string(status))
+	}
+
+	return sqlClause, params
+}
+
+func (sscq SearchStockCountQuery) BuildPagination() (string, []interface{}) {
+	return util.DefaultPaginationBuilder(sscq.Limit, sscq.Offset)
+}
+
+func (sscq SearchStockCountQuery) BuildOrderByClause() []string {
+	return []string{
+		"sc.created_at desc",
+		"sc.id desc",
+	}
+}
@@ -0,0 +1,134 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type StockMovementType string
+
+const (
+	// StockReceipt is stock taken in from a purchase order
+	StockReceipt StockMovementType = "receipt"
+	// StockTransferOut is stock sent from its location to another one
+	StockTransferOut StockMovementType = "transfer_out"
+	// StockTransferIn is transferred stock received at its location, or
+	// returned to the source when the transfer was cancelled
+	StockTransferIn StockMovementType = "transfer_in"
+	// StockCountAdjustment corrects the stock to a physical count
+	StockCountAdjustment StockMovementType = "count_adjustment"
+)
+
+func (smt StockMovementType) IsValid() bool {
+	switch smt {
+	case StockReceipt,
+		StockTransferOut,
+		StockTransferIn,
+		StockCountAdjustment:
+		return true
+	default:
+		return false
+	}
+}
+
+// StockMovement is a change of stock at a location other than a sale,
+// Quantity is negative when stock went out.
+type StockMovement struct {
+	CreatedAt       time.Time
+	Type            StockMovementType
+	Note            string
+	UnitCost        *float64
+	PurchaseOrderID *uuid.UUID
+	TransferID      *uuid.UUID
+	StockCountID    *uuid.UUID
+	ID              uuid.UUID
+	ProductID       uuid.UUID
+	LocationID      uuid.UUID
+	CreatedBy       uuid.UUID
+	Quantity        float64
+}
+
+func (sm StockMovement) ToResponseBody() StockMovementResponseBody {
+	body := StockMovementResponseBody{
+		ID:         sm.ID.String(),
+		ProductID:  sm.ProductID.String(),
+		LocationID: sm.LocationID.String(),
+		Type:       string(sm.Type),
+		Note:       sm.Note,
+		CreatedBy:  sm.CreatedBy.String(),
+		CreatedAt:  util.ToISO8601(sm.CreatedAt),
+		Quantity:   sm.Quantity,
+		UnitCost:   sm.UnitCost,
+	}
+	if sm.PurchaseOrderID != nil {
+		body.PurchaseOrderID = sm.PurchaseOrderID.String()
+	}
+	if sm.TransferID != nil {
+		body.TransferID = sm.TransferID.String()
+	}
+	if sm.StockCountID != nil {
+		body.StockCountID = sm.StockCountID.String()
+	}
+
+	return body
+}
+
+type StockMovementResponseBody struct {
+	ID              string   `json:"id"`
+	ProductID       string   `json:"productId"`
+	LocationID      string   `json:"locationId"`
+	Type            string   `json:"type"`
+	PurchaseOrderID string   `json:"purchaseOrderId,omitempty"`
+	TransferID      string   `json:"transferId,omitempty"`
+	StockCountID    string   `json:"stockCountId,omitempty"`
+	Note            string   `json:"note,omitempty"`
+	CreatedBy       string   `json:"createdBy"`
+	CreatedAt       string   `json:"createdAt"`
+	UnitCost        *float64 `json:"unitCost,omitempty"`
+	Quantity        float64  `json:"quantity"`
+}
+
+type SearchStockMovementQuery struct {
+	Type       string    `query:"type"`
+	Limit      int       `query:"limit"`
+	Offset     int       `query:"offset"`
+	LocationID uuid.UUID `query:"locationId"`
+	ProductID  uuid.UUID `query:"-"`
+}
+
+func (ssmq SearchStockMovementQuery) IsValid() bool {
+	return (ssmq.Type == "" ||
+		StockMovementType(ssmq.Type).IsValid()) &&
+		ssmq.Limit >= 0 &&
+		ssmq.Offset >= 0
+}
+
+func (ssmq SearchStockMovementQuery) BuildWhereClauseAndParams() ([]string, []interface{}) {
+	sqlClause := []string{"sm.product_id = $%d"}
+	params := []interface{}{ssmq.ProductID}
+
+	if movementType := StockMovementType(ssmq.Type); movementType.IsValid() {
+		sqlClause = append(sqlClause, "sm.type = $%d")
+		params = append(params, string(movementType))
+	}
+
+	if ssmq.LocationID != uuid.Nil {
+		sqlClause = append(sqlClause, "sm.location_id = $%d")
+		params = append(params, ssmq.LocationID)
+	}
+
+	return sqlClause, params
+}
+
+func (ssmq SearchStockMovementQuery) BuildPagination() (string, []interface{}) {
+	return util.DefaultPaginationBuilder(ssmq.Limit, ssmq.Offset)
+}
+
+func (ssmq SearchStockMovementQuery) BuildOrderByClause() []string {
+	return []string{
+		"sm.created_at desc",
+		"sm.id desc",
+	}
+}
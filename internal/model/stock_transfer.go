@@ -0,0 +1,236 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type StockTransferStatus string
+
+const (
+	StockTransferInTransit StockTransferStatus = "in_transit"
+	StockTransferReceived  StockTransferStatus = "received"
+	StockTransferCancelled StockTransferStatus = "cancelled"
+)
+
+func (sts StockTransferStatus) IsValid() bool {
+	switch sts {
+	case StockTransferInTransit,
+		StockTransferReceived,
+		StockTransferCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// StockTransfer moves stock from one location to another. The stock leaves
+// the source when the transfer is created and is at neither location until
+// the transfer is received, cancelling puts it back at the source.
+type StockTransfer struct {
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	ReceivedAt       time.Time
+	Status           StockTransferStatus
+	Notes            string
+	FromLocationName string
+	ToLocationName   string
+	Lines            []StockTransferLine
+	ReceivedBy       *uuid.UUID
+	ID               uuid.UUID
+	FromLocationID   uuid.UUID
+	ToLocationID     uuid.UUID
+	CreatedBy        uuid.UUID
+}
+
+type StockTransferLine struct {
+	ProductName string
+	SKU         string
+	ProductID   uuid.UUID
+	Quantity    float64
+}
+
+func (st StockTransfer) ToResponseBody() StockTransferResponseBody {
+	body := StockTransferResponseBody{
+		ID:               st.ID.String(),
+		FromLocationID:   st.FromLocationID.String(),
+		FromLocationName: st.FromLocationName,
+		ToLocationID:     st.ToLocationID.String(),
+		ToLocationName:   st.ToLocationName,
+		Status:           string(st.Status),
+		Notes:            st.Notes,
+		CreatedBy:        st.CreatedBy.String(),
+		CreatedAt:        util.ToISO8601(st.CreatedAt),
+		UpdatedAt:        util.ToISO8601(st.UpdatedAt),
+	}
+	if !st.ReceivedAt.IsZero() {
+		body.ReceivedAt = util.ToISO8601(st.ReceivedAt)
+	}
+	if st.ReceivedBy != nil {
+		body.ReceivedBy = st.ReceivedBy.String()
+	}
+	if st.Lines != nil {
+		body.Lines = make(
+			[]StockTransferLineResponseBody,
+			0,
+			len(st.Lines),
+		)
+		for _, line := range st.Lines {
+			body.Lines = append(
+				body.Lines,
+				StockTransferLineResponseBody{
+					ProductID: line.ProductID.String(),
+					Name:      line.ProductName,
+					SKU:       line.SKU,
+					Quantity:  line.Quantity,
+				},
+			)
+		}
+	}
+
+	return body
+}
+
+type StockTransferResponseBody struct {
+	ID               string                          `json:"id"`
+	FromLocationID   string                          `json:"fromLocationId"`
+	FromLocationName string                          `json:"fromLocationName"`
+	ToLocationID     string                          `json:"toLocationId"`
+	ToLocationName   string                          `json:"toLocationName"`
+	Status           string                          `json:"status"`
+	Notes            string                          `json:"notes,omitempty"`
+	CreatedBy        string                          `json:"createdBy"`
+	CreatedAt        string                          `json:"createdAt"`
+	UpdatedAt        string                          `json:"updatedAt"`
+	ReceivedBy       string                          `json:"receivedBy,omitempty"`
+	ReceivedAt       string                          `json:"receivedAt,omitempty"`
+	Lines            []StockTransferLineResponseBody `json:"lines,omitempty"`
+}
+
+type StockTransferLineResponseBody struct {
+	ProductID string  `json:"productId"`
+	Name      string  `json:"name"`
+	SKU       string  `json:"sku"`
+	Quantity  float64 `json:"quantity"`
+}
+
+type StockTransferRequestBody struct {
+	FromLocationID string                         `json:"fromLocationId"`
+	ToLocationID   string                         `json:"toLocationId"`
+	Notes          string                         `json:"notes"`
+	Lines          []StockTransferLineRequestBody `json:"lines"`
+}
+
+type StockTransferLineRequestBody struct {
+	ProductID string  `json:"productId"`
+	Quantity  float64 `json:"quantity"`
+}
+
+func (body StockTransferRequestBody) IsValid() bool {
+	from, err := uuid.Parse(body.FromLocationID)
+	if err != nil {
+		return false
+	}
+
+	to, err := uuid.Parse(body.ToLocationID)
+	if err != nil || from == to {
+		return false
+	}
+
+	if len(body.Notes) > 200 || len(body.Lines) == 0 {
+		return false
+	}
+
+	productIDs := make(map[uuid.UUID]bool, len(body.Lines))
+	for _, line := range body.Lines {
+		productID, err := uuid.Parse(line.ProductID)
+		if err != nil || productIDs[productID] {
+			return false
+		}
+		productIDs[productID] = true
+
+		if line.Quantity <= 0 || line.Quantity > 100000 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ToStockTransfer expects a body that passed IsValid.
+func (body StockTransferRequestBody) ToStockTransfer() StockTransfer {
+	transfer := StockTransfer{
+		FromLocationID: uuid.MustParse(body.FromLocationID),
+		ToLocationID:   uuid.MustParse(body.ToLocationID),
+		Status:         StockTransferInTransit,
+		Notes:          body.Notes,
+		Lines:          make([]StockTransferLine, 0, len(body.Lines)),
+	}
+	for _, line := range body.Lines {
+		transfer.Lines = append(transfer.Lines, StockTransferLine{
+			ProductID: uuid.MustParse(line.ProductID),
+			Quantity:  line.Quantity,
+		})
+	}
+
+	return transfer
+}
+
+// SearchStockTransferQuery filters on the status and on a location the
+// transfers leave or go to.
+type SearchStockTransferQuery struct {
+	LocationID string `query:"locationId"`
+	Status     string `query:"status"`
+	Limit      int    `query:"limit"`
+	Offset     int    `query:"offset"`
+}
+
+func (sstq SearchStockTransferQuery) IsValid() bool {
+	if sstq.LocationID != "" {
+		if _, err := uuid.Parse(sstq.LocationID); err != nil {
+			return false
+		}
+	}
+
+	if sstq.Status != "" &&
+		!StockTransferStatus(sstq.Status).IsValid() {
+		return false
+	}
+
+	return sstq.Limit >= 0 && sstq.Offset >= 0
+}
+
+func (sstq SearchStockTransferQuery) BuildWhereClauseAndParams() ([]string, []interface{}) {
+	var (
+		sqlClause []string
+		params    []interface{}
+	)
+
+	if locationID, err := uuid.Parse(sstq.LocationID); err == nil {
+		sqlClause = append(
+			sqlClause,
+			"(st.from_location_id = $%[1]d or st.to_location_id = $%[1]d)",
+		)
+		params = append(params, locationID)
+	}
+
+	if status := StockTransferStatus(sstq.Status); status.IsValid() {
+		sqlClause = append(sqlClause, "st.status = $%d")
+		params = append(params, string(status))
+	}
+
+	return sqlClause, params
+}
+
+func (sstq SearchStockTransferQuery) BuildPagination() (string, []interface{}) {
+	return util.DefaultPaginationBuilder(sstq.Limit, sstq.Offset)
+}
+
+func (sstq SearchStockTransferQuery) BuildOrderByClause() []string {
+	return []string{
+		"st.created_at desc",
+		"st.id desc",
+	}
+}
@@ -0,0 +1,125 @@
+package model
+
+import (
+	"fmt"
+	"net/mail"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type Supplier struct {
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Name        string
+	PhoneNumber string
+	Email       string
+	Address     string
+	Notes       string
+	ID          uuid.UUID
+	CreatedBy   uuid.UUID
+}
+
+func (s Supplier) ToResponseBody() SupplierResponseBody {
+	return SupplierResponseBody{
+		ID:          s.ID.String(),
+		Name:        s.Name,
+		PhoneNumber: s.PhoneNumber,
+		Email:       s.Email,
+		Address:     s.Address,
+		Notes:       s.Notes,
+		CreatedAt:   util.ToISO8601(s.CreatedAt),
+		UpdatedAt:   util.ToISO8601(s.UpdatedAt),
+	}
+}
+
+type SupplierResponseBody struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	PhoneNumber string `json:"phoneNumber,omitempty"`
+	Email       string `json:"email,omitempty"`
+	Address     string `json:"address,omitempty"`
+	Notes       string `json:"notes,omitempty"`
+	CreatedAt   string `json:"createdAt"`
+	UpdatedAt   string `json:"updatedAt"`
+}
+
+// SupplierRequestBody creates or replaces a supplier, everything but the
+// name is optional.
+type SupplierRequestBody struct {
+	Name        string `json:"name"`
+	PhoneNumber string `json:"phoneNumber"`
+	Email       string `json:"email"`
+	Address     string `json:"address"`
+	Notes       string `json:"notes"`
+}
+
+func (body SupplierRequestBody) IsValid() bool {
+	if nameLen := len(body.Name); nameLen < 1 ||
+		nameLen > 100 {
+		return false
+	}
+
+	if body.PhoneNumber != "" &&
+		!util.ValidatePhoneNumber(body.PhoneNumber) {
+		return false
+	}
+
+	if body.Email != "" {
+		if len(body.Email) > 100 {
+			return false
+		}
+		if _, err := mail.ParseAddress(body.Email); err != nil {
+			return false
+		}
+	}
+
+	return len(body.Address) <= 200 &&
+		len(body.Notes) <= 200
+}
+
+func (body SupplierRequestBody) ToSupplier() Supplier {
+	return Supplier{
+		Name:        body.Name,
+		PhoneNumber: body.PhoneNumber,
+		Email:       body.Email,
+		Address:     body.Address,
+		Notes:       body.Notes,
+	}
+}
+
+type SearchSupplierQuery struct {
+	Name   string `query:"name"`
+	Limit  int    `query:"limit"`
+	Offset int    `query:"offset"`
+}
+
+func (ssq SearchSupplierQuery) IsValid() bool {
+	return ssq.Limit >= 0 && ssq.Offset >= 0
+}
+
+func (ssq SearchSupplierQuery) BuildWhereClauseAndParams() ([]string, []interface{}) {
+	var (
+		sqlClause []string
+		params    []interface{}
+	)
+
+	if ssq.Name != "" {
+		sqlClause = append(sqlClause, "sp.name ilike $%d")
+		params = append(params, fmt.Sprintf("%%%s%%", ssq.Name))
+	}
+
+	return sqlClause, params
+}
+
+func (ssq SearchSupplierQuery) BuildPagination() (string, []interface{}) {
+	return util.DefaultPaginationBuilder(ssq.Limit, ssq.Offset)
+}
+
+func (ssq SearchSupplierQuery) BuildOrderByClause() []string {
+	return []string{
+		"sp.name",
+		"sp.id",
+	}
+}
@@ -0,0 +1,113 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+// TaxRate overrides the store default tax rate for a single product or for
+// a whole category.
+type TaxRate struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Name      string
+	Category  ProductCategory
+	ProductID *uuid.UUID
+	// Rate is in percent
+	Rate      float64
+	ID        uuid.UUID
+	CreatedBy uuid.UUID
+}
+
+// ResolveTaxRate returns the rate of the product itself, else the rate of
+// its category, else defaultRate.
+func ResolveTaxRate(
+	rates []TaxRate,
+	product Product,
+	defaultRate float64,
+) float64 {
+	rate := defaultRate
+	for _, taxRate := range rates {
+		if taxRate.ProductID != nil &&
+			*taxRate.ProductID == product.ID {
+			return taxRate.Rate
+		}
+		if taxRate.Category != "" &&
+			taxRate.Category == product.Category {
+			rate = taxRate.Rate
+		}
+	}
+
+	return rate
+}
+
+func (tr TaxRate) ToResponseBody() TaxRateResponseBody {
+	body := TaxRateResponseBody{
+		ID:        tr.ID.String(),
+		Name:      tr.Name,
+		Category:  tr.Category,
+		Rate:      tr.Rate,
+		CreatedAt: util.ToISO8601(tr.CreatedAt),
+	}
+	if tr.ProductID != nil {
+		body.ProductID = tr.ProductID.String()
+	}
+
+	return body
+}
+
+type TaxRateResponseBody struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	ProductID string          `json:"productId,omitempty"`
+	Category  ProductCategory `json:"category,omitempty"`
+	CreatedAt string          `json:"createdAt"`
+	Rate      float64         `json:"rate"`
+}
+
+type TaxRateRequestBody struct {
+	Name      string          `json:"name"`
+	ProductID string          `json:"productId"`
+	Category  ProductCategory `json:"category"`
+	Rate      float64         `json:"rate"`
+}
+
+func (body TaxRateRequestBody) IsValid() bool {
+	if nameLen := len(body.Name); nameLen < 1 ||
+		nameLen > 100 {
+		return false
+	}
+
+	if body.Rate < 0 || body.Rate > 100 {
+		return false
+	}
+
+	// exactly one of product and category
+	if (body.ProductID == "") == (body.Category == "") {
+		return false
+	}
+
+	if body.ProductID != "" {
+		_, err := uuid.Parse(body.ProductID)
+		return err == nil
+	}
+
+	return body.Category.IsValid()
+}
+
+// ToTaxRate expects a body that passed IsValid.
+func (body TaxRateRequestBody) ToTaxRate() TaxRate {
+	taxRate := TaxRate{
+		Name:     body.Name,
+		Category: body.Category,
+		Rate:     body.Rate,
+	}
+	if body.ProductID != "" {
+		productID := uuid.MustParse(body.ProductID)
+		taxRate.ProductID = &productID
+	}
+
+	return taxRate
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"math"
+
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+// UnitOfMeasure is the unit a product is stocked and sold in. Quantities and
+// stock are in this unit.
+type UnitOfMeasure string
+
+const (
+	UnitPiece      UnitOfMeasure = "piece"
+	UnitKilogram   UnitOfMeasure = "kg"
+	UnitGram       UnitOfMeasure = "g"
+	UnitLitre      UnitOfMeasure = "l"
+	UnitMillilitre UnitOfMeasure = "ml"
+	UnitMetre      UnitOfMeasure = "m"
+)
+
+func (u UnitOfMeasure) IsValid() bool {
+	switch u {
+	case UnitPiece,
+		UnitKilogram,
+		UnitGram,
+		UnitLitre,
+		UnitMillilitre,
+		UnitMetre:
+		return true
+	default:
+		return false
+	}
+}
+
+// Decimals is how many decimals a quantity of the unit may have, 0 for the
+// units only sold whole.
+func (u UnitOfMeasure) Decimals() int {
+	switch u {
+	case UnitKilogram, UnitLitre:
+		return util.QuantityDecimals
+	case UnitMetre:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// IsValidQuantity reports whether quantity has no more decimals than the
+// unit allows.
+func (u UnitOfMeasure) IsValidQuantity(quantity float64) bool {
+	return math.Abs(
+		quantity-util.RoundQuantity(quantity, u.Decimals()),
+	) < 1e-9
+}
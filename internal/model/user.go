@@ -28,10 +28,6 @@ func (b RegisterBody) IsValid() bool {
 		return false
 	}
 
-	if phoneLength := len(b.PhoneNumber); phoneLength < 10 || phoneLength > 16 {
-		return false
-	}
-
 	if !util.ValidatePhoneNumber(b.PhoneNumber) {
 		return false
 	}
@@ -49,10 +45,6 @@ type LoginBody struct {
 }
 
 func (b LoginBody) IsValid() bool {
-	if phoneLength := len(b.PhoneNumber); phoneLength < 10 || phoneLength > 16 {
-		return false
-	}
-
 	if !util.ValidatePhoneNumber(b.PhoneNumber) {
 		return false
 	}
@@ -65,8 +57,10 @@ func (b LoginBody) IsValid() bool {
 }
 
 type User struct {
-	Name        string
-	Password    string
-	PhoneNumber string
-	ID          uuid.UUID
+	Name             string
+	Password         string
+	PhoneNumber      string
+	PhoneCountryCode string
+	PhoneRegion      string
+	ID               uuid.UUID
 }
@@ -0,0 +1,106 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/nozzlium/eniqilo_store/internal/config"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+)
+
+const (
+	Log     = "log"
+	Webhook = "webhook"
+)
+
+// Notifier sends stock alerts out of the app. An alert is sent again when
+// NotifyLowStock fails, receivers can tell repeats apart by its id.
+type Notifier interface {
+	NotifyLowStock(ctx context.Context, alert model.StockAlert) error
+}
+
+// New returns the notifier picked by ALERT_NOTIFIER.
+func New(cfg config.AlertConfig) (Notifier, error) {
+	switch cfg.Notifier {
+	case Log:
+		return LogNotifier{}, nil
+	case Webhook:
+		if cfg.WebhookURL == "" {
+			return nil, fmt.Errorf("ALERT_WEBHOOK_URL is required by the webhook notifier")
+		}
+		return NewWebhookNotifier(cfg.WebhookURL), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", cfg.Notifier)
+	}
+}
+
+// LogNotifier writes the alerts to the log.
+type LogNotifier struct{}
+
+func (LogNotifier) NotifyLowStock(
+	ctx context.Context,
+	alert model.StockAlert,
+) error {
+	log.Printf(
+		"low stock: %s (%s) has %g left, reorder point %g, reorder %g",
+		alert.Name,
+		alert.SKU,
+		alert.Stock,
+		alert.ReorderPoint,
+		alert.ReorderQuantity,
+	)
+
+	return nil
+}
+
+// WebhookNotifier posts the alerts as JSON to a URL, any status other than
+// 2xx is a failure.
+type WebhookNotifier struct {
+	client *http.Client
+	url    string
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		client: &http.Client{Timeout: 10 * time.Second},
+		url:    url,
+	}
+}
+
+func (n *WebhookNotifier) NotifyLowStock(
+	ctx context.Context,
+	alert model.StockAlert,
+) error {
+	body, err := json.Marshal(alert.ToEvent())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		n.url,
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", res.Status)
+	}
+
+	return nil
+}
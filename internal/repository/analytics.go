@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type AnalyticsRepository struct {
+	db *pgx.Conn
+}
+
+func NewAnalyticsRepository(
+	db *pgx.Conn,
+) *AnalyticsRepository {
+	return &AnalyticsRepository{db}
+}
+
+// RefreshRollups recomputes the hourly sales rollups. The refresh is
+// concurrent so that reports keep reading the previous rollups meanwhile.
+func (r *AnalyticsRepository) RefreshRollups(
+	ctx context.Context,
+) error {
+	for _, view := range []string{
+		"product_sales_hourly",
+		"sales_hourly",
+	} {
+		_, err := r.db.Exec(
+			ctx,
+			"refresh materialized view concurrently "+view,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *AnalyticsRepository) FindTopProducts(
+	ctx context.Context,
+	query model.TopProductsQuery,
+) ([]model.ProductSales, error) {
+	from, to := query.Bounds()
+	rows, err := r.db.Query(
+		ctx,
+		`
+    select
+      p.id,
+      p.name,
+      p.sku,
+      p.category::text,
+      sum(s.units),
+      sum(s.order_count),
+      sum(s.revenue)
+    from product_sales_hourly s
+    join products p on p.id = s.product_id
+    where s.sold_hour >= $1 and s.sold_hour < $2
+    group by p.id
+    order by `+strings.Join(query.BuildOrderByClause(), ", ")+`
+    limit $3`,
+		from,
+		to,
+		util.PageLimit(query.Limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]model.ProductSales, 0)
+	for rows.Next() {
+		var (
+			sales    model.ProductSales
+			category string
+		)
+		err := rows.Scan(
+			&sales.ProductID,
+			&sales.Name,
+			&sales.SKU,
+			&category,
+			&sales.Units,
+			&sales.OrderCount,
+			&sales.Revenue,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		sales.Category = sales.Category.FromDBEnumType(category)
+		products = append(products, sales)
+	}
+
+	return products, rows.Err()
+}
+
+// FindSlowMovers lists the products on sale without sales since the days
+// of the query started.
+func (r *AnalyticsRepository) FindSlowMovers(
+	ctx context.Context,
+	searchQuery model.SlowMoversQuery,
+) ([]model.SlowMover, error) {
+	var query bytes.Buffer
+	query.WriteString(`
+    select
+      p.id,
+      p.name,
+      p.sku,
+      p.category::text,
+      p.stock,
+      last.sold_hour
+    from products p
+    left join lateral (
+      select max(s.sold_hour) as sold_hour
+      from product_sales_hourly s
+      where s.product_id = p.id
+    ) last on true
+    where p.deleted_at is null`)
+
+	queryString, params := util.BuildQueryStringAndParams(
+		&query,
+		searchQuery.BuildWhereClauseAndParams,
+		searchQuery.BuildPagination,
+		searchQuery.BuildOrderByClause,
+	)
+
+	rows, err := r.db.Query(ctx, queryString, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]model.SlowMover, 0)
+	for rows.Next() {
+		var (
+			product    model.SlowMover
+			category   string
+			lastSoldAt *time.Time
+		)
+		err := rows.Scan(
+			&product.ProductID,
+			&product.Name,
+			&product.SKU,
+			&category,
+			&product.Stock,
+			&lastSoldAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		product.Category = product.Category.FromDBEnumType(category)
+		if lastSoldAt != nil {
+			product.LastSoldAt = *lastSoldAt
+		}
+		products = append(products, product)
+	}
+
+	return products, rows.Err()
+}
+
+// FindHeatmap returns every hour of the week, the ones without sales too.
+func (r *AnalyticsRepository) FindHeatmap(
+	ctx context.Context,
+	query model.DateRangeQuery,
+) ([]model.HeatmapCell, error) {
+	from, to := query.Bounds()
+	rows, err := r.db.Query(
+		ctx,
+		`
+    select
+      extract(isodow from s.sold_hour)::int,
+      extract(hour from s.sold_hour)::int,
+      sum(s.order_count),
+      sum(s.revenue)
+    from sales_hourly s
+    where s.sold_hour >= $1 and s.sold_hour < $2
+    group by 1, 2
+  `,
+		from,
+		to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cells := model.NewHeatmap()
+	for rows.Next() {
+		var cell model.HeatmapCell
+		err := rows.Scan(
+			&cell.Weekday,
+			&cell.Hour,
+			&cell.OrderCount,
+			&cell.Revenue,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		cells[(cell.Weekday-1)*24+cell.Hour] = cell
+	}
+
+	return cells, rows.Err()
+}
+
+func (r *AnalyticsRepository) FindCategoryTrends(
+	ctx context.Context,
+	query model.CategoryTrendQuery,
+) ([]model.CategoryTrend, error) {
+	from, to := query.Bounds()
+	rows, err := r.db.Query(
+		ctx,
+		`
+    select
+      date_trunc($1, s.sold_hour),
+      p.category::text,
+      sum(s.units),
+      sum(s.revenue)
+    from product_sales_hourly s
+    join products p on p.id = s.product_id
+    where s.sold_hour >= $2 and s.sold_hour < $3
+    group by 1, 2
+    order by 1, 2
+  `,
+		query.TruncateTo(),
+		from,
+		to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trends := make([]model.CategoryTrend, 0)
+	for rows.Next() {
+		var (
+			trend    model.CategoryTrend
+			category string
+		)
+		err := rows.Scan(
+			&trend.Period,
+			&category,
+			&trend.Units,
+			&trend.Revenue,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		trend.Category = trend.Category.FromDBEnumType(category)
+		trends = append(trends, trend)
+	}
+
+	return trends, rows.Err()
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// exactCountThreshold is the number of rows up to which totals are counted
+// exactly. Past it the planner estimate is used, so that a count never has
+// to walk a whole large table.
+const exactCountThreshold = 10000
+
+// countRows returns how many rows query yields and whether the number is an
+// estimate.
+func countRows(
+	ctx context.Context,
+	db *pgx.Conn,
+	query string,
+	params []interface{},
+) (int64, bool, error) {
+	var total int64
+	err := db.QueryRow(
+		ctx,
+		fmt.Sprintf(
+			"select count(*) from (%s limit %d) counted",
+			query,
+			exactCountThreshold+1,
+		),
+		params...,
+	).Scan(&total)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if total <= exactCountThreshold {
+		return total, false, nil
+	}
+
+	var plan []byte
+	err = db.QueryRow(
+		ctx,
+		fmt.Sprintf("explain (format json) %s", query),
+		params...,
+	).Scan(&plan)
+	if err != nil {
+		return 0, false, err
+	}
+
+	var explained []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &explained); err != nil {
+		return 0, false, err
+	}
+
+	if len(explained) == 0 ||
+		int64(explained[0].Plan.Rows) < total {
+		return total, true, nil
+	}
+
+	return int64(explained[0].Plan.Rows), true, nil
+}
@@ -0,0 +1,472 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type CouponRepository struct {
+	db *pgx.Conn
+}
+
+func NewCouponRepository(
+	db *pgx.Conn,
+) *CouponRepository {
+	return &CouponRepository{db}
+}
+
+const couponColumns = `
+      cp.id,
+      cp.code,
+      cp.type,
+      cp.value,
+      cp.min_basket_amount,
+      cp.max_uses,
+      cp.max_uses_per_customer,
+      cp.used_count,
+      cp.product_id,
+      cp.category,
+      cp.starts_at,
+      cp.ends_at,
+      cp.is_active,
+      cp.created_at,
+      cp.updated_at,
+      cp.created_by`
+
+func (r *CouponRepository) Save(
+	ctx context.Context,
+	coupon model.Coupon,
+) (model.Coupon, error) {
+	query := `
+    insert into coupons (
+      id,
+      code,
+      type,
+      value,
+      min_basket_amount,
+      max_uses,
+      max_uses_per_customer,
+      product_id,
+      category,
+      starts_at,
+      ends_at,
+      is_active,
+      created_at,
+      updated_at,
+      created_by
+    ) values (
+      $1, $2, $3, $4, $5, $6, $7, $8, nullif($9, '')::category, $10, $11,
+      $12, $13, $13, $14
+    )`
+
+	_, err := r.db.Exec(
+		ctx,
+		query,
+		coupon.ID,
+		coupon.Code,
+		string(coupon.Type),
+		coupon.Value,
+		coupon.MinBasketAmount,
+		coupon.MaxUses,
+		coupon.MaxUsesPerCustomer,
+		coupon.ProductID,
+		coupon.Category.ToDBEnumType(),
+		nullTime(coupon.StartsAt),
+		nullTime(coupon.EndsAt),
+		coupon.IsActive,
+		coupon.CreatedAt,
+		coupon.CreatedBy,
+	)
+	if err != nil {
+		return model.Coupon{}, err
+	}
+
+	return coupon, nil
+}
+
+// Update leaves used_count alone.
+func (r *CouponRepository) Update(
+	ctx context.Context,
+	coupon model.Coupon,
+) (model.Coupon, error) {
+	query := `
+    update coupons set
+      code = $1,
+      type = $2,
+      value = $3,
+      min_basket_amount = $4,
+      max_uses = $5,
+      max_uses_per_customer = $6,
+      product_id = $7,
+      category = nullif($8, '')::category,
+      starts_at = $9,
+      ends_at = $10,
+      is_active = $11,
+      updated_at = $12
+    where id = $13 and deleted_at is null`
+
+	tag, err := r.db.Exec(
+		ctx,
+		query,
+		coupon.Code,
+		string(coupon.Type),
+		coupon.Value,
+		coupon.MinBasketAmount,
+		coupon.MaxUses,
+		coupon.MaxUsesPerCustomer,
+		coupon.ProductID,
+		coupon.Category.ToDBEnumType(),
+		nullTime(coupon.StartsAt),
+		nullTime(coupon.EndsAt),
+		coupon.IsActive,
+		coupon.UpdatedAt,
+		coupon.ID,
+	)
+	if err != nil {
+		return model.Coupon{}, err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.Coupon{}, constant.ErrNotFound
+	}
+
+	return coupon, nil
+}
+
+func (r *CouponRepository) Delete(
+	ctx context.Context,
+	id uuid.UUID,
+	deletedAt time.Time,
+) error {
+	tag, err := r.db.Exec(
+		ctx,
+		`
+    update coupons set
+      deleted_at = $1,
+      updated_at = $1
+    where id = $2 and deleted_at is null
+  `,
+		deletedAt,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return constant.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *CouponRepository) FindByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (model.Coupon, error) {
+	return r.findOne(
+		ctx,
+		`cp.id = $1`,
+		id,
+	)
+}
+
+func (r *CouponRepository) FindByCode(
+	ctx context.Context,
+	code string,
+) (model.Coupon, error) {
+	return r.findOne(
+		ctx,
+		`upper(cp.code) = $1`,
+		model.NormalizeCouponCode(code),
+	)
+}
+
+func (r *CouponRepository) findOne(
+	ctx context.Context,
+	where string,
+	param interface{},
+) (model.Coupon, error) {
+	query := `
+    select` + couponColumns + `
+    from coupons cp
+    where ` + where + ` and cp.deleted_at is null`
+
+	coupon, err := scanCoupon(
+		r.db.QueryRow(ctx, query, param),
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Coupon{}, constant.ErrNotFound
+		}
+		return model.Coupon{}, err
+	}
+
+	return coupon, nil
+}
+
+func (r *CouponRepository) FindAll(
+	ctx context.Context,
+	searchQuery model.SearchCouponQuery,
+) ([]model.Coupon, error) {
+	var query bytes.Buffer
+	query.WriteString(`
+    select` + couponColumns + `
+    from coupons cp
+    where cp.deleted_at is null`)
+
+	queryString, params := util.BuildQueryStringAndParams(
+		&query,
+		searchQuery.BuildWhereClauseAndParams,
+		searchQuery.BuildPagination,
+		searchQuery.BuildOrderByClause,
+	)
+
+	rows, err := r.db.Query(ctx, queryString, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	coupons := make([]model.Coupon, 0)
+	for rows.Next() {
+		coupon, err := scanCoupon(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		coupons = append(coupons, coupon)
+	}
+
+	return coupons, rows.Err()
+}
+
+// lockCoupon locks the coupon of the redemption and checks its limits
+// again, so that concurrent checkouts can't use it more often than allowed.
+func lockCoupon(
+	ctx context.Context,
+	tx pgx.Tx,
+	redemption model.CouponRedemption,
+) error {
+	query := `
+    select` + couponColumns + `
+    from coupons cp
+    where cp.id = $1 and cp.deleted_at is null
+    for update`
+
+	coupon, err := scanCoupon(
+		tx.QueryRow(ctx, query, redemption.CouponID),
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return constant.ErrInvalidCoupon
+		}
+		return err
+	}
+
+	if !coupon.IsRunning(redemption.CreatedAt) {
+		return constant.ErrInvalidCoupon
+	}
+
+	if coupon.MaxUses != nil &&
+		coupon.UsedCount >= *coupon.MaxUses {
+		return constant.ErrCouponLimitReached
+	}
+
+	if coupon.MaxUsesPerCustomer != nil {
+		var customerUses int
+		err := tx.QueryRow(
+			ctx,
+			`
+    select count(*)
+    from coupon_redemptions
+    where coupon_id = $1 and customer_id = $2 and voided_at is null
+  `,
+			redemption.CouponID,
+			redemption.CustomerID,
+		).Scan(&customerUses)
+		if err != nil {
+			return err
+		}
+
+		if customerUses >= *coupon.MaxUsesPerCustomer {
+			return constant.ErrCouponLimitReached
+		}
+	}
+
+	return nil
+}
+
+// FindRedemptionsByCustomer is every use of a coupon by the customer, voided
+// ones included, newest first.
+func (r *CouponRepository) FindRedemptionsByCustomer(
+	ctx context.Context,
+	customerID uuid.UUID,
+) ([]model.CouponRedemption, error) {
+	rows, err := r.db.Query(
+		ctx,
+		`
+    select
+      cr.id,
+      cr.coupon_id,
+      cp.code,
+      cr.order_id,
+      cr.customer_id,
+      cr.discount_amount,
+      cr.created_at,
+      cr.voided_at
+    from coupon_redemptions cr
+    join coupons cp on cp.id = cr.coupon_id
+    where cr.customer_id = $1
+    order by cr.created_at desc, cr.id desc
+  `,
+		customerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	redemptions := make([]model.CouponRedemption, 0)
+	for rows.Next() {
+		var (
+			redemption model.CouponRedemption
+			voidedAt   *time.Time
+		)
+		err := rows.Scan(
+			&redemption.ID,
+			&redemption.CouponID,
+			&redemption.Code,
+			&redemption.OrderID,
+			&redemption.CustomerID,
+			&redemption.DiscountAmount,
+			&redemption.CreatedAt,
+			&voidedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if voidedAt != nil {
+			redemption.VoidedAt = *voidedAt
+		}
+
+		redemptions = append(redemptions, redemption)
+	}
+
+	return redemptions, rows.Err()
+}
+
+// queueCouponRedemption has to be queued after the order it belongs to.
+func queueCouponRedemption(
+	batch *pgx.Batch,
+	redemption model.CouponRedemption,
+) {
+	batch.Queue(
+		`
+    update coupons set
+      used_count = used_count + 1
+    where id = $1
+  `,
+		redemption.CouponID,
+	)
+	batch.Queue(
+		`
+    insert into coupon_redemptions (
+      id,
+      coupon_id,
+      order_id,
+      customer_id,
+      discount_amount,
+      created_at
+    ) values (
+      $1, $2, $3, $4, $5, $6
+    )
+  `,
+		redemption.ID,
+		redemption.CouponID,
+		redemption.OrderID,
+		redemption.CustomerID,
+		redemption.DiscountAmount,
+		redemption.CreatedAt,
+	)
+}
+
+// queueCouponRelease gives the use of a coupon back when its order is
+// voided.
+func queueCouponRelease(
+	batch *pgx.Batch,
+	orderID uuid.UUID,
+	voidedAt time.Time,
+) {
+	batch.Queue(
+		`
+    update coupons cp set
+      used_count = cp.used_count - 1
+    from coupon_redemptions cr
+    where cr.order_id = $1
+      and cr.voided_at is null
+      and cp.id = cr.coupon_id
+  `,
+		orderID,
+	)
+	batch.Queue(
+		`
+    update coupon_redemptions set
+      voided_at = $1
+    where order_id = $2 and voided_at is null
+  `,
+		voidedAt,
+		orderID,
+	)
+}
+
+func scanCoupon(row pgx.Row) (model.Coupon, error) {
+	var (
+		coupon           model.Coupon
+		couponType       string
+		category         *string
+		startsAt, endsAt *time.Time
+	)
+	err := row.Scan(
+		&coupon.ID,
+		&coupon.Code,
+		&couponType,
+		&coupon.Value,
+		&coupon.MinBasketAmount,
+		&coupon.MaxUses,
+		&coupon.MaxUsesPerCustomer,
+		&coupon.UsedCount,
+		&coupon.ProductID,
+		&category,
+		&startsAt,
+		&endsAt,
+		&coupon.IsActive,
+		&coupon.CreatedAt,
+		&coupon.UpdatedAt,
+		&coupon.CreatedBy,
+	)
+	if err != nil {
+		return model.Coupon{}, err
+	}
+
+	coupon.Type = model.CouponType(couponType)
+	if category != nil {
+		coupon.Category = coupon.Category.FromDBEnumType(*category)
+	}
+	if startsAt != nil {
+		coupon.StartsAt = *startsAt
+	}
+	if endsAt != nil {
+		coupon.EndsAt = *endsAt
+	}
+
+	return coupon, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const cursorFetchSize = 500
+
+// streamWithCursor runs query through a server side cursor and hands every
+// row to scan, fetching cursorFetchSize rows at a time so large result sets
+// never have to be held in memory. Exports stream after their handler has
+// returned, so the cursor gets a connection of its own, opened with the
+// settings of db and closed once the rows are read, leaving db free for the
+// other requests.
+func streamWithCursor(
+	ctx context.Context,
+	db *pgx.Conn,
+	query string,
+	params []interface{},
+	scan func(rows pgx.Rows) error,
+) error {
+	conn, err := pgx.ConnectConfig(ctx, db.Config().Copy())
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(
+		ctx,
+		fmt.Sprintf(
+			"declare export_cursor no scroll cursor for %s",
+			query,
+		),
+		params...,
+	)
+	if err != nil {
+		return err
+	}
+
+	fetchQuery := fmt.Sprintf(
+		"fetch forward %d from export_cursor",
+		cursorFetchSize,
+	)
+	for {
+		rows, err := tx.Query(ctx, fetchQuery)
+		if err != nil {
+			return err
+		}
+
+		fetched := 0
+		for rows.Next() {
+			fetched++
+			if err := scan(rows); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if fetched < cursorFetchSize {
+			break
+		}
+	}
+
+	_, err = tx.Exec(ctx, "close export_cursor")
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -1,15 +1,16 @@
 package repository
 
 import (
+	"bytes"
 	"context"
 	"errors"
-	"fmt"
-	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/nozzlium/eniqilo_store/internal/constant"
 	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/util"
 )
 
 type CustomerRepository struct {
@@ -30,15 +31,17 @@ func (repo *CustomerRepository) Save(
 ) (model.Customer, error) {
 	query := `
     insert into customers
-      (id, phone_number, name)
+      (id, phone_number, phone_country_code, phone_region, name)
     values
-      ($1, $2, $3);
+      ($1, $2, nullif($3, ''), $4, $5);
   `
 	_, err := repo.db.Exec(
 		ctx,
 		query,
 		customer.ID,
 		customer.PhoneNumber,
+		customer.PhoneCountryCode,
+		customer.PhoneRegion,
 		customer.Name,
 	)
 	if err != nil {
@@ -56,7 +59,7 @@ func (repo *CustomerRepository) FindByPhoneNumber(
     select 
       id, phone_number, name
     from customers
-      where phone_number = $1
+      where phone_number = $1 and deleted_at is null
   `
 
 	customer := model.Customer{}
@@ -86,7 +89,7 @@ func (repo *CustomerRepository) FindByID(
     select 
       id, phone_number, name
     from customers
-      where id = $1
+      where id = $1 and deleted_at is null
   `
 
 	customer := model.Customer{}
@@ -108,17 +111,258 @@ func (repo *CustomerRepository) FindByID(
 	return customer, nil
 }
 
-func (r *CustomerRepository) FindAllCustomers(
+// FindProfileByID also returns deleted and erased customers, so their data
+// can still be exported.
+func (repo *CustomerRepository) FindProfileByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (model.Customer, error) {
+	query := `
+    select 
+      id,
+      phone_number,
+      coalesce(phone_country_code, ''),
+      coalesce(phone_region, ''),
+      name,
+      created_at,
+      updated_at,
+      deleted_at,
+      erased_at
+    from customers
+      where id = $1
+  `
+
+	var (
+		customer  model.Customer
+		deletedAt *time.Time
+		erasedAt  *time.Time
+	)
+	err := repo.db.QueryRow(
+		ctx,
+		query,
+		id,
+	).Scan(
+		&customer.ID,
+		&customer.PhoneNumber,
+		&customer.PhoneCountryCode,
+		&customer.PhoneRegion,
+		&customer.Name,
+		&customer.CreatedAt,
+		&customer.UpdatedAt,
+		&deletedAt,
+		&erasedAt,
+	)
+	if err != nil {
+		if errors.Is(
+			err,
+			pgx.ErrNoRows,
+		) {
+			return customer, constant.ErrNotFound
+		}
+		return customer, err
+	}
+
+	if deletedAt != nil {
+		customer.DeletedAt = *deletedAt
+	}
+	if erasedAt != nil {
+		customer.ErasedAt = *erasedAt
+	}
+
+	return customer, nil
+}
+
+// FindMerges returns the merges the customer took part in, either as the
+// survivor or as the duplicate, newest first.
+func (repo *CustomerRepository) FindMerges(
+	ctx context.Context,
+	id uuid.UUID,
+) ([]model.CustomerMerge, error) {
+	query := `
+    select
+      id,
+      survivor_id,
+      duplicate_id,
+      duplicate_name,
+      duplicate_phone_number,
+      orders_moved,
+      merged_by,
+      created_at
+    from customer_merges
+      where survivor_id = $1 or duplicate_id = $1
+    order by created_at desc, id desc
+  `
+
+	rows, err := repo.db.Query(
+		ctx,
+		query,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	merges := make([]model.CustomerMerge, 0)
+	for rows.Next() {
+		var merge model.CustomerMerge
+		err := rows.Scan(
+			&merge.ID,
+			&merge.SurvivorID,
+			&merge.DuplicateID,
+			&merge.DuplicateName,
+			&merge.DuplicatePhoneNumber,
+			&merge.OrdersMoved,
+			&merge.MergedBy,
+			&merge.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		merges = append(merges, merge)
+	}
+
+	return merges, rows.Err()
+}
+
+func (repo *CustomerRepository) Update(
 	ctx context.Context,
 	customer model.Customer,
+) error {
+	query := `
+    update customers set
+      phone_number = $1,
+      phone_country_code = nullif($2, ''),
+      phone_region = $3,
+      name = $4,
+      updated_at = $5
+    where id = $6 and deleted_at is null
+  `
+	tag, err := repo.db.Exec(
+		ctx,
+		query,
+		customer.PhoneNumber,
+		customer.PhoneCountryCode,
+		customer.PhoneRegion,
+		customer.Name,
+		customer.UpdatedAt,
+		customer.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return constant.ErrNotFound
+	}
+
+	return nil
+}
+
+func (repo *CustomerRepository) Delete(
+	ctx context.Context,
+	id uuid.UUID,
+	deletedAt time.Time,
+) error {
+	query := `
+    update customers set
+      deleted_at = $1
+    where id = $2 and deleted_at is null
+  `
+	tag, err := repo.db.Exec(
+		ctx,
+		query,
+		deletedAt,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return constant.ErrNotFound
+	}
+
+	return nil
+}
+
+// Erase anonymizes the customer in place, along with the merges they took
+// part in. The row itself is kept so that orders.customer_id keeps pointing
+// at something for accounting.
+func (repo *CustomerRepository) Erase(
+	ctx context.Context,
+	id uuid.UUID,
+	erasedAt time.Time,
+) error {
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(
+		ctx,
+		`
+    update customers set
+      name = $1,
+      phone_number = '',
+      phone_country_code = null,
+      phone_region = null,
+      updated_at = $2,
+      deleted_at = coalesce(deleted_at, $2),
+      erased_at = coalesce(erased_at, $2)
+    where id = $3
+  `,
+		model.ErasedCustomerName,
+		erasedAt,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return constant.ErrNotFound
+	}
+
+	// the audit trail of merges keeps the name and number of the duplicate,
+	// which are the same person's whichever side of the merge was erased
+	_, err = tx.Exec(
+		ctx,
+		`
+    update customer_merges set
+      duplicate_name = $1,
+      duplicate_phone_number = ''
+    where survivor_id = $2 or duplicate_id = $2
+  `,
+		model.ErasedCustomerName,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *CustomerRepository) FindAllCustomers(
+	ctx context.Context,
+	searchQuery model.SearchCustomerQuery,
 ) ([]model.Customer, error) {
-	query, params := buildQuery(
-		customer,
+	var query bytes.Buffer
+	query.WriteString(customerSearchBaseQuery)
+
+	queryString, params := util.BuildQueryStringAndParams(
+		&query,
+		searchQuery.BuildWhereClauseAndParams,
+		searchQuery.BuildPagination,
+		searchQuery.BuildOrderByClause,
 	)
 
 	rows, err := r.db.Query(
 		ctx,
-		query,
+		queryString,
 		params...)
 	if err != nil {
 		return []model.Customer{}, err
@@ -132,6 +376,7 @@ func (r *CustomerRepository) FindAllCustomers(
 			&tempCust.ID,
 			&tempCust.PhoneNumber,
 			&tempCust.Name,
+			&tempCust.CreatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -143,63 +388,314 @@ func (r *CustomerRepository) FindAllCustomers(
 	return res, nil
 }
 
-func buildQuery(
-	customer model.Customer,
-) (string, []any) {
-	paramCounter := 0
-	paramQueries := make([]string, 0, 2)
-	params := make([]any, 0, 2)
-	base := `
+const customerSearchBaseQuery = `
     select 
-      id, 
-      phone_number, 
-      name
+      c.id, 
+      c.phone_number, 
+      c.name,
+      c.created_at
+    from customers c
+    where c.deleted_at is null`
+
+func (r *CustomerRepository) Count(
+	ctx context.Context,
+	searchQuery model.SearchCustomerQuery,
+) (int64, bool, error) {
+	var query bytes.Buffer
+	query.WriteString(`
+    select 1
+    from customers c
+    where c.deleted_at is null`)
+
+	// the total covers every page, not only the ones after the cursor
+	searchQuery.Cursor = ""
+	queryString, params := util.BuildQueryStringAndParamsWithoutLimit(
+		&query,
+		searchQuery.BuildWhereClauseAndParams,
+		func() []string { return nil },
+	)
+
+	return countRows(
+		ctx,
+		r.db,
+		queryString,
+		params,
+	)
+}
+
+func (r *CustomerRepository) Export(
+	ctx context.Context,
+	searchQuery model.SearchCustomerQuery,
+	fn func(customer model.Customer) error,
+) error {
+	var query bytes.Buffer
+	query.WriteString(customerSearchBaseQuery)
+
+	// exports always cover every matching customer
+	searchQuery.Cursor = ""
+	queryString, params := util.BuildQueryStringAndParamsWithoutLimit(
+		&query,
+		searchQuery.BuildWhereClauseAndParams,
+		searchQuery.BuildOrderByClause,
+	)
+
+	return streamWithCursor(
+		ctx,
+		r.db,
+		queryString,
+		params,
+		func(rows pgx.Rows) error {
+			var c model.Customer
+			err := rows.Scan(
+				&c.ID,
+				&c.PhoneNumber,
+				&c.Name,
+				&c.CreatedAt,
+			)
+			if err != nil {
+				return err
+			}
+
+			return fn(c)
+		},
+	)
+}
+
+// Merge moves every order, loyalty point and coupon use of the duplicate
+// to the survivor, deletes the duplicate and records the merge, all in one
+// transaction.
+func (repo *CustomerRepository) Merge(
+	ctx context.Context,
+	merge model.CustomerMerge,
+) (model.CustomerMerge, error) {
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return model.CustomerMerge{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	// lock both rows so neither can be changed or merged elsewhere meanwhile
+	rows, err := tx.Query(
+		ctx,
+		`
+    select id, name, phone_number
     from customers
-  `
+    where id = any($1::uuid[]) and deleted_at is null
+    order by id
+    for update
+  `,
+		[]uuid.UUID{merge.SurvivorID, merge.DuplicateID},
+	)
+	if err != nil {
+		return model.CustomerMerge{}, err
+	}
 
-	if customer.PhoneNumber != "" {
-		paramCounter++
-		paramQueries = append(
-			paramQueries,
-			fmt.Sprintf(
-				`phone_number like $%d || '%%'`,
-				paramCounter,
-			),
-		)
-		params = append(
-			params,
-			"+"+customer.PhoneNumber,
+	found := 0
+	for rows.Next() {
+		var customer model.Customer
+		err := rows.Scan(
+			&customer.ID,
+			&customer.Name,
+			&customer.PhoneNumber,
 		)
+		if err != nil {
+			rows.Close()
+			return model.CustomerMerge{}, err
+		}
+
+		found++
+		if customer.ID == merge.DuplicateID {
+			merge.DuplicateName = customer.Name
+			merge.DuplicatePhoneNumber = customer.PhoneNumber
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return model.CustomerMerge{}, err
 	}
 
-	if customer.Name != "" {
-		paramCounter++
-		paramQueries = append(
-			paramQueries,
-			fmt.Sprintf(
-				"name ilike '%%' || $%d || '%%'",
-				paramCounter,
-			),
+	if found != 2 {
+		return model.CustomerMerge{}, constant.ErrNotFound
+	}
+
+	tag, err := tx.Exec(
+		ctx,
+		`
+    update orders set
+      customer_id = $1,
+      updated_at = $2
+    where customer_id = $3
+  `,
+		merge.SurvivorID,
+		merge.CreatedAt,
+		merge.DuplicateID,
+	)
+	if err != nil {
+		return model.CustomerMerge{}, err
+	}
+	merge.OrdersMoved = int(tag.RowsAffected())
+
+	_, err = tx.Exec(
+		ctx,
+		`
+    update loyalty_point_transactions set
+      customer_id = $1
+    where customer_id = $2
+  `,
+		merge.SurvivorID,
+		merge.DuplicateID,
+	)
+	if err != nil {
+		return model.CustomerMerge{}, err
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`
+    update coupon_redemptions set
+      customer_id = $1
+    where customer_id = $2
+  `,
+		merge.SurvivorID,
+		merge.DuplicateID,
+	)
+	if err != nil {
+		return model.CustomerMerge{}, err
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`
+    update customers set
+      deleted_at = $1,
+      updated_at = $1
+    where id = $2
+  `,
+		merge.CreatedAt,
+		merge.DuplicateID,
+	)
+	if err != nil {
+		return model.CustomerMerge{}, err
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`
+    insert into customer_merges (
+      id,
+      survivor_id,
+      duplicate_id,
+      duplicate_name,
+      duplicate_phone_number,
+      orders_moved,
+      merged_by,
+      created_at
+    ) values (
+      $1, $2, $3, $4, $5, $6, $7, $8
+    )
+  `,
+		merge.ID,
+		merge.SurvivorID,
+		merge.DuplicateID,
+		merge.DuplicateName,
+		merge.DuplicatePhoneNumber,
+		merge.OrdersMoved,
+		merge.MergedBy,
+		merge.CreatedAt,
+	)
+	if err != nil {
+		return model.CustomerMerge{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return model.CustomerMerge{}, err
+	}
+
+	return merge, nil
+}
+
+const duplicateNameSimilarity = 0.6
+
+func (repo *CustomerRepository) FindDuplicateCandidates(
+	ctx context.Context,
+	limit int,
+) ([]model.DuplicateCustomerCandidate, error) {
+	// the phone pairs join on the digits expression index and the name
+	// pairs on the trigram index, an or of the two would compare every
+	// customer with every other
+	query := `
+    with pairs as (
+      select a.id as customer_id, b.id as duplicate_id
+      from customers a
+      join customers b
+        on regexp_replace(b.phone_number, '\D', '', 'g') =
+          regexp_replace(a.phone_number, '\D', '', 'g')
+        and b.deleted_at is null
+        and a.id < b.id
+      where a.deleted_at is null
+      union
+      select a.id, b.id
+      from customers a
+      join customers b
+        on b.name % a.name
+        and b.deleted_at is null
+        and a.id < b.id
+      where a.deleted_at is null
+        and similarity(a.name, b.name) >= $1
+    )
+    select
+      a.id,
+      a.name,
+      a.phone_number,
+      b.id,
+      b.name,
+      b.phone_number,
+      similarity(a.name, b.name) as name_similarity,
+      regexp_replace(a.phone_number, '\D', '', 'g') =
+        regexp_replace(b.phone_number, '\D', '', 'g') as same_phone
+    from pairs p
+    join customers a on a.id = p.customer_id
+    join customers b on b.id = p.duplicate_id
+    order by same_phone desc, name_similarity desc, a.id, b.id
+    limit $2
+  `
+
+	rows, err := repo.db.Query(
+		ctx,
+		query,
+		duplicateNameSimilarity,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := make([]model.DuplicateCustomerCandidate, 0, limit)
+	for rows.Next() {
+		var (
+			candidate               model.DuplicateCustomerCandidate
+			customerID, duplicateID uuid.UUID
 		)
-		params = append(
-			params,
-			customer.Name,
+		err := rows.Scan(
+			&customerID,
+			&candidate.Customer.Name,
+			&candidate.Customer.PhoneNumber,
+			&duplicateID,
+			&candidate.Duplicate.Name,
+			&candidate.Duplicate.PhoneNumber,
+			&candidate.NameSimilarity,
+			&candidate.SamePhone,
 		)
-	}
+		if err != nil {
+			return nil, err
+		}
 
-	if paramCounter == 0 {
-		return fmt.Sprintf(
-			"%s order by created_at desc;",
-			base,
-		), params
+		candidate.Customer.UserID = customerID.String()
+		candidate.Duplicate.UserID = duplicateID.String()
+		candidates = append(candidates, candidate)
 	}
 
-	return fmt.Sprintf(
-		"%s where %s order by created_at desc;",
-		base,
-		strings.Join(
-			paramQueries,
-			" and ",
-		),
-	), params
+	return candidates, rows.Err()
 }
@@ -0,0 +1,474 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type LocationRepository struct {
+	db *pgx.Conn
+}
+
+func NewLocationRepository(
+	db *pgx.Conn,
+) *LocationRepository {
+	return &LocationRepository{db}
+}
+
+const locationColumns = `
+      l.id,
+      l.name,
+      coalesce(l.address, ''),
+      l.is_default,
+      l.created_at,
+      l.updated_at`
+
+func (r *LocationRepository) Save(
+	ctx context.Context,
+	location model.Location,
+) (model.Location, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.Location{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if location.IsDefault {
+		err := unsetDefaultLocation(ctx, tx, location.CreatedAt)
+		if err != nil {
+			return model.Location{}, err
+		}
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`
+    insert into locations (
+      id,
+      name,
+      address,
+      is_default,
+      created_at,
+      updated_at
+    ) values (
+      $1, $2, nullif($3, ''), $4, $5, $6
+    )
+  `,
+		location.ID,
+		location.Name,
+		location.Address,
+		location.IsDefault,
+		location.CreatedAt,
+		location.UpdatedAt,
+	)
+	if err != nil {
+		if hasPgErrorCode(err, pgUniqueViolation) {
+			return model.Location{}, constant.ErrLocationExists
+		}
+		return model.Location{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return model.Location{}, err
+	}
+
+	return location, nil
+}
+
+func (r *LocationRepository) FindByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (model.Location, error) {
+	query := `
+    select` + locationColumns + `
+    from locations l
+    where l.id = $1 and l.deleted_at is null`
+
+	location, err := scanLocation(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Location{}, constant.ErrNotFound
+		}
+		return model.Location{}, err
+	}
+
+	return location, nil
+}
+
+func (r *LocationRepository) FindDefault(
+	ctx context.Context,
+) (model.Location, error) {
+	query := `
+    select` + locationColumns + `
+    from locations l
+    where l.is_default`
+
+	location, err := scanLocation(r.db.QueryRow(ctx, query))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Location{}, constant.ErrNotFound
+		}
+		return model.Location{}, err
+	}
+
+	return location, nil
+}
+
+func (r *LocationRepository) FindAll(
+	ctx context.Context,
+	searchQuery model.SearchLocationQuery,
+) ([]model.Location, error) {
+	var query bytes.Buffer
+	query.WriteString(`
+    select` + locationColumns + `
+    from locations l
+    where l.deleted_at is null`)
+
+	queryString, params := util.BuildQueryStringAndParams(
+		&query,
+		searchQuery.BuildWhereClauseAndParams,
+		searchQuery.BuildPagination,
+		searchQuery.BuildOrderByClause,
+	)
+
+	rows, err := r.db.Query(ctx, queryString, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	locations := make([]model.Location, 0)
+	for rows.Next() {
+		location, err := scanLocation(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		locations = append(locations, location)
+	}
+
+	return locations, rows.Err()
+}
+
+// Update replaces the location. The default location stays the default when
+// isDefault is left unset, it moves only by making another location the
+// default.
+func (r *LocationRepository) Update(
+	ctx context.Context,
+	location model.Location,
+) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if location.IsDefault {
+		err := unsetDefaultLocation(ctx, tx, location.UpdatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	tag, err := tx.Exec(
+		ctx,
+		`
+    update locations set
+      name = $1,
+      address = nullif($2, ''),
+      is_default = is_default or $3,
+      updated_at = $4
+    where id = $5 and deleted_at is null
+  `,
+		location.Name,
+		location.Address,
+		location.IsDefault,
+		location.UpdatedAt,
+		location.ID,
+	)
+	if err != nil {
+		if hasPgErrorCode(err, pgUniqueViolation) {
+			return constant.ErrLocationExists
+		}
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return constant.ErrNotFound
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Delete hides the location. The default location, one still holding stock
+// and one with transfers on the way to or from it cannot be deleted.
+func (r *LocationRepository) Delete(
+	ctx context.Context,
+	id uuid.UUID,
+	deletedAt time.Time,
+) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var inUse bool
+	err = tx.QueryRow(
+		ctx,
+		`
+    select
+      l.is_default
+      or exists (
+        select 1 from product_stocks ps
+        where ps.location_id = l.id and ps.stock > 0
+      )
+      or exists (
+        select 1 from stock_transfers st
+        where st.status = 'in_transit'
+          and (st.from_location_id = l.id or st.to_location_id = l.id)
+      )
+    from locations l
+    where l.id = $1 and l.deleted_at is null
+    for update
+  `,
+		id,
+	).Scan(&inUse)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return constant.ErrNotFound
+		}
+		return err
+	}
+
+	if inUse {
+		return constant.ErrLocationInUse
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`
+    update locations set
+      deleted_at = $1
+    where id = $2
+  `,
+		deletedAt,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// FindStocks lists the stock held at a location.
+func (r *LocationRepository) FindStocks(
+	ctx context.Context,
+	searchQuery model.SearchLocationStockQuery,
+) ([]model.LocationStock, error) {
+	var query bytes.Buffer
+	query.WriteString(`
+    select
+      ps.location_id,
+      l.name,
+      ps.product_id,
+      p.name,
+      p.sku,
+      ps.stock
+    from product_stocks ps
+    join locations l on l.id = ps.location_id
+    join products p on p.id = ps.product_id
+    where p.deleted_at is null`)
+
+	queryString, params := util.BuildQueryStringAndParams(
+		&query,
+		searchQuery.BuildWhereClauseAndParams,
+		searchQuery.BuildPagination,
+		searchQuery.BuildOrderByClause,
+	)
+
+	rows, err := r.db.Query(ctx, queryString, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanLocationStocks(rows)
+}
+
+// FindProductStocks is the stock of a product at every location, locations
+// without any of it included.
+func (r *LocationRepository) FindProductStocks(
+	ctx context.Context,
+	productID uuid.UUID,
+) ([]model.LocationStock, error) {
+	rows, err := r.db.Query(
+		ctx,
+		`
+    select
+      l.id,
+      l.name,
+      p.id,
+      p.name,
+      p.sku,
+      coalesce(ps.stock, 0)
+    from locations l
+    cross join products p
+    left join product_stocks ps
+      on ps.location_id = l.id and ps.product_id = p.id
+    where p.id = $1 and l.deleted_at is null
+    order by l.is_default desc, l.name, l.id
+  `,
+		productID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanLocationStocks(rows)
+}
+
+// FindStockLevels is the stock of the products at a location, products the
+// location never held are left out.
+func (r *LocationRepository) FindStockLevels(
+	ctx context.Context,
+	locationID uuid.UUID,
+	productIDs []uuid.UUID,
+) (map[uuid.UUID]float64, error) {
+	rows, err := r.db.Query(
+		ctx,
+		`
+    select product_id, stock
+    from product_stocks
+    where location_id = $1 and product_id = any($2::uuid[])
+  `,
+		locationID,
+		productIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	levels := make(map[uuid.UUID]float64, len(productIDs))
+	for rows.Next() {
+		var (
+			productID uuid.UUID
+			stock     float64
+		)
+		err := rows.Scan(&productID, &stock)
+		if err != nil {
+			return nil, err
+		}
+
+		levels[productID] = stock
+	}
+
+	return levels, rows.Err()
+}
+
+func unsetDefaultLocation(
+	ctx context.Context,
+	tx pgx.Tx,
+	at time.Time,
+) error {
+	_, err := tx.Exec(
+		ctx,
+		`
+    update locations set
+      is_default = false,
+      updated_at = $1
+    where is_default
+  `,
+		at,
+	)
+
+	return err
+}
+
+// queueLocationStock changes the stock of a product at a location and its
+// total by quantity, negative to take stock out. Stock falling below zero
+// at the location fails the batch with a check violation, see
+// mapStockError.
+func queueLocationStock(
+	batch *pgx.Batch,
+	productID uuid.UUID,
+	locationID uuid.UUID,
+	quantity float64,
+) {
+	batch.Queue(
+		`
+    insert into product_stocks (product_id, location_id)
+    values ($1, $2)
+    on conflict do nothing
+  `,
+		productID,
+		locationID,
+	)
+	batch.Queue(
+		`
+    update product_stocks set
+      stock = stock + $3
+    where product_id = $1 and location_id = $2
+  `,
+		productID,
+		locationID,
+		quantity,
+	)
+	batch.Queue(
+		`
+    update products set
+      stock = stock + $2
+    where id = $1
+  `,
+		productID,
+		quantity,
+	)
+}
+
+func scanLocationStocks(rows pgx.Rows) ([]model.LocationStock, error) {
+	defer rows.Close()
+
+	stocks := make([]model.LocationStock, 0)
+	for rows.Next() {
+		var stock model.LocationStock
+		err := rows.Scan(
+			&stock.LocationID,
+			&stock.LocationName,
+			&stock.ProductID,
+			&stock.ProductName,
+			&stock.SKU,
+			&stock.Stock,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		stocks = append(stocks, stock)
+	}
+
+	return stocks, rows.Err()
+}
+
+func scanLocation(row pgx.Row) (model.Location, error) {
+	var location model.Location
+	err := row.Scan(
+		&location.ID,
+		&location.Name,
+		&location.Address,
+		&location.IsDefault,
+		&location.CreatedAt,
+		&location.UpdatedAt,
+	)
+
+	return location, err
+}
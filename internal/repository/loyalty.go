@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type LoyaltyRepository struct {
+	db *pgx.Conn
+}
+
+func NewLoyaltyRepository(
+	db *pgx.Conn,
+) *LoyaltyRepository {
+	return &LoyaltyRepository{db}
+}
+
+func (r *LoyaltyRepository) GetBalance(
+	ctx context.Context,
+	customerID uuid.UUID,
+) (int, error) {
+	var balance int
+	err := r.db.QueryRow(
+		ctx,
+		`
+    select coalesce(sum(points), 0)
+    from loyalty_point_transactions
+    where customer_id = $1
+  `,
+		customerID,
+	).Scan(&balance)
+
+	return balance, err
+}
+
+const loyaltyTransactionBaseQuery = `
+    select
+      lpt.id,
+      lpt.customer_id,
+      lpt.order_id,
+      lpt.type,
+      lpt.points,
+      lpt.created_at
+    from loyalty_point_transactions lpt
+    where 1=1`
+
+func (r *LoyaltyRepository) FindTransactions(
+	ctx context.Context,
+	searchQuery model.SearchLoyaltyTransactionQuery,
+) ([]model.LoyaltyTransaction, error) {
+	var query bytes.Buffer
+	query.WriteString(loyaltyTransactionBaseQuery)
+
+	queryString, params := util.BuildQueryStringAndParams(
+		&query,
+		searchQuery.BuildWhereClauseAndParams,
+		searchQuery.BuildPagination,
+		searchQuery.BuildOrderByClause,
+	)
+
+	rows, err := r.db.Query(
+		ctx,
+		queryString,
+		params...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanLoyaltyTransactions(rows)
+}
+
+// FindAllTransactions is the whole ledger of the customer, newest first.
+func (r *LoyaltyRepository) FindAllTransactions(
+	ctx context.Context,
+	customerID uuid.UUID,
+) ([]model.LoyaltyTransaction, error) {
+	searchQuery := model.SearchLoyaltyTransactionQuery{
+		CustomerID: customerID,
+	}
+
+	var query bytes.Buffer
+	query.WriteString(loyaltyTransactionBaseQuery)
+
+	queryString, params := util.BuildQueryStringAndParamsWithoutLimit(
+		&query,
+		searchQuery.BuildWhereClauseAndParams,
+		searchQuery.BuildOrderByClause,
+	)
+
+	rows, err := r.db.Query(
+		ctx,
+		queryString,
+		params...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanLoyaltyTransactions(rows)
+}
+
+func scanLoyaltyTransactions(
+	rows pgx.Rows,
+) ([]model.LoyaltyTransaction, error) {
+	defer rows.Close()
+
+	transactions := make([]model.LoyaltyTransaction, 0)
+	for rows.Next() {
+		var transaction model.LoyaltyTransaction
+		err := rows.Scan(
+			&transaction.ID,
+			&transaction.CustomerID,
+			&transaction.OrderID,
+			&transaction.Type,
+			&transaction.Points,
+			&transaction.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, rows.Err()
+}
+
+// lockLoyaltyBalance locks the customer row so that two checkouts of the
+// same customer can't redeem the same points, then reads the balance.
+func lockLoyaltyBalance(
+	ctx context.Context,
+	tx pgx.Tx,
+	customerID uuid.UUID,
+) (int, error) {
+	_, err := tx.Exec(
+		ctx,
+		`select 1 from customers where id = $1 for update`,
+		customerID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var balance int
+	err = tx.QueryRow(
+		ctx,
+		`
+    select coalesce(sum(points), 0)
+    from loyalty_point_transactions
+    where customer_id = $1
+  `,
+		customerID,
+	).Scan(&balance)
+
+	return balance, err
+}
+
+func queueLoyaltyTransaction(
+	batch *pgx.Batch,
+	transaction model.LoyaltyTransaction,
+) {
+	batch.Queue(
+		`
+    insert into loyalty_point_transactions (
+      id,
+      customer_id,
+      order_id,
+      type,
+      points,
+      created_at
+    ) values (
+      $1, $2, $3, $4, $5, $6
+    )
+  `,
+		transaction.ID,
+		transaction.CustomerID,
+		transaction.OrderID,
+		string(transaction.Type),
+		transaction.Points,
+		transaction.CreatedAt,
+	)
+}
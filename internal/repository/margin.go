@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type MarginRepository struct {
+	db *pgx.Conn
+}
+
+func NewMarginRepository(
+	db *pgx.Conn,
+) *MarginRepository {
+	return &MarginRepository{db}
+}
+
+// marginLines are the lines of the orders created between $1 and $2 that
+// were not voided, revenue is taken without the tax inclusive prices hold.
+// Bundles are counted as the components they were allocated to.
+const marginLines = `
+    with lines as (
+      select
+        op.product_id,
+        o.created_at,
+        op.quantity,
+        op.net_price - case
+          when o.prices_include_tax then op.tax_amount
+          else 0
+        end as revenue,
+        op.quantity * op.cost as cost
+      from orders o
+      join order_sales_lines op on op.order_id = o.id
+      where o.created_at >= $1 and o.created_at < $2
+        and o.voided_at is null
+    )`
+
+func (r *MarginRepository) FindByProduct(
+	ctx context.Context,
+	query model.ProductMarginQuery,
+) ([]model.ProductMargin, error) {
+	from, to := query.Bounds()
+	pagination, paginationParams := util.DefaultPaginationBuilder(
+		query.Limit,
+		query.Offset,
+	)
+	rows, err := r.db.Query(
+		ctx,
+		marginLines+`
+    select
+      p.id,
+      p.name,
+      p.sku,
+      p.category::text,
+      sum(l.quantity),
+      sum(l.revenue),
+      sum(l.cost)
+    from lines l
+    join products p on p.id = l.product_id
+    where $3::text = '' or p.category::text = $3
+    group by p.id
+    order by `+strings.Join(query.BuildOrderByClause(), ", ")+
+			fmt.Sprintf(pagination, 4, 5),
+		append(
+			[]interface{}{
+				from,
+				to,
+				model.ProductCategory(query.Category).ToDBEnumType(),
+			},
+			paginationParams...,
+		)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	margins := make([]model.ProductMargin, 0)
+	for rows.Next() {
+		var (
+			margin   model.ProductMargin
+			category string
+		)
+		err := rows.Scan(
+			&margin.ProductID,
+			&margin.Name,
+			&margin.SKU,
+			&category,
+			&margin.Units,
+			&margin.Revenue,
+			&margin.Cost,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		margin.Category = margin.Category.FromDBEnumType(category)
+		margins = append(margins, margin)
+	}
+
+	return margins, rows.Err()
+}
+
+func (r *MarginRepository) FindByCategory(
+	ctx context.Context,
+	query model.DateRangeQuery,
+) ([]model.CategoryMargin, error) {
+	from, to := query.Bounds()
+	rows, err := r.db.Query(
+		ctx,
+		marginLines+`
+    select
+      p.category::text,
+      sum(l.quantity),
+      sum(l.revenue),
+      sum(l.cost)
+    from lines l
+    join products p on p.id = l.product_id
+    group by p.category
+    order by sum(l.revenue) - sum(l.cost) desc`,
+		from,
+		to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	margins := make([]model.CategoryMargin, 0)
+	for rows.Next() {
+		var (
+			margin   model.CategoryMargin
+			category string
+		)
+		err := rows.Scan(
+			&category,
+			&margin.Units,
+			&margin.Revenue,
+			&margin.Cost,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		margin.Category = margin.Category.FromDBEnumType(category)
+		margins = append(margins, margin)
+	}
+
+	return margins, rows.Err()
+}
+
+func (r *MarginRepository) FindTrend(
+	ctx context.Context,
+	query model.MarginTrendQuery,
+) ([]model.PeriodMargin, error) {
+	from, to := query.Bounds()
+	rows, err := r.db.Query(
+		ctx,
+		marginLines+`
+    select
+      date_trunc($3, l.created_at),
+      sum(l.quantity),
+      sum(l.revenue),
+      sum(l.cost)
+    from lines l
+    join products p on p.id = l.product_id
+    where $4::text = '' or p.category::text = $4
+    group by 1
+    order by 1`,
+		from,
+		to,
+		query.TruncateTo(),
+		model.ProductCategory(query.Category).ToDBEnumType(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	margins := make([]model.PeriodMargin, 0)
+	for rows.Next() {
+		var margin model.PeriodMargin
+		err := rows.Scan(
+			&margin.Period,
+			&margin.Units,
+			&margin.Revenue,
+			&margin.Cost,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		margins = append(margins, margin)
+	}
+
+	return margins, rows.Err()
+}
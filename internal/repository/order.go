@@ -5,9 +5,11 @@ import (
 	"context"
 	"errors"
 	"log"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
 	"github.com/nozzlium/eniqilo_store/internal/model"
 	"github.com/nozzlium/eniqilo_store/internal/util"
 )
@@ -25,7 +27,6 @@ func NewOrderRepository(
 func (r *OrderRepository) Save(
 	ctx context.Context,
 	order model.Order,
-	products []model.Product,
 ) (model.Order, error) {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
@@ -33,6 +34,37 @@ func (r *OrderRepository) Save(
 	}
 	defer tx.Rollback(ctx)
 
+	if order.PointsRedeemed > 0 {
+		balance, err := lockLoyaltyBalance(
+			ctx,
+			tx,
+			order.CustomerID,
+		)
+		if err != nil {
+			return model.Order{}, err
+		}
+
+		if balance < order.PointsRedeemed {
+			return model.Order{}, constant.ErrInsufficientPoints
+		}
+	}
+
+	if order.ShiftID != nil {
+		err := lockOpenShift(ctx, tx, *order.ShiftID)
+		if err != nil {
+			return model.Order{}, err
+		}
+	}
+
+	var couponID *uuid.UUID
+	if order.CouponRedemption != nil {
+		err := lockCoupon(ctx, tx, *order.CouponRedemption)
+		if err != nil {
+			return model.Order{}, err
+		}
+		couponID = &order.CouponRedemption.CouponID
+	}
+
 	batch := &pgx.Batch{}
 
 	// create order entity
@@ -43,9 +75,25 @@ func (r *OrderRepository) Save(
         customer_id,
         total_price,
         payment_amount,
-        change
+        change,
+        points_redeemed,
+        points_amount,
+        points_earned,
+        gross_price,
+        discount_amount,
+        coupon_id,
+        coupon_discount,
+        subtotal,
+        tax_amount,
+        prices_include_tax,
+        created_by,
+        shift_id,
+        location_id,
+        created_at,
+        updated_at
     ) values (
-      $1, $2, $3, $4, $5
+      $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15,
+      $16, $17, $18, $19, $19
     );
   `
 	batch.Queue(
@@ -55,6 +103,20 @@ func (r *OrderRepository) Save(
 		order.TotalPrice,
 		order.PaymentAmount,
 		order.Change,
+		order.PointsRedeemed,
+		order.PointsAmount,
+		order.PointsEarned,
+		order.GrossPrice,
+		order.DiscountAmount,
+		couponID,
+		order.CouponDiscount,
+		order.Subtotal,
+		order.TaxAmount,
+		order.PricesIncludeTax,
+		order.CreatedBy,
+		order.ShiftID,
+		order.LocationID,
+		order.CreatedAt,
 	)
 
 	// create order_product entity
@@ -66,9 +128,31 @@ func (r *OrderRepository) Save(
         order_id,
         product_id,
         quantity,
-        price
+        price,
+        discount_amount,
+        promotion_id,
+        tax_rate,
+        tax_amount,
+        cost,
+        is_bundle,
+        coupon_discount
     ) values (
-      $1, $2, $3, $4
+      $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+    )
+  `
+	queryOrderProductComponent := `
+    insert into
+      order_product_components (
+        order_id,
+        bundle_id,
+        product_id,
+        quantity,
+        net_price,
+        tax_amount,
+        cost,
+        coupon_discount
+    ) values (
+      $1, $2, $3, $4, $5, $6, $7, $8
     )
   `
 	for _, orderProduct := range order.ProductOrders {
@@ -78,29 +162,97 @@ func (r *OrderRepository) Save(
 			orderProduct.ProductID,
 			orderProduct.Quantity,
 			orderProduct.Price,
+			orderProduct.DiscountAmount,
+			orderProduct.PromotionID,
+			orderProduct.TaxRate,
+			orderProduct.TaxAmount,
+			orderProduct.Cost,
+			orderProduct.Components != nil,
+			orderProduct.CouponDiscount,
+		)
+		for _, component := range orderProduct.Components {
+			batch.Queue(
+				queryOrderProductComponent,
+				orderProduct.OrderID,
+				orderProduct.ProductID,
+				component.ProductID,
+				component.Quantity,
+				component.NetPrice,
+				component.TaxAmount,
+				component.Cost,
+				component.CouponDiscount,
+			)
+		}
+	}
+
+	// take the stock from the location the order was rung up at, a bundle
+	// takes its components
+	productIDs := make([]uuid.UUID, 0, len(order.ProductOrders))
+	for _, orderProduct := range order.ProductOrders {
+		if orderProduct.Components != nil {
+			for _, component := range orderProduct.Components {
+				queueLocationStock(
+					batch,
+					component.ProductID,
+					order.LocationID,
+					-component.Quantity,
+				)
+				productIDs = append(productIDs, component.ProductID)
+			}
+			continue
+		}
+
+		queueLocationStock(
+			batch,
+			orderProduct.ProductID,
+			order.LocationID,
+			-orderProduct.Quantity,
 		)
+		productIDs = append(productIDs, orderProduct.ProductID)
 	}
+	queueLowStockAlerts(batch, productIDs, order.CreatedAt)
 
-	// update product stock
-	queryUpdateStock := `
-    update products 
-    set stock = $1 
-    where id = $2;
+	queryPayment := `
+    insert into
+      order_payments (
+        id,
+        order_id,
+        method,
+        amount,
+        tendered_amount,
+        reference,
+        created_at
+    ) values (
+      $1, $2, $3, $4, $5, nullif($6, ''), $7
+    )
   `
-	for _, product := range products {
+	for _, payment := range order.Payments {
 		batch.Queue(
-			queryUpdateStock,
-			product.Stock,
-			product.ID,
+			queryPayment,
+			payment.ID,
+			payment.OrderID,
+			string(payment.Method),
+			payment.Amount,
+			payment.TenderedAmount,
+			payment.Reference,
+			payment.CreatedAt,
 		)
 	}
 
+	for _, transaction := range order.LoyaltyTransactions {
+		queueLoyaltyTransaction(batch, transaction)
+	}
+
+	if order.CouponRedemption != nil {
+		queueCouponRedemption(batch, *order.CouponRedemption)
+	}
+
 	batchRes := tx.SendBatch(
 		ctx,
 		batch,
 	)
 	if err := batchRes.Close(); err != nil {
-		return model.Order{}, err
+		return model.Order{}, mapStockError(err)
 	}
 
 	err = tx.Commit(ctx)
@@ -111,6 +263,142 @@ func (r *OrderRepository) Save(
 	return order, nil
 }
 
+// Void puts the stock of the order back and reverses the loyalty points it
+// earned or used. The order itself is kept and only marked as voided.
+func (r *OrderRepository) Void(
+	ctx context.Context,
+	orderVoid model.OrderVoid,
+	reversalID uuid.UUID,
+) (model.OrderVoid, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.OrderVoid{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var voidedAt *time.Time
+	err = tx.QueryRow(
+		ctx,
+		`
+    select customer_id, voided_at
+    from orders
+    where id = $1
+    for update
+  `,
+		orderVoid.ID,
+	).Scan(
+		&orderVoid.CustomerID,
+		&voidedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.OrderVoid{}, constant.ErrNotFound
+		}
+		return model.OrderVoid{}, err
+	}
+
+	if voidedAt != nil {
+		return model.OrderVoid{}, constant.ErrOrderVoided
+	}
+
+	// the balance may go negative when earned points were already spent,
+	// the customer then earns their way back to zero
+	var netPoints int
+	err = tx.QueryRow(
+		ctx,
+		`
+    select coalesce(sum(points), 0)
+    from loyalty_point_transactions
+    where order_id = $1
+  `,
+		orderVoid.ID,
+	).Scan(&netPoints)
+	if err != nil {
+		return model.OrderVoid{}, err
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(
+		`
+    insert into product_stocks (product_id, location_id, stock)
+    select
+      op.product_id,
+      coalesce(
+        o.location_id,
+        (select id from locations where is_default)
+      ),
+      sum(op.quantity)
+    from orders o
+    join order_sales_lines op on op.order_id = o.id
+    where o.id = $1
+    group by 1, 2
+    on conflict (product_id, location_id) do update set
+      stock = product_stocks.stock + excluded.stock
+  `,
+		orderVoid.ID,
+	)
+	// a product sold on its own and in a bundle is on more than one line,
+	// an update from a join would only add one of them
+	batch.Queue(
+		`
+    update products p set
+      stock = p.stock + op.quantity
+    from (
+      select product_id, sum(quantity) as quantity
+      from order_sales_lines
+      where order_id = $1
+      group by product_id
+    ) op
+    where p.id = op.product_id
+  `,
+		orderVoid.ID,
+	)
+	queueOrderLowStockRearm(batch, orderVoid.ID)
+
+	if netPoints != 0 {
+		orderVoid.PointsReversed = -netPoints
+		queueLoyaltyTransaction(
+			batch,
+			model.LoyaltyTransaction{
+				ID:         reversalID,
+				CustomerID: orderVoid.CustomerID,
+				OrderID:    &orderVoid.ID,
+				Type:       model.LoyaltyReversal,
+				Points:     orderVoid.PointsReversed,
+				CreatedAt:  orderVoid.VoidedAt,
+			},
+		)
+	}
+
+	queueCouponRelease(batch, orderVoid.ID, orderVoid.VoidedAt)
+
+	batch.Queue(
+		`
+    update orders set
+      voided_at = $1,
+      updated_at = $1
+    where id = $2
+  `,
+		orderVoid.VoidedAt,
+		orderVoid.ID,
+	)
+
+	batchRes := tx.SendBatch(
+		ctx,
+		batch,
+	)
+	if err := batchRes.Close(); err != nil {
+		return model.OrderVoid{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return model.OrderVoid{}, err
+	}
+
+	return orderVoid, nil
+}
+
 func (r *OrderRepository) GetOrderIDs(
 	ctx context.Context,
 	searchQuery model.SearchOrderQuery,
@@ -159,6 +447,39 @@ func (r *OrderRepository) GetOrderIDs(
 	return ids, nil
 }
 
+func (r *OrderRepository) GetOrderIDsByCustomerID(
+	ctx context.Context,
+	customerID uuid.UUID,
+) ([]uuid.UUID, error) {
+	query := `
+    select o.id
+    from orders o
+    where o.customer_id = $1
+    order by o.created_at desc, o.id desc`
+
+	rows, err := r.db.Query(
+		ctx,
+		query,
+		customerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return ids, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
 func (r *OrderRepository) Search(
 	ctx context.Context,
 	searchQuery model.SearchOrderDetailQuery,
@@ -168,13 +489,36 @@ func (r *OrderRepository) Search(
     select
       o.id,
       o.customer_id,
+      o.gross_price,
+      o.discount_amount,
+      coalesce(cp.code, ''),
+      o.coupon_discount,
+      o.subtotal,
+      o.tax_amount,
+      o.prices_include_tax,
+      o.total_price,
       o.payment_amount,
       o.change,
+      o.points_redeemed,
+      o.points_amount,
+      o.points_earned,
+      o.created_by,
+      o.shift_id,
+      o.location_id,
       o.created_at,
+      o.voided_at,
       op.product_id,
-      op.quantity
+      op.quantity,
+      op.price,
+      op.total_price,
+      op.discount_amount,
+      op.coupon_discount,
+      op.promotion_id,
+      op.tax_rate,
+      op.tax_amount
     from orders o
     join order_product op on o.id = op.order_id
+    left join coupons cp on cp.id = o.coupon_id
     where 1=1`)
 
 	queryString, params := util.BuildQueryStringAndParamsWithoutLimit(
@@ -208,49 +552,369 @@ func (r *OrderRepository) Search(
 	defer rows.Close()
 	for rows.Next() {
 		var (
-			o         model.Order
-			quantity  int
-			productID uuid.UUID
+			o          model.Order
+			line       model.ProductOrder
+			locationID *uuid.UUID
+			voidedAt   *time.Time
 		)
 
 		err := rows.Scan(
 			&o.ID,
 			&o.CustomerID,
+			&o.GrossPrice,
+			&o.DiscountAmount,
+			&o.CouponCode,
+			&o.CouponDiscount,
+			&o.Subtotal,
+			&o.TaxAmount,
+			&o.PricesIncludeTax,
+			&o.TotalPrice,
 			&o.PaymentAmount,
 			&o.Change,
+			&o.PointsRedeemed,
+			&o.PointsAmount,
+			&o.PointsEarned,
+			&o.CreatedBy,
+			&o.ShiftID,
+			&locationID,
 			&o.CreatedAt,
-			&productID,
-			&quantity,
+			&voidedAt,
+			&line.ProductID,
+			&line.Quantity,
+			&line.Price,
+			&line.TotalPrice,
+			&line.DiscountAmount,
+			&line.CouponDiscount,
+			&line.PromotionID,
+			&line.TaxRate,
+			&line.TaxAmount,
 		)
 		if err != nil {
 			return orderMap, orders, err
 		}
+		if voidedAt != nil {
+			o.VoidedAt = *voidedAt
+		}
+		if locationID != nil {
+			o.LocationID = *locationID
+		}
+
+		orders = groupOrderLine(orderMap, orders, o, line)
+	}
 
-		om, ok := orderMap[o.ID]
-		if !ok {
-			orders = append(
-				orders,
-				o.ID,
+	// the connection is free for the next query only once rows are closed
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return orderMap, orders, err
+	}
+
+	err = r.findPayments(ctx, orderMap, orders)
+	if err != nil {
+		return orderMap, orders, err
+	}
+
+	log.Printf("orderMap: %v \n", orderMap)
+	return orderMap, orders, nil
+}
+
+// groupOrderLine adds a row of the order/line join to orderMap. The first
+// row of an order keeps its header and later rows only add their line, which
+// has to go on the lines gathered so far and not on the fresh row's empty
+// ones, or every order comes back with its last line only.
+func groupOrderLine(
+	orderMap map[uuid.UUID]model.Order,
+	orders []uuid.UUID,
+	o model.Order,
+	line model.ProductOrder,
+) []uuid.UUID {
+	om, ok := orderMap[o.ID]
+	if !ok {
+		orders = append(
+			orders,
+			o.ID,
+		)
+		om = o
+		om.ProductOrders = make(
+			[]model.ProductOrder,
+			0,
+		)
+	}
+
+	line.OrderID = o.ID
+	om.ProductOrders = append(
+		om.ProductOrders,
+		line,
+	)
+
+	orderMap[o.ID] = om
+	return orders
+}
+
+// findPayments adds the tenders of the orders to orderMap, they are
+// queried apart so that they don't multiply the order lines.
+func (r *OrderRepository) findPayments(
+	ctx context.Context,
+	orderMap map[uuid.UUID]model.Order,
+	orderIDs []uuid.UUID,
+) error {
+	if len(orderIDs) == 0 {
+		return nil
+	}
+
+	rows, err := r.db.Query(
+		ctx,
+		`
+    select
+      id,
+      order_id,
+      method,
+      amount,
+      tendered_amount,
+      coalesce(reference, ''),
+      created_at
+    from order_payments
+    where order_id = any($1::uuid[])
+    order by created_at, id
+  `,
+		orderIDs,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			payment model.OrderPayment
+			method  string
+		)
+		err := rows.Scan(
+			&payment.ID,
+			&payment.OrderID,
+			&method,
+			&payment.Amount,
+			&payment.TenderedAmount,
+			&payment.Reference,
+			&payment.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		payment.Method = model.PaymentMethod(method)
+		order := orderMap[payment.OrderID]
+		order.Payments = append(order.Payments, payment)
+		orderMap[payment.OrderID] = order
+	}
+
+	return rows.Err()
+}
+
+func (r *OrderRepository) Export(
+	ctx context.Context,
+	searchQuery model.SearchOrderQuery,
+	fn func(row model.OrderExportRow) error,
+) error {
+	var query bytes.Buffer
+	query.WriteString(`
+    select
+      o.id,
+      o.customer_id,
+      c.name,
+      op.product_id,
+      p.name,
+      p.sku,
+      op.quantity,
+      op.price,
+      op.total_price,
+      op.discount_amount,
+      op.net_price,
+      op.tax_amount,
+      o.gross_price,
+      o.discount_amount,
+      o.subtotal,
+      o.tax_amount,
+      o.total_price,
+      o.payment_amount,
+      o.change,
+      o.created_at
+    from orders o
+    join order_product op on o.id = op.order_id
+    join customers c on c.id = o.customer_id
+    join products p on p.id = op.product_id
+    where 1=1`)
+
+	queryString, params := util.BuildQueryStringAndParamsWithoutLimit(
+		&query,
+		searchQuery.BuildWhereClauseAndParams,
+		searchQuery.BuildOrderByClause,
+	)
+
+	return streamWithCursor(
+		ctx,
+		r.db,
+		queryString,
+		params,
+		func(rows pgx.Rows) error {
+			var (
+				row                            model.OrderExportRow
+				orderID, customerID, productID uuid.UUID
+				createdAt                      time.Time
 			)
-			om = o
-			om.ProductOrders = make(
-				[]model.ProductOrder,
-				0,
+			err := rows.Scan(
+				&orderID,
+				&customerID,
+				&row.CustomerName,
+				&productID,
+				&row.ProductName,
+				&row.SKU,
+				&row.Quantity,
+				&row.Price,
+				&row.ItemTotal,
+				&row.ItemDiscount,
+				&row.ItemNet,
+				&row.ItemTax,
+				&row.OrderGross,
+				&row.OrderDiscount,
+				&row.OrderSubtotal,
+				&row.OrderTax,
+				&row.OrderTotal,
+				&row.PaymentAmount,
+				&row.Change,
+				&createdAt,
 			)
-		}
+			if err != nil {
+				return err
+			}
 
-		om.ProductOrders = append(
-			o.ProductOrders,
-			model.ProductOrder{
-				OrderID:   o.ID,
-				ProductID: productID,
-				Quantity:  quantity,
-			},
+			row.OrderID = orderID.String()
+			row.CustomerID = customerID.String()
+			row.ProductID = productID.String()
+			row.CreatedAt = util.ToISO8601(createdAt)
+			return fn(row)
+		},
+	)
+}
+
+func (r *OrderRepository) Count(
+	ctx context.Context,
+	searchQuery model.SearchOrderQuery,
+) (int64, bool, error) {
+	var query bytes.Buffer
+	query.WriteString(`
+    select 1
+    from orders o
+    where 1=1`)
+
+	// the total covers every page, not only the ones after the cursor
+	searchQuery.Cursor = ""
+	queryString, params := util.BuildQueryStringAndParamsWithoutLimit(
+		&query,
+		searchQuery.BuildWhereClauseAndParams,
+		func() []string { return nil },
+	)
+
+	return countRows(
+		ctx,
+		r.db,
+		queryString,
+		params,
+	)
+}
+
+func (r *OrderRepository) GetCustomerStats(
+	ctx context.Context,
+	customerID uuid.UUID,
+) (model.CustomerStats, error) {
+	query := `
+    select
+      count(*),
+      coalesce(sum(o.total_price), 0),
+      coalesce(avg(o.total_price), 0),
+      min(o.created_at),
+      max(o.created_at)
+    from orders o
+    where o.customer_id = $1 and o.voided_at is null`
+
+	var (
+		stats           model.CustomerStats
+		firstPurchaseAt *time.Time
+		lastPurchaseAt  *time.Time
+	)
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		customerID,
+	).Scan(
+		&stats.OrderCount,
+		&stats.LifetimeSpend,
+		&stats.AverageBasket,
+		&firstPurchaseAt,
+		&lastPurchaseAt,
+	)
+	if err != nil {
+		return stats, err
+	}
+
+	if firstPurchaseAt != nil {
+		stats.FirstPurchaseAt = *firstPurchaseAt
+	}
+	if lastPurchaseAt != nil {
+		stats.LastPurchaseAt = *lastPurchaseAt
+	}
+
+	return stats, nil
+}
+
+func (r *OrderRepository) GetCustomerTopProducts(
+	ctx context.Context,
+	customerID uuid.UUID,
+	limit int,
+) ([]model.CustomerTopProduct, error) {
+	query := `
+    select
+      op.product_id,
+      p.name,
+      sum(op.quantity) as quantity,
+      sum(op.net_price)
+    from orders o
+    join order_product op on o.id = op.order_id
+    join products p on p.id = op.product_id
+    where o.customer_id = $1 and o.voided_at is null
+    group by op.product_id, p.name
+    order by quantity desc, op.product_id
+    limit $2`
+
+	rows, err := r.db.Query(
+		ctx,
+		query,
+		customerID,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]model.CustomerTopProduct, 0, limit)
+	for rows.Next() {
+		var (
+			product   model.CustomerTopProduct
+			productID uuid.UUID
 		)
+		err := rows.Scan(
+			&productID,
+			&product.Name,
+			&product.Quantity,
+			&product.TotalSpend,
+		)
+		if err != nil {
+			return nil, err
+		}
 
-		orderMap[o.ID] = om
+		product.ProductID = productID.String()
+		products = append(products, product)
 	}
 
-	log.Printf("orderMap: %v \n", orderMap)
-	return orderMap, orders, nil
+	return products, rows.Err()
 }
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+)
+
+func TestGroupOrderLineKeepsEveryLine(t *testing.T) {
+	first := model.Order{ID: uuid.New()}
+	second := model.Order{ID: uuid.New()}
+	rows := []struct {
+		order model.Order
+		line  model.ProductOrder
+	}{
+		{first, model.ProductOrder{ProductID: uuid.New(), Quantity: 1}},
+		{first, model.ProductOrder{ProductID: uuid.New(), Quantity: 2}},
+		{second, model.ProductOrder{ProductID: uuid.New(), Quantity: 3}},
+		{first, model.ProductOrder{ProductID: uuid.New(), Quantity: 4}},
+	}
+
+	orderMap := make(map[uuid.UUID]model.Order)
+	var orders []uuid.UUID
+	for _, row := range rows {
+		orders = groupOrderLine(orderMap, orders, row.order, row.line)
+	}
+
+	if len(orders) != 2 || orders[0] != first.ID || orders[1] != second.ID {
+		t.Fatalf("orders = %v, want [%s %s]", orders, first.ID, second.ID)
+	}
+
+	lines := orderMap[first.ID].ProductOrders
+	if len(lines) != 3 {
+		t.Fatalf("first order has %d lines, want 3", len(lines))
+	}
+	for i, want := range []float64{1, 2, 4} {
+		if lines[i].Quantity != want {
+			t.Errorf("line %d quantity = %v, want %v", i, lines[i].Quantity, want)
+		}
+		if lines[i].OrderID != first.ID {
+			t.Errorf("line %d order id = %s, want %s", i, lines[i].OrderID, first.ID)
+		}
+	}
+
+	if n := len(orderMap[second.ID].ProductOrders); n != 1 {
+		t.Fatalf("second order has %d lines, want 1", n)
+	}
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+)
+
+const (
+	pgNotNullViolation = "23502"
+	pgUniqueViolation  = "23505"
+	pgCheckViolation   = "23514"
+)
+
+func hasPgErrorCode(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
+
+// mapStockError turns a stock falling below zero at a location into
+// ErrInsufficientStock, it is caught by the check of product_stocks when two
+// sales race for the last of it.
+func mapStockError(err error) error {
+	if hasPgErrorCode(err, pgCheckViolation) {
+		return constant.ErrInsufficientStock
+	}
+
+	return err
+}
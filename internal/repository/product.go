@@ -35,12 +35,19 @@ func (r *ProductRepository) Search(
 			sku,
 			category,
 			image_url,
-			stock,
+			` + model.AvailableStockColumn + `,
 			notes,
 			price,
 			location, 
 			is_available,
-			created_at
+			is_bundle,
+			unit,
+			created_at,
+			`)
+	query.WriteString(
+		searchQuery.BuildScoreColumn(searchQuery.SearchParamIndex()),
+	)
+	query.WriteString(`
     from products p 
     where deleted_at is null`)
 
@@ -83,7 +90,10 @@ func (r *ProductRepository) Search(
 			&p.Price,
 			&p.Location,
 			&p.IsAvailable,
+			&p.IsBundle,
+			&p.Unit,
 			&p.CreatedAt,
+			&p.Score,
 		)
 		if err != nil {
 			return nil, err
@@ -114,13 +124,25 @@ func (r *ProductRepository) Save(
     image_url,
     is_available,
     location,
+    reorder_point,
+    reorder_quantity,
+    cost,
     created_at,
     updated_at,
-    created_by
+    created_by,
+    is_bundle,
+    unit
   ) values 
-  ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+  ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`
 
-	_, err := r.db.Exec(ctx, query,
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &pgx.Batch{}
+	batch.Queue(query,
 		product.ID,
 		product.Name,
 		product.SKU,
@@ -131,37 +153,116 @@ func (r *ProductRepository) Save(
 		product.ImageURL,
 		product.IsAvailable,
 		product.Location,
+		product.ReorderPoint,
+		product.ReorderQuantity,
+		product.Cost,
 		product.CreatedAt,
 		product.UpdatedAt,
 		product.CreatedBy,
+		product.IsBundle,
+		string(product.Unit),
 	)
-	if err != nil {
+
+	if product.IsBundle {
+		queueBundleComponents(batch, product.ID, product.Components)
+	} else {
+		// the stock a product is created with is at the default location
+		batch.Queue(
+			`
+    insert into product_stocks (product_id, location_id, stock)
+    select $1, id, $2
+    from locations
+    where is_default
+  `,
+			product.ID,
+			product.Stock,
+		)
+	}
+
+	batchRes := tx.SendBatch(
+		ctx,
+		batch,
+	)
+	if err := batchRes.Close(); err != nil {
 		return err
 	}
 
-	return nil
+	return tx.Commit(ctx)
 }
 
 func (r *ProductRepository) Update(
 	ctx context.Context,
 	product model.Product,
 ) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		stock             float64
+		defaultLocationID uuid.UUID
+	)
+	err = tx.QueryRow(
+		ctx,
+		`
+    select p.stock, l.id
+    from products p
+    join locations l on l.is_default
+    where p.id = $1
+    for update of p
+  `,
+		product.ID,
+	).Scan(
+		&stock,
+		&defaultLocationID,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return constant.ErrNotFound
+		}
+		return err
+	}
+
+	batch := &pgx.Batch{}
+
+	// a stock set by hand is counted at the default location, the other
+	// locations keep theirs
+	delta := util.RoundQuantity(product.Stock-stock, util.QuantityDecimals)
+	if delta != 0 {
+		queueLocationStock(
+			batch,
+			product.ID,
+			defaultLocationID,
+			delta,
+		)
+	}
+
 	query := `
   update products set
     name = $1,
     sku = $2,
     price = $3,
-    stock = $4,
     notes = $5,
     category = $6,
     image_url = $7,
     is_available = $8,
     location = $9,
     updated_at = $10,
-    updated_by = $11
+    updated_by = $11,
+    reorder_point = $13,
+    reorder_quantity = $14,
+    cost = $15,
+    unit = $16,
+    -- stock back above the reorder point can alert again
+    low_stock_alerted_at = case
+      when $4 > $13 then null
+      else low_stock_alerted_at
+    end
   where id = $12`
 
-	_, err := r.db.Exec(ctx, query,
+	batch.Queue(query,
 		product.Name,
 		product.SKU,
 		product.Price,
@@ -174,12 +275,32 @@ func (r *ProductRepository) Update(
 		product.UpdatedAt,
 		product.UpdatedBy,
 		product.ID,
+		product.ReorderPoint,
+		product.ReorderQuantity,
+		product.Cost,
+		string(product.Unit),
 	)
-	if err != nil {
-		return err
+
+	if product.Components != nil {
+		batch.Queue(
+			`
+    delete from product_bundle_components
+    where bundle_id = $1
+  `,
+			product.ID,
+		)
+		queueBundleComponents(batch, product.ID, product.Components)
 	}
 
-	return nil
+	batchRes := tx.SendBatch(
+		ctx,
+		batch,
+	)
+	if err := batchRes.Close(); err != nil {
+		return mapStockError(err)
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (r *ProductRepository) Delete(
@@ -220,11 +341,13 @@ func (r *ProductRepository) FindBySKU(
 			sku,
 			category,
 			image_url,
-			stock,
+			` + model.AvailableStockColumn + `,
 			notes,
 			price,
 			location, 
-			is_available
+			is_available,
+			is_bundle,
+			unit
     from products p 
     where sku = $1 and deleted_at is null`
 
@@ -244,6 +367,8 @@ func (r *ProductRepository) FindBySKU(
 		&p.Price,
 		&p.Location,
 		&p.IsAvailable,
+		&p.IsBundle,
+		&p.Unit,
 	)
 	if err != nil {
 		if errors.Is(
@@ -276,11 +401,16 @@ func (r *ProductRepository) FindByID(
 			sku,
 			category,
 			image_url,
-			stock,
+			` + model.AvailableStockColumn + `,
 			notes,
 			price,
 			location, 
-			is_available
+			is_available,
+			reorder_point,
+			reorder_quantity,
+			cost,
+			is_bundle,
+			unit
     from products p 
     where id = $1 and deleted_at is null`
 
@@ -295,6 +425,11 @@ func (r *ProductRepository) FindByID(
 		&p.Price,
 		&p.Location,
 		&p.IsAvailable,
+		&p.ReorderPoint,
+		&p.ReorderQuantity,
+		&p.Cost,
+		&p.IsBundle,
+		&p.Unit,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -306,6 +441,15 @@ func (r *ProductRepository) FindByID(
 	p.Category = p.Category.FromDBEnumType(
 		category,
 	)
+	p.ID = id
+
+	if p.IsBundle {
+		components, err := r.FindBundleComponents(ctx, []uuid.UUID{id})
+		if err != nil {
+			return p, err
+		}
+		p.Components = components[id]
+	}
 
 	return p, nil
 }
@@ -320,7 +464,11 @@ func (r *ProductRepository) FindByIds(
       name, 
       stock, 
       price, 
-      is_available 
+      is_available,
+      category,
+      cost,
+      is_bundle,
+      unit
     from products
     where id = any($1::uuid[])
     and deleted_at is null
@@ -342,13 +490,20 @@ func (r *ProductRepository) FindByIds(
 		map[uuid.UUID]model.Product,
 	)
 	for rows.Next() {
-		temp := model.Product{}
+		var (
+			temp     model.Product
+			category string
+		)
 		err = rows.Scan(
 			&temp.ID,
 			&temp.Name,
 			&temp.Stock,
 			&temp.Price,
 			&temp.IsAvailable,
+			&category,
+			&temp.Cost,
+			&temp.IsBundle,
+			&temp.Unit,
 		)
 		if err != nil {
 			if errors.Is(err, pgx.ErrNoRows) {
@@ -357,8 +512,184 @@ func (r *ProductRepository) FindByIds(
 			return nil, err
 		}
 
+		temp.Category = temp.Category.FromDBEnumType(category)
 		res[temp.ID] = temp
 	}
 
 	return res, nil
 }
+
+// FindBundleComponents is what each of the bundles is made of, with the
+// current price and cost of the components. Deleted components are kept so
+// that the bundle can no longer be sold.
+func (r *ProductRepository) FindBundleComponents(
+	ctx context.Context,
+	bundleIDs []uuid.UUID,
+) (map[uuid.UUID][]model.BundleComponent, error) {
+	rows, err := r.db.Query(
+		ctx,
+		`
+    select
+      pbc.bundle_id,
+      pbc.component_id,
+      pbc.quantity,
+      p.name,
+      p.price,
+      p.cost,
+      p.deleted_at is not null
+    from product_bundle_components pbc
+    join products p on p.id = pbc.component_id
+    where pbc.bundle_id = any($1::uuid[])
+    order by pbc.bundle_id, p.name, pbc.component_id
+  `,
+		bundleIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	components := make(
+		map[uuid.UUID][]model.BundleComponent,
+		len(bundleIDs),
+	)
+	for rows.Next() {
+		var (
+			bundleID  uuid.UUID
+			component model.BundleComponent
+		)
+		err := rows.Scan(
+			&bundleID,
+			&component.ProductID,
+			&component.Quantity,
+			&component.Name,
+			&component.Price,
+			&component.Cost,
+			&component.IsDeleted,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		components[bundleID] = append(components[bundleID], component)
+	}
+
+	return components, rows.Err()
+}
+
+func queueBundleComponents(
+	batch *pgx.Batch,
+	bundleID uuid.UUID,
+	components []model.BundleComponent,
+) {
+	for _, component := range components {
+		batch.Queue(
+			`
+    insert into product_bundle_components (
+      bundle_id,
+      component_id,
+      quantity
+    ) values (
+      $1, $2, $3
+    )
+  `,
+			bundleID,
+			component.ProductID,
+			component.Quantity,
+		)
+	}
+}
+
+func (r *ProductRepository) Export(
+	ctx context.Context,
+	searchQuery model.SearchProductQuery,
+	fn func(product model.Product) error,
+) error {
+	var query bytes.Buffer
+	query.WriteString(`
+    select
+			id,
+			name,
+			sku,
+			category,
+			image_url,
+			` + model.AvailableStockColumn + `,
+			notes,
+			price,
+			location, 
+			is_available,
+			is_bundle,
+			unit,
+			created_at
+    from products p 
+    where deleted_at is null`)
+
+	queryString, params := util.BuildQueryStringAndParamsWithoutLimit(
+		&query,
+		searchQuery.BuildWhereClauseAndParams,
+		searchQuery.BuildOrderByClause,
+	)
+
+	return streamWithCursor(
+		ctx,
+		r.db,
+		queryString,
+		params,
+		func(rows pgx.Rows) error {
+			var (
+				p        model.Product
+				category string
+			)
+
+			err := rows.Scan(
+				&p.ID,
+				&p.Name,
+				&p.SKU,
+				&category,
+				&p.ImageURL,
+				&p.Stock,
+				&p.Notes,
+				&p.Price,
+				&p.Location,
+				&p.IsAvailable,
+				&p.IsBundle,
+				&p.Unit,
+				&p.CreatedAt,
+			)
+			if err != nil {
+				return err
+			}
+
+			p.Category = p.Category.FromDBEnumType(
+				category,
+			)
+			return fn(p)
+		},
+	)
+}
+
+func (r *ProductRepository) Count(
+	ctx context.Context,
+	searchQuery model.SearchProductQuery,
+) (int64, bool, error) {
+	var query bytes.Buffer
+	query.WriteString(`
+    select 1
+    from products p 
+    where deleted_at is null`)
+
+	// the total covers every page, not only the ones after the cursor
+	searchQuery.Cursor = ""
+	queryString, params := util.BuildQueryStringAndParamsWithoutLimit(
+		&query,
+		searchQuery.BuildWhereClauseAndParams,
+		func() []string { return nil },
+	)
+
+	return countRows(
+		ctx,
+		r.db,
+		queryString,
+		params,
+	)
+}
@@ -0,0 +1,361 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+)
+
+type ProductImageRepository struct {
+	db *pgx.Conn
+}
+
+func NewProductImageRepository(
+	db *pgx.Conn,
+) *ProductImageRepository {
+	return &ProductImageRepository{db}
+}
+
+const productImageColumns = `
+      pi.id,
+      pi.product_id,
+      pi.position,
+      pi.url,
+      pi.thumbnail_url,
+      pi.storage_key,
+      pi.thumbnail_key,
+      pi.content_type,
+      pi.size,
+      pi.width,
+      pi.height,
+      pi.created_at,
+      pi.created_by`
+
+// Save adds the image after the other images of the product, it becomes
+// the image of the product when it is the first. It fails with
+// ErrTooManyImages once the product has MaxProductImages.
+func (r *ProductImageRepository) Save(
+	ctx context.Context,
+	image model.ProductImage,
+) (model.ProductImage, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.ProductImage{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = lockProductImages(ctx, tx, image.ProductID)
+	if err != nil {
+		return model.ProductImage{}, err
+	}
+
+	err = tx.QueryRow(
+		ctx,
+		`
+    select count(*)
+    from product_images
+    where product_id = $1
+  `,
+		image.ProductID,
+	).Scan(&image.Position)
+	if err != nil {
+		return model.ProductImage{}, err
+	}
+
+	if image.Position >= model.MaxProductImages {
+		return model.ProductImage{}, constant.ErrTooManyImages
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(
+		`
+    insert into product_images (
+      id,
+      product_id,
+      position,
+      url,
+      thumbnail_url,
+      storage_key,
+      thumbnail_key,
+      content_type,
+      size,
+      width,
+      height,
+      created_at,
+      created_by
+    ) values (
+      $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+    )
+  `,
+		image.ID,
+		image.ProductID,
+		image.Position,
+		image.URL,
+		image.ThumbnailURL,
+		image.StorageKey,
+		image.ThumbnailKey,
+		image.ContentType,
+		image.Size,
+		image.Width,
+		image.Height,
+		image.CreatedAt,
+		image.CreatedBy,
+	)
+	queueProductImageURL(batch, image.ProductID)
+
+	batchRes := tx.SendBatch(
+		ctx,
+		batch,
+	)
+	if err := batchRes.Close(); err != nil {
+		return model.ProductImage{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return model.ProductImage{}, err
+	}
+
+	return image, nil
+}
+
+func (r *ProductImageRepository) FindByProduct(
+	ctx context.Context,
+	productID uuid.UUID,
+) ([]model.ProductImage, error) {
+	rows, err := r.db.Query(
+		ctx,
+		`
+    select`+productImageColumns+`
+    from product_images pi
+    where pi.product_id = $1
+    order by pi.position, pi.id
+  `,
+		productID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanProductImages(rows)
+}
+
+// Reorder puts the images of the product in the order of ids, which has to
+// hold every one of them, and makes the first the image of the product.
+func (r *ProductImageRepository) Reorder(
+	ctx context.Context,
+	productID uuid.UUID,
+	ids []uuid.UUID,
+) ([]model.ProductImage, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = lockProductImages(ctx, tx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := tx.Exec(
+		ctx,
+		`
+    update product_images set
+      position = array_position($2::uuid[], id) - 1
+    where product_id = $1
+  `,
+		productID,
+		ids,
+	)
+	if err != nil {
+		// an image of the product left out of ids gets a null position
+		if hasPgErrorCode(err, pgNotNullViolation) {
+			return nil, constant.ErrBadInput
+		}
+		return nil, err
+	}
+
+	// ids of images that are not the product's
+	if tag.RowsAffected() != int64(len(ids)) {
+		return nil, constant.ErrBadInput
+	}
+
+	batch := &pgx.Batch{}
+	queueProductImageURL(batch, productID)
+	batchRes := tx.SendBatch(
+		ctx,
+		batch,
+	)
+	if err := batchRes.Close(); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(
+		ctx,
+		`
+    select`+productImageColumns+`
+    from product_images pi
+    where pi.product_id = $1
+    order by pi.position, pi.id
+  `,
+		productID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := scanProductImages(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return images, tx.Commit(ctx)
+}
+
+// Delete removes the image and closes the gap it leaves in the order. The
+// removed image is returned so that its files can be deleted too.
+func (r *ProductImageRepository) Delete(
+	ctx context.Context,
+	productID uuid.UUID,
+	id uuid.UUID,
+) (model.ProductImage, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.ProductImage{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = lockProductImages(ctx, tx, productID)
+	if err != nil {
+		return model.ProductImage{}, err
+	}
+
+	image, err := scanProductImage(tx.QueryRow(
+		ctx,
+		`
+    delete from product_images pi
+    where pi.id = $1 and pi.product_id = $2
+    returning`+productImageColumns,
+		id,
+		productID,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ProductImage{}, constant.ErrNotFound
+		}
+		return model.ProductImage{}, err
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(
+		`
+    update product_images set
+      position = position - 1
+    where product_id = $1 and position > $2
+  `,
+		productID,
+		image.Position,
+	)
+	queueProductImageURL(batch, productID)
+
+	batchRes := tx.SendBatch(
+		ctx,
+		batch,
+	)
+	if err := batchRes.Close(); err != nil {
+		return model.ProductImage{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return model.ProductImage{}, err
+	}
+
+	return image, nil
+}
+
+// lockProductImages locks the product so that changes to the order of its
+// images go one at a time. Deleted products fail with ErrNotFound.
+func lockProductImages(
+	ctx context.Context,
+	tx pgx.Tx,
+	productID uuid.UUID,
+) error {
+	var id uuid.UUID
+	err := tx.QueryRow(
+		ctx,
+		`
+    select id
+    from products
+    where id = $1 and deleted_at is null
+    for update
+  `,
+		productID,
+	).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return constant.ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// queueProductImageURL makes the first image of the product its image, a
+// product left without images keeps the one it had.
+func queueProductImageURL(batch *pgx.Batch, productID uuid.UUID) {
+	batch.Queue(
+		`
+    update products p set
+      image_url = pi.url
+    from product_images pi
+    where p.id = $1
+      and pi.product_id = p.id
+      and pi.position = 0
+  `,
+		productID,
+	)
+}
+
+func scanProductImages(rows pgx.Rows) ([]model.ProductImage, error) {
+	defer rows.Close()
+
+	images := make([]model.ProductImage, 0)
+	for rows.Next() {
+		image, err := scanProductImage(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		images = append(images, image)
+	}
+
+	return images, rows.Err()
+}
+
+func scanProductImage(row pgx.Row) (model.ProductImage, error) {
+	var image model.ProductImage
+	err := row.Scan(
+		&image.ID,
+		&image.ProductID,
+		&image.Position,
+		&image.URL,
+		&image.ThumbnailURL,
+		&image.StorageKey,
+		&image.ThumbnailKey,
+		&image.ContentType,
+		&image.Size,
+		&image.Width,
+		&image.Height,
+		&image.CreatedAt,
+		&image.CreatedBy,
+	)
+
+	return image, err
+}
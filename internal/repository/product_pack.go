@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+)
+
+type ProductPackRepository struct {
+	db *pgx.Conn
+}
+
+func NewProductPackRepository(
+	db *pgx.Conn,
+) *ProductPackRepository {
+	return &ProductPackRepository{db}
+}
+
+const productPackColumns = `
+      pp.id,
+      pp.product_id,
+      pp.name,
+      pp.sku,
+      pp.quantity,
+      pp.created_at`
+
+func (r *ProductPackRepository) Save(
+	ctx context.Context,
+	pack model.ProductPack,
+) (model.ProductPack, error) {
+	_, err := r.db.Exec(
+		ctx,
+		`
+    insert into product_packs (
+      id,
+      product_id,
+      name,
+      sku,
+      quantity,
+      created_at
+    ) values (
+      $1, $2, $3, $4, $5, $6
+    )
+  `,
+		pack.ID,
+		pack.ProductID,
+		pack.Name,
+		pack.SKU,
+		pack.Quantity,
+		pack.CreatedAt,
+	)
+	if err != nil {
+		return model.ProductPack{}, err
+	}
+
+	return pack, nil
+}
+
+func (r *ProductPackRepository) FindByProduct(
+	ctx context.Context,
+	productID uuid.UUID,
+) ([]model.ProductPack, error) {
+	rows, err := r.db.Query(
+		ctx,
+		`
+    select`+productPackColumns+`
+    from product_packs pp
+    where pp.product_id = $1 and pp.deleted_at is null
+    order by pp.quantity, pp.id
+  `,
+		productID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	packs := make([]model.ProductPack, 0)
+	for rows.Next() {
+		pack, err := scanProductPack(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		packs = append(packs, pack)
+	}
+
+	return packs, rows.Err()
+}
+
+// FindByIDs is the packs with the ids, deleted ones left out.
+func (r *ProductPackRepository) FindByIDs(
+	ctx context.Context,
+	ids []uuid.UUID,
+) (map[uuid.UUID]model.ProductPack, error) {
+	rows, err := r.db.Query(
+		ctx,
+		`
+    select`+productPackColumns+`
+    from product_packs pp
+    where pp.id = any($1::uuid[]) and pp.deleted_at is null
+  `,
+		ids,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	packs := make(map[uuid.UUID]model.ProductPack, len(ids))
+	for rows.Next() {
+		pack, err := scanProductPack(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		packs[pack.ID] = pack
+	}
+
+	return packs, rows.Err()
+}
+
+func (r *ProductPackRepository) Delete(
+	ctx context.Context,
+	productID uuid.UUID,
+	id uuid.UUID,
+	deletedAt time.Time,
+) error {
+	tag, err := r.db.Exec(
+		ctx,
+		`
+    update product_packs set
+      deleted_at = $1
+    where id = $2 and product_id = $3 and deleted_at is null
+  `,
+		deletedAt,
+		id,
+		productID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return constant.ErrNotFound
+	}
+
+	return nil
+}
+
+func scanProductPack(row pgx.Row) (model.ProductPack, error) {
+	var pack model.ProductPack
+	err := row.Scan(
+		&pack.ID,
+		&pack.ProductID,
+		&pack.Name,
+		&pack.SKU,
+		&pack.Quantity,
+		&pack.CreatedAt,
+	)
+
+	return pack, err
+}
@@ -0,0 +1,319 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type PromotionRepository struct {
+	db *pgx.Conn
+}
+
+func NewPromotionRepository(
+	db *pgx.Conn,
+) *PromotionRepository {
+	return &PromotionRepository{db}
+}
+
+const promotionColumns = `
+      pr.id,
+      pr.name,
+      pr.type,
+      pr.value,
+      pr.buy_quantity,
+      pr.get_quantity,
+      pr.product_id,
+      pr.category,
+      pr.starts_at,
+      pr.ends_at,
+      pr.is_active,
+      pr.created_at,
+      pr.updated_at,
+      pr.created_by`
+
+func (r *PromotionRepository) Save(
+	ctx context.Context,
+	promotion model.Promotion,
+) (model.Promotion, error) {
+	query := `
+    insert into promotions (
+      id,
+      name,
+      type,
+      value,
+      buy_quantity,
+      get_quantity,
+      product_id,
+      category,
+      starts_at,
+      ends_at,
+      is_active,
+      created_at,
+      updated_at,
+      created_by
+    ) values (
+      $1, $2, $3, $4, $5, $6, $7, nullif($8, '')::category, $9, $10, $11,
+      $12, $12, $13
+    )`
+
+	_, err := r.db.Exec(
+		ctx,
+		query,
+		promotion.ID,
+		promotion.Name,
+		string(promotion.Type),
+		promotion.Value,
+		promotion.BuyQuantity,
+		promotion.GetQuantity,
+		promotion.ProductID,
+		promotion.Category.ToDBEnumType(),
+		nullTime(promotion.StartsAt),
+		nullTime(promotion.EndsAt),
+		promotion.IsActive,
+		promotion.CreatedAt,
+		promotion.CreatedBy,
+	)
+	if err != nil {
+		return model.Promotion{}, err
+	}
+
+	return promotion, nil
+}
+
+func (r *PromotionRepository) Update(
+	ctx context.Context,
+	promotion model.Promotion,
+) (model.Promotion, error) {
+	query := `
+    update promotions set
+      name = $1,
+      type = $2,
+      value = $3,
+      buy_quantity = $4,
+      get_quantity = $5,
+      product_id = $6,
+      category = nullif($7, '')::category,
+      starts_at = $8,
+      ends_at = $9,
+      is_active = $10,
+      updated_at = $11
+    where id = $12 and deleted_at is null`
+
+	tag, err := r.db.Exec(
+		ctx,
+		query,
+		promotion.Name,
+		string(promotion.Type),
+		promotion.Value,
+		promotion.BuyQuantity,
+		promotion.GetQuantity,
+		promotion.ProductID,
+		promotion.Category.ToDBEnumType(),
+		nullTime(promotion.StartsAt),
+		nullTime(promotion.EndsAt),
+		promotion.IsActive,
+		promotion.UpdatedAt,
+		promotion.ID,
+	)
+	if err != nil {
+		return model.Promotion{}, err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.Promotion{}, constant.ErrNotFound
+	}
+
+	return promotion, nil
+}
+
+func (r *PromotionRepository) Delete(
+	ctx context.Context,
+	id uuid.UUID,
+	deletedAt time.Time,
+) error {
+	tag, err := r.db.Exec(
+		ctx,
+		`
+    update promotions set
+      deleted_at = $1,
+      updated_at = $1
+    where id = $2 and deleted_at is null
+  `,
+		deletedAt,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return constant.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *PromotionRepository) FindByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (model.Promotion, error) {
+	query := `
+    select` + promotionColumns + `
+    from promotions pr
+    where pr.id = $1 and pr.deleted_at is null`
+
+	promotion, err := scanPromotion(
+		r.db.QueryRow(ctx, query, id),
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Promotion{}, constant.ErrNotFound
+		}
+		return model.Promotion{}, err
+	}
+
+	return promotion, nil
+}
+
+func (r *PromotionRepository) FindAll(
+	ctx context.Context,
+	searchQuery model.SearchPromotionQuery,
+) ([]model.Promotion, error) {
+	var query bytes.Buffer
+	query.WriteString(`
+    select` + promotionColumns + `
+    from promotions pr
+    where pr.deleted_at is null`)
+
+	queryString, params := util.BuildQueryStringAndParams(
+		&query,
+		searchQuery.BuildWhereClauseAndParams,
+		searchQuery.BuildPagination,
+		searchQuery.BuildOrderByClause,
+	)
+
+	return r.findPromotions(ctx, queryString, params...)
+}
+
+// FindRunning returns the promotions running at the given time that could
+// apply to one of the products, either directly or through its category.
+func (r *PromotionRepository) FindRunning(
+	ctx context.Context,
+	at time.Time,
+	products []model.Product,
+) ([]model.Promotion, error) {
+	productIDs := make([]uuid.UUID, 0, len(products))
+	categories := make([]string, 0, len(products))
+	for _, product := range products {
+		productIDs = append(productIDs, product.ID)
+		categories = append(
+			categories,
+			product.Category.ToDBEnumType(),
+		)
+	}
+
+	query := `
+    select` + promotionColumns + `
+    from promotions pr
+    where pr.deleted_at is null
+      and pr.is_active
+      and (pr.starts_at is null or pr.starts_at <= $1)
+      and (pr.ends_at is null or pr.ends_at > $1)
+      and (
+        pr.product_id = any($2::uuid[])
+        or pr.category::text = any($3::text[])
+        or (pr.product_id is null and pr.category is null)
+      )`
+
+	return r.findPromotions(
+		ctx,
+		query,
+		at,
+		productIDs,
+		categories,
+	)
+}
+
+func (r *PromotionRepository) findPromotions(
+	ctx context.Context,
+	query string,
+	params ...interface{},
+) ([]model.Promotion, error) {
+	rows, err := r.db.Query(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promotions := make([]model.Promotion, 0)
+	for rows.Next() {
+		promotion, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		promotions = append(promotions, promotion)
+	}
+
+	return promotions, rows.Err()
+}
+
+func scanPromotion(row pgx.Row) (model.Promotion, error) {
+	var (
+		promotion        model.Promotion
+		promotionType    string
+		category         *string
+		startsAt, endsAt *time.Time
+	)
+	err := row.Scan(
+		&promotion.ID,
+		&promotion.Name,
+		&promotionType,
+		&promotion.Value,
+		&promotion.BuyQuantity,
+		&promotion.GetQuantity,
+		&promotion.ProductID,
+		&category,
+		&startsAt,
+		&endsAt,
+		&promotion.IsActive,
+		&promotion.CreatedAt,
+		&promotion.UpdatedAt,
+		&promotion.CreatedBy,
+	)
+	if err != nil {
+		return model.Promotion{}, err
+	}
+
+	promotion.Type = model.PromotionType(promotionType)
+	if category != nil {
+		promotion.Category = promotion.Category.FromDBEnumType(
+			*category,
+		)
+	}
+	if startsAt != nil {
+		promotion.StartsAt = *startsAt
+	}
+	if endsAt != nil {
+		promotion.EndsAt = *endsAt
+	}
+
+	return promotion, nil
+}
+
+// nullTime stores the zero time as null.
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
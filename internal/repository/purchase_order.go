@@ -0,0 +1,476 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type PurchaseOrderRepository struct {
+	db *pgx.Conn
+}
+
+func NewPurchaseOrderRepository(
+	db *pgx.Conn,
+) *PurchaseOrderRepository {
+	return &PurchaseOrderRepository{db}
+}
+
+const purchaseOrderColumns = `
+      po.id,
+      po.supplier_id,
+      sp.name,
+      po.location_id,
+      l.name,
+      po.status,
+      coalesce(po.notes, ''),
+      po.expected_at,
+      po.created_at,
+      po.updated_at,
+      po.created_by,
+      (
+        select coalesce(sum(pol.quantity * pol.unit_cost), 0)
+        from purchase_order_lines pol
+        where pol.purchase_order_id = po.id
+      )`
+
+func (r *PurchaseOrderRepository) Save(
+	ctx context.Context,
+	po model.PurchaseOrder,
+) (model.PurchaseOrder, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &pgx.Batch{}
+	batch.Queue(
+		`
+    insert into purchase_orders (
+      id,
+      supplier_id,
+      location_id,
+      status,
+      notes,
+      expected_at,
+      created_at,
+      updated_at,
+      created_by
+    ) values (
+      $1, $2, $3, $4, nullif($5, ''), $6, $7, $7, $8
+    )
+  `,
+		po.ID,
+		po.SupplierID,
+		po.LocationID,
+		string(po.Status),
+		po.Notes,
+		nullTime(po.ExpectedAt),
+		po.CreatedAt,
+		po.CreatedBy,
+	)
+
+	queryLine := `
+    insert into purchase_order_lines (
+      purchase_order_id,
+      product_id,
+      quantity,
+      unit_cost
+    ) values (
+      $1, $2, $3, $4
+    )
+  `
+	for _, line := range po.Lines {
+		batch.Queue(
+			queryLine,
+			po.ID,
+			line.ProductID,
+			line.Quantity,
+			line.UnitCost,
+		)
+	}
+
+	batchRes := tx.SendBatch(
+		ctx,
+		batch,
+	)
+	if err := batchRes.Close(); err != nil {
+		return model.PurchaseOrder{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+
+	return po, nil
+}
+
+func (r *PurchaseOrderRepository) FindByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (model.PurchaseOrder, error) {
+	query := `
+    select` + purchaseOrderColumns + `
+    from purchase_orders po
+    join suppliers sp on sp.id = po.supplier_id
+    join locations l on l.id = po.location_id
+    where po.id = $1`
+
+	po, err := scanPurchaseOrder(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.PurchaseOrder{}, constant.ErrNotFound
+		}
+		return model.PurchaseOrder{}, err
+	}
+
+	rows, err := r.db.Query(ctx, purchaseOrderLinesQuery, po.ID)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+
+	po.Lines, err = scanPurchaseOrderLines(rows)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+
+	return po, nil
+}
+
+func (r *PurchaseOrderRepository) FindAll(
+	ctx context.Context,
+	searchQuery model.SearchPurchaseOrderQuery,
+) ([]model.PurchaseOrder, error) {
+	var query bytes.Buffer
+	query.WriteString(`
+    select` + purchaseOrderColumns + `
+    from purchase_orders po
+    join suppliers sp on sp.id = po.supplier_id
+    join locations l on l.id = po.location_id
+    where 1=1`)
+
+	queryString, params := util.BuildQueryStringAndParams(
+		&query,
+		searchQuery.BuildWhereClauseAndParams,
+		searchQuery.BuildPagination,
+		searchQuery.BuildOrderByClause,
+	)
+
+	rows, err := r.db.Query(ctx, queryString, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pos := make([]model.PurchaseOrder, 0)
+	for rows.Next() {
+		po, err := scanPurchaseOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		pos = append(pos, po)
+	}
+
+	return pos, rows.Err()
+}
+
+// Receive takes a delivery in. Every line received raises the stock of its
+// product at the location of the order, updates the product cost from the unit cost paid and is recorded
+// as a stock movement. The order is locked for the whole receipt so two
+// deliveries cannot both take in the last of a line.
+func (r *PurchaseOrderRepository) Receive(
+	ctx context.Context,
+	receipt model.PurchaseOrderReceipt,
+) (model.PurchaseOrder, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	po, err := lockOpenPurchaseOrder(ctx, tx, receipt.PurchaseOrderID)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+
+	rows, err := tx.Query(ctx, purchaseOrderLinesQuery, po.ID)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+
+	po.Lines, err = scanPurchaseOrderLines(rows)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+
+	lines := receipt.Lines
+	if len(lines) == 0 {
+		for _, line := range po.Lines {
+			if line.Remaining() > 0 {
+				lines = append(lines, model.PurchaseOrderReceiptLine{
+					ProductID: line.ProductID,
+					Quantity:  line.Remaining(),
+				})
+			}
+		}
+	}
+
+	lineIndex := make(map[uuid.UUID]int, len(po.Lines))
+	for i, line := range po.Lines {
+		lineIndex[line.ProductID] = i
+	}
+
+	batch := &pgx.Batch{}
+	productIDs := make([]uuid.UUID, 0, len(lines))
+	for _, received := range lines {
+		i, ok := lineIndex[received.ProductID]
+		if !ok {
+			return model.PurchaseOrder{}, constant.ErrBadInput
+		}
+
+		line := &po.Lines[i]
+		if received.Quantity > line.Remaining() {
+			return model.PurchaseOrder{}, constant.ErrExceedsOrdered
+		}
+		line.ReceivedQuantity += received.Quantity
+
+		unitCost := line.UnitCost
+		if received.UnitCost != nil {
+			unitCost = *received.UnitCost
+		}
+
+		batch.Queue(
+			`
+    update purchase_order_lines set
+      received_quantity = received_quantity + $1
+    where purchase_order_id = $2 and product_id = $3
+  `,
+			received.Quantity,
+			po.ID,
+			received.ProductID,
+		)
+		batch.Queue(
+			`
+    insert into product_stocks (product_id, location_id, stock)
+    values ($1, $2, $3)
+    on conflict (product_id, location_id) do update set
+      stock = product_stocks.stock + excluded.stock
+  `,
+			received.ProductID,
+			po.LocationID,
+			received.Quantity,
+		)
+		batch.Queue(
+			`
+    update products set
+      stock = stock + $1::numeric,
+      cost = case
+        when $3 and stock > 0
+          then round((stock * cost + $1::numeric * $2::numeric) / (stock + $1::numeric), 2)
+        else $2::numeric
+      end
+    where id = $4
+  `,
+			received.Quantity,
+			unitCost,
+			receipt.AverageCost,
+			received.ProductID,
+		)
+		queueStockMovement(
+			batch,
+			model.StockMovement{
+				ProductID:       received.ProductID,
+				LocationID:      po.LocationID,
+				Type:            model.StockReceipt,
+				Quantity:        received.Quantity,
+				UnitCost:        &unitCost,
+				PurchaseOrderID: &po.ID,
+				Note:            receipt.Note,
+				CreatedAt:       receipt.ReceivedAt,
+				CreatedBy:       receipt.ReceivedBy,
+			},
+		)
+		productIDs = append(productIDs, received.ProductID)
+	}
+	queueLowStockRearm(batch, productIDs)
+
+	po.Status = po.NextStatus()
+	po.UpdatedAt = receipt.ReceivedAt
+	batch.Queue(
+		`
+    update purchase_orders set
+      status = $1,
+      updated_at = $2
+    where id = $3
+  `,
+		string(po.Status),
+		po.UpdatedAt,
+		po.ID,
+	)
+
+	batchRes := tx.SendBatch(
+		ctx,
+		batch,
+	)
+	if err := batchRes.Close(); err != nil {
+		return model.PurchaseOrder{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+
+	return po, nil
+}
+
+// Cancel closes an order nothing more is expected of, stock already
+// received stays.
+func (r *PurchaseOrderRepository) Cancel(
+	ctx context.Context,
+	id uuid.UUID,
+	cancelledAt time.Time,
+) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = lockOpenPurchaseOrder(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`
+    update purchase_orders set
+      status = $1,
+      updated_at = $2
+    where id = $3
+  `,
+		string(model.PurchaseOrderCancelled),
+		cancelledAt,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// lockOpenPurchaseOrder fails with ErrPurchaseOrderClosed when the order
+// was received in full or cancelled already.
+func lockOpenPurchaseOrder(
+	ctx context.Context,
+	tx pgx.Tx,
+	id uuid.UUID,
+) (model.PurchaseOrder, error) {
+	var po model.PurchaseOrder
+	err := tx.QueryRow(
+		ctx,
+		`
+    select id, location_id, status
+    from purchase_orders
+    where id = $1
+    for update
+  `,
+		id,
+	).Scan(
+		&po.ID,
+		&po.LocationID,
+		&po.Status,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.PurchaseOrder{}, constant.ErrNotFound
+		}
+		return model.PurchaseOrder{}, err
+	}
+
+	if !po.Status.IsOpen() {
+		return model.PurchaseOrder{}, constant.ErrPurchaseOrderClosed
+	}
+
+	return po, nil
+}
+
+const purchaseOrderLinesQuery = `
+    select
+      pol.product_id,
+      p.name,
+      p.sku,
+      pol.quantity,
+      pol.received_quantity,
+      pol.unit_cost
+    from purchase_order_lines pol
+    join products p on p.id = pol.product_id
+    where pol.purchase_order_id = $1
+    order by p.name, pol.product_id
+  `
+
+func scanPurchaseOrderLines(rows pgx.Rows) ([]model.PurchaseOrderLine, error) {
+	defer rows.Close()
+
+	lines := make([]model.PurchaseOrderLine, 0)
+	for rows.Next() {
+		var line model.PurchaseOrderLine
+		err := rows.Scan(
+			&line.ProductID,
+			&line.ProductName,
+			&line.SKU,
+			&line.Quantity,
+			&line.ReceivedQuantity,
+			&line.UnitCost,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
+
+func scanPurchaseOrder(row pgx.Row) (model.PurchaseOrder, error) {
+	var (
+		po         model.PurchaseOrder
+		expectedAt *time.Time
+	)
+	err := row.Scan(
+		&po.ID,
+		&po.SupplierID,
+		&po.SupplierName,
+		&po.LocationID,
+		&po.LocationName,
+		&po.Status,
+		&po.Notes,
+		&expectedAt,
+		&po.CreatedAt,
+		&po.UpdatedAt,
+		&po.CreatedBy,
+		&po.TotalCost,
+	)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+
+	if expectedAt != nil {
+		po.ExpectedAt = *expectedAt
+	}
+
+	return po, nil
+}
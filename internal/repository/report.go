@@ -0,0 +1,417 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type ReportRepository struct {
+	db *pgx.Conn
+}
+
+func NewReportRepository(
+	db *pgx.Conn,
+) *ReportRepository {
+	return &ReportRepository{db}
+}
+
+// dayOrders has the orders created between $1 and $2 with sign 1 and the
+// orders voided between them with sign -1, summing sign * amount nets the
+// refunds off the sales.
+const dayOrders = `
+    with day_orders as (
+      select
+        o.id,
+        o.created_by,
+        o.total_price,
+        o.discount_amount,
+        o.tax_amount,
+        1 as sign
+      from orders o
+      where o.created_at >= $1 and o.created_at < $2
+      union all
+      select
+        o.id,
+        o.created_by,
+        o.total_price,
+        o.discount_amount,
+        o.tax_amount,
+        -1 as sign
+      from orders o
+      where o.voided_at >= $1 and o.voided_at < $2
+    )`
+
+// ComputeDaily computes the report of the day starting at day from the
+// orders, whether or not the day was closed.
+func (r *ReportRepository) ComputeDaily(
+	ctx context.Context,
+	day time.Time,
+) (model.DailyReport, error) {
+	// the queries of the report read a single snapshot
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return model.DailyReport{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	report, err := computeDailyReport(ctx, tx, day)
+	if err != nil {
+		return model.DailyReport{}, err
+	}
+
+	return report, tx.Commit(ctx)
+}
+
+// FindClosed returns the frozen report of the day, ErrNotFound when the
+// day was not closed.
+func (r *ReportRepository) FindClosed(
+	ctx context.Context,
+	day time.Time,
+) (model.DailyReport, error) {
+	var (
+		report       model.DailyReport
+		businessDate time.Time
+	)
+	err := r.db.QueryRow(
+		ctx,
+		`
+    select
+      business_date,
+      gross_sales,
+      refunds,
+      net_sales,
+      discounts,
+      tax,
+      order_count,
+      refund_count,
+      items_sold,
+      categories,
+      cashiers,
+      payments,
+      time_zone,
+      closed_at,
+      closed_by
+    from daily_reports
+    where business_date = $1
+  `,
+		day,
+	).Scan(
+		&businessDate,
+		&report.GrossSales,
+		&report.Refunds,
+		&report.NetSales,
+		&report.Discounts,
+		&report.Tax,
+		&report.OrderCount,
+		&report.RefundCount,
+		&report.ItemsSold,
+		&report.Categories,
+		&report.Cashiers,
+		&report.Payments,
+		&report.TimeZone,
+		&report.ClosedAt,
+		&report.ClosedBy,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.DailyReport{}, constant.ErrNotFound
+		}
+		return model.DailyReport{}, err
+	}
+
+	report.Date = time.Date(
+		businessDate.Year(),
+		businessDate.Month(),
+		businessDate.Day(),
+		0, 0, 0, 0,
+		util.Location(),
+	)
+
+	return report, nil
+}
+
+// Close computes the report of the day and freezes it, it fails with
+// ErrDayClosed when the day was closed already.
+func (r *ReportRepository) Close(
+	ctx context.Context,
+	day time.Time,
+	closedAt time.Time,
+	closedBy uuid.UUID,
+) (model.DailyReport, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel: pgx.RepeatableRead,
+	})
+	if err != nil {
+		return model.DailyReport{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	report, err := computeDailyReport(ctx, tx, day)
+	if err != nil {
+		return model.DailyReport{}, err
+	}
+
+	report.ClosedAt = closedAt
+	report.ClosedBy = &closedBy
+	tag, err := tx.Exec(
+		ctx,
+		`
+    insert into daily_reports (
+      business_date,
+      gross_sales,
+      refunds,
+      net_sales,
+      discounts,
+      tax,
+      order_count,
+      refund_count,
+      items_sold,
+      categories,
+      cashiers,
+      payments,
+      time_zone,
+      closed_at,
+      closed_by
+    ) values (
+      $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
+    )
+    on conflict (business_date) do nothing
+  `,
+		report.Date,
+		report.GrossSales,
+		report.Refunds,
+		report.NetSales,
+		report.Discounts,
+		report.Tax,
+		report.OrderCount,
+		report.RefundCount,
+		report.ItemsSold,
+		report.Categories,
+		report.Cashiers,
+		report.Payments,
+		report.TimeZone,
+		report.ClosedAt,
+		report.ClosedBy,
+	)
+	if err != nil {
+		return model.DailyReport{}, err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.DailyReport{}, constant.ErrDayClosed
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return model.DailyReport{}, err
+	}
+
+	return report, nil
+}
+
+func computeDailyReport(
+	ctx context.Context,
+	tx pgx.Tx,
+	day time.Time,
+) (model.DailyReport, error) {
+	report := model.DailyReport{
+		Date:     day,
+		TimeZone: util.Location().String(),
+	}
+	from, to := day, day.AddDate(0, 0, 1)
+
+	err := tx.QueryRow(
+		ctx,
+		dayOrders+`
+    select
+      coalesce(sum(total_price) filter (where sign > 0), 0),
+      coalesce(sum(total_price) filter (where sign < 0), 0),
+      coalesce(sum(sign * discount_amount), 0),
+      coalesce(sum(sign * tax_amount), 0),
+      count(*) filter (where sign > 0),
+      count(*) filter (where sign < 0)
+    from day_orders`,
+		from,
+		to,
+	).Scan(
+		&report.GrossSales,
+		&report.Refunds,
+		&report.Discounts,
+		&report.Tax,
+		&report.OrderCount,
+		&report.RefundCount,
+	)
+	if err != nil {
+		return model.DailyReport{}, err
+	}
+	report.NetSales = util.RoundMoney(report.GrossSales - report.Refunds)
+
+	report.Categories, err = findCategorySales(ctx, tx, from, to)
+	if err != nil {
+		return model.DailyReport{}, err
+	}
+	for _, category := range report.Categories {
+		report.ItemsSold += category.Quantity
+	}
+
+	report.Cashiers, err = findCashierSales(ctx, tx, from, to)
+	if err != nil {
+		return model.DailyReport{}, err
+	}
+
+	report.Payments, err = findPaymentMethodTotals(ctx, tx, from, to)
+	if err != nil {
+		return model.DailyReport{}, err
+	}
+
+	return report, nil
+}
+
+func findCategorySales(
+	ctx context.Context,
+	tx pgx.Tx,
+	from, to time.Time,
+) ([]model.CategorySales, error) {
+	rows, err := tx.Query(
+		ctx,
+		dayOrders+`
+    select
+      p.category::text,
+      sum(d.sign * op.quantity),
+      sum(d.sign * op.net_price)
+    from day_orders d
+    join order_sales_lines op on op.order_id = d.id
+    join products p on p.id = op.product_id
+    group by p.category
+    order by 3 desc`,
+		from,
+		to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := make([]model.CategorySales, 0)
+	for rows.Next() {
+		var (
+			sales    model.CategorySales
+			category string
+		)
+		err := rows.Scan(
+			&category,
+			&sales.Quantity,
+			&sales.Sales,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		sales.Category = sales.Category.FromDBEnumType(category)
+		categories = append(categories, sales)
+	}
+
+	return categories, rows.Err()
+}
+
+func findCashierSales(
+	ctx context.Context,
+	tx pgx.Tx,
+	from, to time.Time,
+) ([]model.CashierSales, error) {
+	rows, err := tx.Query(
+		ctx,
+		dayOrders+`
+    select
+      d.created_by,
+      coalesce(u.name, ''),
+      count(*) filter (where d.sign > 0),
+      count(*) filter (where d.sign < 0),
+      sum(d.sign * d.total_price)
+    from day_orders d
+    left join users u on u.id = d.created_by
+    group by d.created_by, u.name
+    order by 5 desc`,
+		from,
+		to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cashiers := make([]model.CashierSales, 0)
+	for rows.Next() {
+		var (
+			sales     model.CashierSales
+			cashierID *uuid.UUID
+		)
+		err := rows.Scan(
+			&cashierID,
+			&sales.Name,
+			&sales.OrderCount,
+			&sales.RefundCount,
+			&sales.NetSales,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if cashierID != nil {
+			sales.CashierID = cashierID.String()
+		}
+		cashiers = append(cashiers, sales)
+	}
+
+	return cashiers, rows.Err()
+}
+
+func findPaymentMethodTotals(
+	ctx context.Context,
+	tx pgx.Tx,
+	from, to time.Time,
+) ([]model.PaymentMethodTotal, error) {
+	rows, err := tx.Query(
+		ctx,
+		dayOrders+`
+    select
+      op.method::text,
+      sum(d.sign * op.amount)
+    from day_orders d
+    join order_payments op on op.order_id = d.id
+    group by op.method
+    order by op.method`,
+		from,
+		to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := make([]model.PaymentMethodTotal, 0)
+	for rows.Next() {
+		var payment model.PaymentMethodTotal
+		err := rows.Scan(
+			&payment.Method,
+			&payment.Amount,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		payments = append(payments, payment)
+	}
+
+	return payments, rows.Err()
+}
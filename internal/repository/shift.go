@@ -0,0 +1,444 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type ShiftRepository struct {
+	db *pgx.Conn
+}
+
+func NewShiftRepository(
+	db *pgx.Conn,
+) *ShiftRepository {
+	return &ShiftRepository{db}
+}
+
+// the cash totals of an open shift are computed from its orders and cash
+// movements, a closed shift keeps the totals it was closed with
+const shiftColumns = `
+      s.id,
+      s.cashier_id,
+      s.opening_float,
+      coalesce(s.cash_sales, (
+        select coalesce(sum(op.amount), 0)
+        from order_payments op
+        join orders o on o.id = op.order_id
+        where o.shift_id = s.id
+          and o.voided_at is null
+          and op.method = 'cash'
+      )),
+      coalesce(s.pay_ins, (
+        select coalesce(sum(cm.amount), 0)
+        from cash_movements cm
+        where cm.shift_id = s.id and cm.type = 'pay_in'
+      )),
+      coalesce(s.pay_outs, (
+        select coalesce(sum(cm.amount), 0)
+        from cash_movements cm
+        where cm.shift_id = s.id and cm.type = 'pay_out'
+      )),
+      s.expected_cash,
+      s.counted_cash,
+      s.variance,
+      coalesce(s.notes, ''),
+      s.opened_at,
+      s.closed_at,
+      s.closed_by`
+
+func (r *ShiftRepository) Save(
+	ctx context.Context,
+	shift model.Shift,
+) (model.Shift, error) {
+	query := `
+    insert into shifts (
+      id,
+      cashier_id,
+      opening_float,
+      notes,
+      opened_at
+    ) values (
+      $1, $2, $3, nullif($4, ''), $5
+    )`
+
+	_, err := r.db.Exec(
+		ctx,
+		query,
+		shift.ID,
+		shift.CashierID,
+		shift.OpeningFloat,
+		shift.Notes,
+		shift.OpenedAt,
+	)
+	if err != nil {
+		return model.Shift{}, err
+	}
+
+	shift.ExpectedCash = shift.ComputeExpectedCash()
+	return shift, nil
+}
+
+func (r *ShiftRepository) FindByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (model.Shift, error) {
+	shift, err := r.findOne(
+		ctx,
+		`s.id = $1`,
+		id,
+	)
+	if err != nil {
+		return model.Shift{}, err
+	}
+
+	shift.CashMovements, err = r.findCashMovements(ctx, shift.ID)
+	if err != nil {
+		return model.Shift{}, err
+	}
+
+	return shift, nil
+}
+
+// FindOpenByCashier returns ErrNotFound when the cashier has no open shift.
+func (r *ShiftRepository) FindOpenByCashier(
+	ctx context.Context,
+	cashierID uuid.UUID,
+) (model.Shift, error) {
+	return r.findOne(
+		ctx,
+		`s.cashier_id = $1 and s.closed_at is null`,
+		cashierID,
+	)
+}
+
+func (r *ShiftRepository) findOne(
+	ctx context.Context,
+	where string,
+	param interface{},
+) (model.Shift, error) {
+	query := `
+    select` + shiftColumns + `
+    from shifts s
+    where ` + where
+
+	shift, err := scanShift(r.db.QueryRow(ctx, query, param))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Shift{}, constant.ErrNotFound
+		}
+		return model.Shift{}, err
+	}
+
+	return shift, nil
+}
+
+func (r *ShiftRepository) FindAll(
+	ctx context.Context,
+	searchQuery model.SearchShiftQuery,
+) ([]model.Shift, error) {
+	var query bytes.Buffer
+	query.WriteString(`
+    select` + shiftColumns + `
+    from shifts s
+    where 1=1`)
+
+	queryString, params := util.BuildQueryStringAndParams(
+		&query,
+		searchQuery.BuildWhereClauseAndParams,
+		searchQuery.BuildPagination,
+		searchQuery.BuildOrderByClause,
+	)
+
+	rows, err := r.db.Query(ctx, queryString, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shifts := make([]model.Shift, 0)
+	for rows.Next() {
+		shift, err := scanShift(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		shifts = append(shifts, shift)
+	}
+
+	return shifts, rows.Err()
+}
+
+func (r *ShiftRepository) findCashMovements(
+	ctx context.Context,
+	shiftID uuid.UUID,
+) ([]model.CashMovement, error) {
+	rows, err := r.db.Query(
+		ctx,
+		`
+    select
+      id,
+      shift_id,
+      type,
+      amount,
+      reason,
+      created_by,
+      created_at
+    from cash_movements
+    where shift_id = $1
+    order by created_at, id
+  `,
+		shiftID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := make([]model.CashMovement, 0)
+	for rows.Next() {
+		var movement model.CashMovement
+		err := rows.Scan(
+			&movement.ID,
+			&movement.ShiftID,
+			&movement.Type,
+			&movement.Amount,
+			&movement.Reason,
+			&movement.CreatedBy,
+			&movement.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		movements = append(movements, movement)
+	}
+
+	return movements, rows.Err()
+}
+
+// SaveCashMovement records a pay-in or pay-out, the shift has to be open.
+func (r *ShiftRepository) SaveCashMovement(
+	ctx context.Context,
+	movement model.CashMovement,
+) (model.CashMovement, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.CashMovement{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = lockOpenShift(ctx, tx, movement.ShiftID)
+	if err != nil {
+		return model.CashMovement{}, err
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`
+    insert into cash_movements (
+      id,
+      shift_id,
+      type,
+      amount,
+      reason,
+      created_by,
+      created_at
+    ) values (
+      $1, $2, $3, $4, $5, $6, $7
+    )
+  `,
+		movement.ID,
+		movement.ShiftID,
+		string(movement.Type),
+		movement.Amount,
+		movement.Reason,
+		movement.CreatedBy,
+		movement.CreatedAt,
+	)
+	if err != nil {
+		return model.CashMovement{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return model.CashMovement{}, err
+	}
+
+	return movement, nil
+}
+
+// Close counts the drawer of an open shift. The shift is locked before its
+// totals are computed, so checkouts still saving on it are counted and
+// later ones fail with ErrShiftClosed.
+func (r *ShiftRepository) Close(
+	ctx context.Context,
+	shiftClose model.ShiftClose,
+) (model.Shift, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.Shift{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var closedAt *time.Time
+	err = tx.QueryRow(
+		ctx,
+		`
+    select closed_at
+    from shifts
+    where id = $1
+    for update
+  `,
+		shiftClose.ID,
+	).Scan(&closedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Shift{}, constant.ErrNotFound
+		}
+		return model.Shift{}, err
+	}
+
+	if closedAt != nil {
+		return model.Shift{}, constant.ErrShiftClosed
+	}
+
+	shift, err := scanShift(
+		tx.QueryRow(
+			ctx,
+			`
+    select`+shiftColumns+`
+    from shifts s
+    where s.id = $1`,
+			shiftClose.ID,
+		),
+	)
+	if err != nil {
+		return model.Shift{}, err
+	}
+
+	variance := util.RoundMoney(
+		shiftClose.CountedCash - shift.ExpectedCash,
+	)
+	shift.CountedCash = &shiftClose.CountedCash
+	shift.Variance = &variance
+	shift.ClosedAt = shiftClose.ClosedAt
+	shift.ClosedBy = &shiftClose.ClosedBy
+	if shiftClose.Notes != "" {
+		shift.Notes = shiftClose.Notes
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`
+    update shifts set
+      cash_sales = $1,
+      pay_ins = $2,
+      pay_outs = $3,
+      expected_cash = $4,
+      counted_cash = $5,
+      variance = $6,
+      notes = nullif($7, ''),
+      closed_at = $8,
+      closed_by = $9
+    where id = $10
+  `,
+		shift.CashSales,
+		shift.PayIns,
+		shift.PayOuts,
+		shift.ExpectedCash,
+		shift.CountedCash,
+		shift.Variance,
+		shift.Notes,
+		shift.ClosedAt,
+		shift.ClosedBy,
+		shift.ID,
+	)
+	if err != nil {
+		return model.Shift{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return model.Shift{}, err
+	}
+
+	return shift, nil
+}
+
+// lockOpenShift keeps the shift from being closed until tx is done, it
+// fails with ErrShiftClosed when the shift was closed already.
+func lockOpenShift(
+	ctx context.Context,
+	tx pgx.Tx,
+	shiftID uuid.UUID,
+) error {
+	var closedAt *time.Time
+	err := tx.QueryRow(
+		ctx,
+		`
+    select closed_at
+    from shifts
+    where id = $1
+    for share
+  `,
+		shiftID,
+	).Scan(&closedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return constant.ErrNotFound
+		}
+		return err
+	}
+
+	if closedAt != nil {
+		return constant.ErrShiftClosed
+	}
+
+	return nil
+}
+
+func scanShift(row pgx.Row) (model.Shift, error) {
+	var (
+		shift        model.Shift
+		expectedCash *float64
+		closedAt     *time.Time
+	)
+	err := row.Scan(
+		&shift.ID,
+		&shift.CashierID,
+		&shift.OpeningFloat,
+		&shift.CashSales,
+		&shift.PayIns,
+		&shift.PayOuts,
+		&expectedCash,
+		&shift.CountedCash,
+		&shift.Variance,
+		&shift.Notes,
+		&shift.OpenedAt,
+		&closedAt,
+		&shift.ClosedBy,
+	)
+	if err != nil {
+		return model.Shift{}, err
+	}
+
+	if closedAt != nil {
+		shift.ClosedAt = *closedAt
+	}
+	if expectedCash != nil {
+		shift.ExpectedCash = *expectedCash
+	} else {
+		shift.ExpectedCash = shift.ComputeExpectedCash()
+	}
+
+	return shift, nil
+}
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+// maxStockAlertAttempts is how often an alert is tried before it is left
+// for someone to look at.
+const maxStockAlertAttempts = 10
+
+type StockAlertRepository struct {
+	db *pgx.Conn
+}
+
+func NewStockAlertRepository(
+	db *pgx.Conn,
+) *StockAlertRepository {
+	return &StockAlertRepository{db}
+}
+
+// FindPending returns the alerts not sent yet, oldest first.
+func (r *StockAlertRepository) FindPending(
+	ctx context.Context,
+	limit int,
+) ([]model.StockAlert, error) {
+	rows, err := r.db.Query(
+		ctx,
+		`
+    select
+      sa.id,
+      sa.product_id,
+      p.name,
+      p.sku,
+      sa.stock,
+      sa.reorder_point,
+      sa.reorder_quantity,
+      sa.attempts,
+      sa.created_at
+    from stock_alerts sa
+    join products p on p.id = sa.product_id
+    where sa.notified_at is null and sa.attempts < $1
+    order by sa.created_at
+    limit $2
+  `,
+		maxStockAlertAttempts,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := make([]model.StockAlert, 0)
+	for rows.Next() {
+		var alert model.StockAlert
+		err := rows.Scan(
+			&alert.ID,
+			&alert.ProductID,
+			&alert.Name,
+			&alert.SKU,
+			&alert.Stock,
+			&alert.ReorderPoint,
+			&alert.ReorderQuantity,
+			&alert.Attempts,
+			&alert.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, rows.Err()
+}
+
+func (r *StockAlertRepository) MarkNotified(
+	ctx context.Context,
+	id uuid.UUID,
+	notifiedAt time.Time,
+) error {
+	_, err := r.db.Exec(
+		ctx,
+		`
+    update stock_alerts set
+      notified_at = $1,
+      attempts = attempts + 1,
+      last_error = null
+    where id = $2
+  `,
+		notifiedAt,
+		id,
+	)
+
+	return err
+}
+
+func (r *StockAlertRepository) MarkFailed(
+	ctx context.Context,
+	id uuid.UUID,
+	cause error,
+) error {
+	_, err := r.db.Exec(
+		ctx,
+		`
+    update stock_alerts set
+      attempts = attempts + 1,
+      last_error = $1
+    where id = $2
+  `,
+		cause.Error(),
+		id,
+	)
+
+	return err
+}
+
+// FindLowStock lists the products at or below their reorder point.
+func (r *StockAlertRepository) FindLowStock(
+	ctx context.Context,
+	searchQuery model.SearchLowStockQuery,
+) ([]model.LowStockProduct, error) {
+	var query bytes.Buffer
+	query.WriteString(`
+    select
+      p.id,
+      p.name,
+      p.sku,
+      p.category,
+      p.stock,
+      p.reorder_point,
+      p.reorder_quantity,
+      p.low_stock_alerted_at
+    from products p
+    where p.deleted_at is null
+      and p.reorder_point > 0
+      and p.stock <= p.reorder_point`)
+
+	queryString, params := util.BuildQueryStringAndParams(
+		&query,
+		searchQuery.BuildWhereClauseAndParams,
+		searchQuery.BuildPagination,
+		searchQuery.BuildOrderByClause,
+	)
+
+	rows, err := r.db.Query(ctx, queryString, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]model.LowStockProduct, 0)
+	for rows.Next() {
+		var (
+			product   model.LowStockProduct
+			category  string
+			alertedAt *time.Time
+		)
+		err := rows.Scan(
+			&product.ID,
+			&product.Name,
+			&product.SKU,
+			&category,
+			&product.Stock,
+			&product.ReorderPoint,
+			&product.ReorderQuantity,
+			&alertedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		product.Category = product.Category.FromDBEnumType(category)
+		if alertedAt != nil {
+			product.AlertedAt = *alertedAt
+		}
+		products = append(products, product)
+	}
+
+	return products, rows.Err()
+}
+
+// queueLowStockAlerts raises an alert for each of the products that is now
+// at or below its reorder point and was not alerted for it yet. It has to
+// be queued after the stock update.
+func queueLowStockAlerts(
+	batch *pgx.Batch,
+	productIDs []uuid.UUID,
+	at time.Time,
+) {
+	batch.Queue(
+		`
+    with crossed as (
+      update products set
+        low_stock_alerted_at = $1
+      where id = any($2::uuid[])
+        and reorder_point > 0
+        and stock <= reorder_point
+        and low_stock_alerted_at is null
+      returning id, stock, reorder_point, reorder_quantity
+    )
+    insert into stock_alerts (
+      product_id,
+      stock,
+      reorder_point,
+      reorder_quantity,
+      created_at
+    )
+    select id, stock, reorder_point, reorder_quantity, $1
+    from crossed
+  `,
+		at,
+		productIDs,
+	)
+}
+
+// queueLowStockRearm lets the products alert again once their stock is
+// back above the reorder point. It has to be queued after the stock update.
+func queueLowStockRearm(
+	batch *pgx.Batch,
+	productIDs []uuid.UUID,
+) {
+	batch.Queue(
+		`
+    update products set
+      low_stock_alerted_at = null
+    where id = any($1::uuid[])
+      and low_stock_alerted_at is not null
+      and stock > reorder_point
+  `,
+		productIDs,
+	)
+}
+
+// queueOrderLowStockRearm is queueLowStockRearm for the products of the
+// order.
+func queueOrderLowStockRearm(
+	batch *pgx.Batch,
+	orderID uuid.UUID,
+) {
+	batch.Queue(
+		`
+    update products p set
+      low_stock_alerted_at = null
+    from order_sales_lines op
+    where op.order_id = $1
+      and p.id = op.product_id
+      and p.low_stock_alerted_at is not null
+      and p.stock > p.reorder_point
+  `,
+		orderID,
+	)
+}
@@ -0,0 +1,731 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type StockCountRepository struct {
+	db *pgx.Conn
+}
+
+func NewStockCountRepository(
+	db *pgx.Conn,
+) *StockCountRepository {
+	return &StockCountRepository{db}
+}
+
+const stockCountColumns = `
+      sc.id,
+      sc.location_id,
+      l.name,
+      coalesce(sc.category::text, ''),
+      sc.status,
+      coalesce(sc.notes, ''),
+      sc.created_at,
+      sc.updated_at,
+      sc.created_by,
+      sc.closed_at,
+      sc.closed_by,
+      (
+        select count(*)
+        from stock_count_lines scl
+        where scl.count_id = sc.id
+      ),
+      (
+        select count(scl.counted)
+        from stock_count_lines scl
+        where scl.count_id = sc.id
+      )`
+
+// Save opens the count with a line for every product of its category, or
+// every product when it has none, expecting the stock they have now.
+func (r *StockCountRepository) Save(
+	ctx context.Context,
+	count model.StockCount,
+) (model.StockCount, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.StockCount{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(
+		ctx,
+		`
+    insert into stock_counts (
+      id,
+      location_id,
+      category,
+      status,
+      notes,
+      created_at,
+      updated_at,
+      created_by
+    ) values (
+      $1, $2, nullif($3, '')::category, $4, nullif($5, ''), $6, $6, $7
+    )
+  `,
+		count.ID,
+		count.LocationID,
+		count.Category.ToDBEnumType(),
+		string(count.Status),
+		count.Notes,
+		count.CreatedAt,
+		count.CreatedBy,
+	)
+	if err != nil {
+		if hasPgErrorCode(err, pgUniqueViolation) {
+			return model.StockCount{}, constant.ErrStockCountOpen
+		}
+		return model.StockCount{}, err
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`
+    insert into stock_count_lines (count_id, product_id, expected)
+    select $1, p.id, coalesce(ps.stock, 0)
+    from products p
+    left join product_stocks ps
+      on ps.product_id = p.id and ps.location_id = $2
+    where p.deleted_at is null
+      and not p.is_bundle
+      and ($3::text = '' or p.category::text = $3)
+  `,
+		count.ID,
+		count.LocationID,
+		count.Category.ToDBEnumType(),
+	)
+	if err != nil {
+		return model.StockCount{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return model.StockCount{}, err
+	}
+
+	return count, nil
+}
+
+func (r *StockCountRepository) FindByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (model.StockCount, error) {
+	query := `
+    select` + stockCountColumns + `
+    from stock_counts sc
+    join locations l on l.id = sc.location_id
+    where sc.id = $1`
+
+	count, err := scanStockCount(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.StockCount{}, constant.ErrNotFound
+		}
+		return model.StockCount{}, err
+	}
+
+	rows, err := r.db.Query(
+		ctx,
+		`
+    select
+      scl.product_id,
+      p.name,
+      p.sku,
+      p.unit,
+      scl.expected,
+      scl.counted,
+      scl.stock_at_count,
+      scl.counted_at,
+      scl.counted_by,
+      coalesce(ps.stock, 0),
+      p.cost
+    from stock_count_lines scl
+    join products p on p.id = scl.product_id
+    left join product_stocks ps
+      on ps.product_id = scl.product_id and ps.location_id = $2
+    where scl.count_id = $1
+    order by p.name, scl.product_id
+  `,
+		count.ID,
+		count.LocationID,
+	)
+	if err != nil {
+		return model.StockCount{}, err
+	}
+	defer rows.Close()
+
+	count.Lines = make([]model.StockCountLine, 0, count.LineCount)
+	for rows.Next() {
+		var (
+			line      model.StockCountLine
+			countedAt *time.Time
+		)
+		err := rows.Scan(
+			&line.ProductID,
+			&line.ProductName,
+			&line.SKU,
+			&line.Unit,
+			&line.Expected,
+			&line.Counted,
+			&line.StockAtCount,
+			&countedAt,
+			&line.CountedBy,
+			&line.Stock,
+			&line.Cost,
+		)
+		if err != nil {
+			return model.StockCount{}, err
+		}
+
+		if countedAt != nil {
+			line.CountedAt = *countedAt
+		}
+		count.Lines = append(count.Lines, line)
+	}
+
+	return count, rows.Err()
+}
+
+func (r *StockCountRepository) FindAll(
+	ctx context.Context,
+	searchQuery model.SearchStockCountQuery,
+) ([]model.StockCount, error) {
+	var query bytes.Buffer
+	query.WriteString(`
+    select` + stockCountColumns + `
+    from stock_counts sc
+    join locations l on l.id = sc.location_id
+    where 1=1`)
+
+	queryString, params := util.BuildQueryStringAndParams(
+		&query,
+		searchQuery.BuildWhereClauseAndParams,
+		searchQuery.BuildPagination,
+		searchQuery.BuildOrderByClause,
+	)
+
+	rows, err := r.db.Query(ctx, queryString, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make([]model.StockCount, 0)
+	for rows.Next() {
+		count, err := scanStockCount(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
+
+// Record writes counted quantities to the lines of an open count, taking
+// the stock the products have at that moment as what the counts are
+// compared against. Products are found by SKU when their id is not given,
+// the SKU of a pack counts as that many units of its product. Entries for
+// products not on the count or with more decimals than the unit of the
+// product allows fail with ErrBadInput.
+func (r *StockCountRepository) Record(
+	ctx context.Context,
+	record model.StockCountRecord,
+) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	count, err := lockOpenStockCount(ctx, tx, record.CountID)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range record.Entries {
+		productID, quantity, err := findStockCountLine(
+			ctx,
+			tx,
+			count.ID,
+			entry,
+		)
+		if err != nil {
+			return err
+		}
+
+		tag, err := tx.Exec(
+			ctx,
+			`
+    update stock_count_lines scl set
+      counted = case
+        when $4 then coalesce(scl.counted, 0) + $3
+        else $3
+      end,
+      stock_at_count = coalesce((
+        select ps.stock
+        from product_stocks ps
+        where ps.product_id = scl.product_id and ps.location_id = $5
+      ), 0),
+      counted_at = $6,
+      counted_by = $7
+    where scl.count_id = $1 and scl.product_id = $2
+  `,
+			count.ID,
+			productID,
+			quantity,
+			record.Add,
+			count.LocationID,
+			record.RecordedAt,
+			record.RecordedBy,
+		)
+		if err != nil {
+			// a scan taken back below zero
+			if hasPgErrorCode(err, pgCheckViolation) {
+				return constant.ErrBadInput
+			}
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return constant.ErrBadInput
+		}
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`
+    update stock_counts set
+      updated_at = $1
+    where id = $2
+  `,
+		record.RecordedAt,
+		count.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Post adjusts the stock at the location of the count by the variance of
+// every counted line, recording each adjustment as a stock movement. The
+// variance is taken against the stock when the line was counted, so sales
+// made since stay taken off. Uncounted lines are left alone unless
+// uncountedAsZero is set.
+func (r *StockCountRepository) Post(
+	ctx context.Context,
+	id uuid.UUID,
+	uncountedAsZero bool,
+	postedAt time.Time,
+	postedBy uuid.UUID,
+) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	count, err := lockOpenStockCount(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	// the stock rows are locked so no sale moves them under the adjustment
+	_, err = tx.Exec(
+		ctx,
+		`
+    insert into product_stocks (product_id, location_id)
+    select product_id, $2
+    from stock_count_lines
+    where count_id = $1
+    on conflict do nothing
+  `,
+		count.ID,
+		count.LocationID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(
+		ctx,
+		`
+    select
+      scl.product_id,
+      scl.counted,
+      scl.stock_at_count,
+      ps.stock,
+      p.cost
+    from stock_count_lines scl
+    join products p on p.id = scl.product_id
+    join product_stocks ps
+      on ps.product_id = scl.product_id and ps.location_id = $2
+    where scl.count_id = $1
+    for update of ps
+  `,
+		count.ID,
+		count.LocationID,
+	)
+	if err != nil {
+		return err
+	}
+
+	var lines []model.StockCountLine
+	for rows.Next() {
+		var line model.StockCountLine
+		err := rows.Scan(
+			&line.ProductID,
+			&line.Counted,
+			&line.StockAtCount,
+			&line.Stock,
+			&line.Cost,
+		)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		lines = append(lines, line)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	batch := &pgx.Batch{}
+	productIDs := make([]uuid.UUID, 0, len(lines))
+	for _, line := range lines {
+		if line.Counted == nil {
+			if !uncountedAsZero {
+				continue
+			}
+
+			zero, stock := 0.0, line.Stock
+			line.Counted, line.StockAtCount = &zero, &stock
+			batch.Queue(
+				`
+    update stock_count_lines set
+      counted = 0,
+      stock_at_count = $3,
+      counted_at = $4,
+      counted_by = $5
+    where count_id = $1 and product_id = $2
+  `,
+				count.ID,
+				line.ProductID,
+				line.Stock,
+				postedAt,
+				postedBy,
+			)
+		}
+
+		// more sold since the line was counted than it found cannot take
+		// the stock below zero
+		adjustment := line.Variance()
+		if adjustment < -line.Stock {
+			adjustment = -line.Stock
+		}
+		if adjustment == 0 {
+			continue
+		}
+
+		cost := line.Cost
+		queueLocationStock(
+			batch,
+			line.ProductID,
+			count.LocationID,
+			adjustment,
+		)
+		queueStockMovement(
+			batch,
+			model.StockMovement{
+				ProductID:    line.ProductID,
+				LocationID:   count.LocationID,
+				Type:         model.StockCountAdjustment,
+				Quantity:     adjustment,
+				UnitCost:     &cost,
+				StockCountID: &count.ID,
+				Note:         count.Notes,
+				CreatedAt:    postedAt,
+				CreatedBy:    postedBy,
+			},
+		)
+		productIDs = append(productIDs, line.ProductID)
+	}
+	queueLowStockAlerts(batch, productIDs, postedAt)
+	queueLowStockRearm(batch, productIDs)
+
+	queueCloseStockCount(
+		batch,
+		count.ID,
+		model.StockCountPosted,
+		postedAt,
+		postedBy,
+	)
+
+	batchRes := tx.SendBatch(
+		ctx,
+		batch,
+	)
+	if err := batchRes.Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Cancel closes the count without touching the stock.
+func (r *StockCountRepository) Cancel(
+	ctx context.Context,
+	id uuid.UUID,
+	cancelledAt time.Time,
+	cancelledBy uuid.UUID,
+) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = lockOpenStockCount(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	batch := &pgx.Batch{}
+	queueCloseStockCount(
+		batch,
+		id,
+		model.StockCountCancelled,
+		cancelledAt,
+		cancelledBy,
+	)
+
+	batchRes := tx.SendBatch(
+		ctx,
+		batch,
+	)
+	if err := batchRes.Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// IsCounting tells whether any of the products is on an open count at the
+// location.
+func (r *StockCountRepository) IsCounting(
+	ctx context.Context,
+	locationID uuid.UUID,
+	productIDs []uuid.UUID,
+) (bool, error) {
+	var counting bool
+	err := r.db.QueryRow(
+		ctx,
+		`
+    select exists (
+      select 1
+      from stock_counts sc
+      join stock_count_lines scl on scl.count_id = sc.id
+      where sc.status = 'open'
+        and sc.location_id = $1
+        and scl.product_id = any($2::uuid[])
+    )
+  `,
+		locationID,
+		productIDs,
+	).Scan(&counting)
+
+	return counting, err
+}
+
+// lockOpenStockCount fails with ErrStockCountClosed when the count was
+// posted or cancelled already.
+func lockOpenStockCount(
+	ctx context.Context,
+	tx pgx.Tx,
+	id uuid.UUID,
+) (model.StockCount, error) {
+	var count model.StockCount
+	err := tx.QueryRow(
+		ctx,
+		`
+    select id, location_id, status, coalesce(notes, '')
+    from stock_counts
+    where id = $1
+    for update
+  `,
+		id,
+	).Scan(
+		&count.ID,
+		&count.LocationID,
+		&count.Status,
+		&count.Notes,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.StockCount{}, constant.ErrNotFound
+		}
+		return model.StockCount{}, err
+	}
+
+	if count.Status != model.StockCountOpen {
+		return model.StockCount{}, constant.ErrStockCountClosed
+	}
+
+	return count, nil
+}
+
+// findStockCountLine finds the product of the count an entry is for and
+// the quantity it counts in units of the product. SKUs are matched against
+// the products and their packs, SKUs not on the count or shared by more of
+// its products fail with ErrBadInput.
+func findStockCountLine(
+	ctx context.Context,
+	tx pgx.Tx,
+	countID uuid.UUID,
+	entry model.StockCountEntry,
+) (uuid.UUID, float64, error) {
+	rows, err := tx.Query(
+		ctx,
+		`
+    select scl.product_id, p.unit, 1::numeric
+    from stock_count_lines scl
+    join products p on p.id = scl.product_id
+    where scl.count_id = $1
+      and (
+        scl.product_id = $2 or
+        (p.sku = $3 and p.deleted_at is null)
+      )
+    union all
+    select scl.product_id, p.unit, pp.quantity
+    from stock_count_lines scl
+    join products p on p.id = scl.product_id
+    join product_packs pp on pp.product_id = scl.product_id
+    where scl.count_id = $1
+      and $3 <> ''
+      and pp.sku = $3
+      and pp.deleted_at is null
+      and p.deleted_at is null
+    limit 2
+  `,
+		countID,
+		entry.ProductID,
+		entry.SKU,
+	)
+	if err != nil {
+		return uuid.Nil, 0, err
+	}
+	defer rows.Close()
+
+	type match struct {
+		productID    uuid.UUID
+		unit         model.UnitOfMeasure
+		packQuantity float64
+	}
+	var matches []match
+	for rows.Next() {
+		var m match
+		err := rows.Scan(&m.productID, &m.unit, &m.packQuantity)
+		if err != nil {
+			return uuid.Nil, 0, err
+		}
+
+		matches = append(matches, m)
+	}
+	if err := rows.Err(); err != nil {
+		return uuid.Nil, 0, err
+	}
+
+	if len(matches) != 1 {
+		return uuid.Nil, 0, constant.ErrBadInput
+	}
+
+	found := matches[0]
+	quantity := entry.Quantity
+	if found.packQuantity != 1 {
+		// packs are counted whole
+		if !model.UnitPiece.IsValidQuantity(quantity) {
+			return uuid.Nil, 0, constant.ErrBadInput
+		}
+		quantity = util.RoundQuantity(
+			quantity*found.packQuantity,
+			util.QuantityDecimals,
+		)
+	}
+	if !found.unit.IsValidQuantity(quantity) {
+		return uuid.Nil, 0, constant.ErrBadInput
+	}
+
+	return found.productID, quantity, nil
+}
+
+func queueCloseStockCount(
+	batch *pgx.Batch,
+	id uuid.UUID,
+	status model.StockCountStatus,
+	at time.Time,
+	by uuid.UUID,
+) {
+	batch.Queue(
+		`
+    update stock_counts set
+      status = $1,
+      closed_at = $2,
+      closed_by = $3,
+      updated_at = $2
+    where id = $4
+  `,
+		string(status),
+		at,
+		by,
+		id,
+	)
+}
+
+func scanStockCount(row pgx.Row) (model.StockCount, error) {
+	var (
+		count    model.StockCount
+		category string
+		closedAt *time.Time
+	)
+	err := row.Scan(
+		&count.ID,
+		&count.LocationID,
+		&count.LocationName,
+		&category,
+		&count.Status,
+		&count.Notes,
+		&count.CreatedAt,
+		&count.UpdatedAt,
+		&count.CreatedBy,
+		&closedAt,
+		&count.ClosedBy,
+		&count.LineCount,
+		&count.CountedCount,
+	)
+	if err != nil {
+		return model.StockCount{}, err
+	}
+
+	count.Category = count.Category.FromDBEnumType(category)
+	if closedAt != nil {
+		count.ClosedAt = *closedAt
+	}
+
+	return count, nil
+}
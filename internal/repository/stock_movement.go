@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type StockMovementRepository struct {
+	db *pgx.Conn
+}
+
+func NewStockMovementRepository(
+	db *pgx.Conn,
+) *StockMovementRepository {
+	return &StockMovementRepository{db}
+}
+
+// FindAll is the stock history of a product, newest first.
+func (r *StockMovementRepository) FindAll(
+	ctx context.Context,
+	searchQuery model.SearchStockMovementQuery,
+) ([]model.StockMovement, error) {
+	var query bytes.Buffer
+	query.WriteString(`
+    select
+      sm.id,
+      sm.product_id,
+      sm.location_id,
+      sm.type,
+      sm.quantity,
+      sm.unit_cost,
+      sm.purchase_order_id,
+      sm.transfer_id,
+      sm.stock_count_id,
+      coalesce(sm.note, ''),
+      sm.created_at,
+      sm.created_by
+    from stock_movements sm
+    where 1=1`)
+
+	queryString, params := util.BuildQueryStringAndParams(
+		&query,
+		searchQuery.BuildWhereClauseAndParams,
+		searchQuery.BuildPagination,
+		searchQuery.BuildOrderByClause,
+	)
+
+	rows, err := r.db.Query(ctx, queryString, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := make([]model.StockMovement, 0)
+	for rows.Next() {
+		var movement model.StockMovement
+		err := rows.Scan(
+			&movement.ID,
+			&movement.ProductID,
+			&movement.LocationID,
+			&movement.Type,
+			&movement.Quantity,
+			&movement.UnitCost,
+			&movement.PurchaseOrderID,
+			&movement.TransferID,
+			&movement.StockCountID,
+			&movement.Note,
+			&movement.CreatedAt,
+			&movement.CreatedBy,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		movements = append(movements, movement)
+	}
+
+	return movements, rows.Err()
+}
+
+// queueStockMovement records a stock change, the stock itself has to be
+// updated alongside, see queueLocationStock.
+func queueStockMovement(
+	batch *pgx.Batch,
+	movement model.StockMovement,
+) {
+	batch.Queue(
+		`
+    insert into stock_movements (
+      product_id,
+      location_id,
+      type,
+      quantity,
+      unit_cost,
+      purchase_order_id,
+      transfer_id,
+      stock_count_id,
+      note,
+      created_at,
+      created_by
+    ) values (
+      $1, $2, $3, $4, $5, $6, $7, $8, nullif($9, ''), $10, $11
+    )
+  `,
+		movement.ProductID,
+		movement.LocationID,
+		string(movement.Type),
+		movement.Quantity,
+		movement.UnitCost,
+		movement.PurchaseOrderID,
+		movement.TransferID,
+		movement.StockCountID,
+		movement.Note,
+		movement.CreatedAt,
+		movement.CreatedBy,
+	)
+}
@@ -0,0 +1,402 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type StockTransferRepository struct {
+	db *pgx.Conn
+}
+
+func NewStockTransferRepository(
+	db *pgx.Conn,
+) *StockTransferRepository {
+	return &StockTransferRepository{db}
+}
+
+const stockTransferColumns = `
+      st.id,
+      st.from_location_id,
+      fl.name,
+      st.to_location_id,
+      tl.name,
+      st.status,
+      coalesce(st.notes, ''),
+      st.created_at,
+      st.updated_at,
+      st.created_by,
+      st.received_at,
+      st.received_by`
+
+const stockTransferFrom = `
+    from stock_transfers st
+    join locations fl on fl.id = st.from_location_id
+    join locations tl on tl.id = st.to_location_id`
+
+// Save sends the transfer, taking its stock out of the source location.
+// It fails with ErrInsufficientStock when the source does not hold enough.
+func (r *StockTransferRepository) Save(
+	ctx context.Context,
+	transfer model.StockTransfer,
+) (model.StockTransfer, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.StockTransfer{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &pgx.Batch{}
+	batch.Queue(
+		`
+    insert into stock_transfers (
+      id,
+      from_location_id,
+      to_location_id,
+      status,
+      notes,
+      created_at,
+      updated_at,
+      created_by
+    ) values (
+      $1, $2, $3, $4, nullif($5, ''), $6, $6, $7
+    )
+  `,
+		transfer.ID,
+		transfer.FromLocationID,
+		transfer.ToLocationID,
+		string(transfer.Status),
+		transfer.Notes,
+		transfer.CreatedAt,
+		transfer.CreatedBy,
+	)
+
+	queryLine := `
+    insert into stock_transfer_lines (
+      transfer_id,
+      product_id,
+      quantity
+    ) values (
+      $1, $2, $3
+    )
+  `
+	for _, line := range transfer.Lines {
+		batch.Queue(
+			queryLine,
+			transfer.ID,
+			line.ProductID,
+			line.Quantity,
+		)
+		queueLocationStock(
+			batch,
+			line.ProductID,
+			transfer.FromLocationID,
+			-line.Quantity,
+		)
+		queueStockMovement(
+			batch,
+			model.StockMovement{
+				ProductID:  line.ProductID,
+				LocationID: transfer.FromLocationID,
+				Type:       model.StockTransferOut,
+				Quantity:   -line.Quantity,
+				TransferID: &transfer.ID,
+				Note:       transfer.Notes,
+				CreatedAt:  transfer.CreatedAt,
+				CreatedBy:  transfer.CreatedBy,
+			},
+		)
+	}
+
+	batchRes := tx.SendBatch(
+		ctx,
+		batch,
+	)
+	if err := batchRes.Close(); err != nil {
+		return model.StockTransfer{}, mapStockError(err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return model.StockTransfer{}, err
+	}
+
+	return transfer, nil
+}
+
+func (r *StockTransferRepository) FindByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (model.StockTransfer, error) {
+	query := `
+    select` + stockTransferColumns + stockTransferFrom + `
+    where st.id = $1`
+
+	transfer, err := scanStockTransfer(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.StockTransfer{}, constant.ErrNotFound
+		}
+		return model.StockTransfer{}, err
+	}
+
+	rows, err := r.db.Query(ctx, stockTransferLinesQuery, transfer.ID)
+	if err != nil {
+		return model.StockTransfer{}, err
+	}
+
+	transfer.Lines, err = scanStockTransferLines(rows)
+	if err != nil {
+		return model.StockTransfer{}, err
+	}
+
+	return transfer, nil
+}
+
+func (r *StockTransferRepository) FindAll(
+	ctx context.Context,
+	searchQuery model.SearchStockTransferQuery,
+) ([]model.StockTransfer, error) {
+	var query bytes.Buffer
+	query.WriteString(`
+    select` + stockTransferColumns + stockTransferFrom + `
+    where 1=1`)
+
+	queryString, params := util.BuildQueryStringAndParams(
+		&query,
+		searchQuery.BuildWhereClauseAndParams,
+		searchQuery.BuildPagination,
+		searchQuery.BuildOrderByClause,
+	)
+
+	rows, err := r.db.Query(ctx, queryString, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := make([]model.StockTransfer, 0)
+	for rows.Next() {
+		transfer, err := scanStockTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, rows.Err()
+}
+
+// Receive puts the stock of the transfer at its destination.
+func (r *StockTransferRepository) Receive(
+	ctx context.Context,
+	id uuid.UUID,
+	receivedAt time.Time,
+	receivedBy uuid.UUID,
+) error {
+	return r.close(
+		ctx,
+		id,
+		model.StockTransferReceived,
+		receivedAt,
+		receivedBy,
+	)
+}
+
+// Cancel puts the stock of the transfer back at its source.
+func (r *StockTransferRepository) Cancel(
+	ctx context.Context,
+	id uuid.UUID,
+	cancelledAt time.Time,
+	cancelledBy uuid.UUID,
+) error {
+	return r.close(
+		ctx,
+		id,
+		model.StockTransferCancelled,
+		cancelledAt,
+		cancelledBy,
+	)
+}
+
+// close ends a transfer still in transit, its stock lands at the destination
+// when it is received or back at the source when it is cancelled. Either way
+// it is recorded as a transfer_in at the location that took it.
+func (r *StockTransferRepository) close(
+	ctx context.Context,
+	id uuid.UUID,
+	status model.StockTransferStatus,
+	at time.Time,
+	by uuid.UUID,
+) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var transfer model.StockTransfer
+	err = tx.QueryRow(
+		ctx,
+		`
+    select id, from_location_id, to_location_id, status
+    from stock_transfers
+    where id = $1
+    for update
+  `,
+		id,
+	).Scan(
+		&transfer.ID,
+		&transfer.FromLocationID,
+		&transfer.ToLocationID,
+		&transfer.Status,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return constant.ErrNotFound
+		}
+		return err
+	}
+
+	if transfer.Status != model.StockTransferInTransit {
+		return constant.ErrTransferClosed
+	}
+
+	rows, err := tx.Query(ctx, stockTransferLinesQuery, transfer.ID)
+	if err != nil {
+		return err
+	}
+
+	transfer.Lines, err = scanStockTransferLines(rows)
+	if err != nil {
+		return err
+	}
+
+	locationID := transfer.ToLocationID
+	if status == model.StockTransferCancelled {
+		locationID = transfer.FromLocationID
+	}
+
+	batch := &pgx.Batch{}
+	productIDs := make([]uuid.UUID, 0, len(transfer.Lines))
+	for _, line := range transfer.Lines {
+		queueLocationStock(
+			batch,
+			line.ProductID,
+			locationID,
+			line.Quantity,
+		)
+		queueStockMovement(
+			batch,
+			model.StockMovement{
+				ProductID:  line.ProductID,
+				LocationID: locationID,
+				Type:       model.StockTransferIn,
+				Quantity:   line.Quantity,
+				TransferID: &transfer.ID,
+				CreatedAt:  at,
+				CreatedBy:  by,
+			},
+		)
+		productIDs = append(productIDs, line.ProductID)
+	}
+	queueLowStockRearm(batch, productIDs)
+
+	batch.Queue(
+		`
+    update stock_transfers set
+      status = $1,
+      received_at = case when $1 = 'received' then $2 end,
+      received_by = case when $1 = 'received' then $3::uuid end,
+      updated_at = $2
+    where id = $4
+  `,
+		string(status),
+		at,
+		by,
+		transfer.ID,
+	)
+
+	batchRes := tx.SendBatch(
+		ctx,
+		batch,
+	)
+	if err := batchRes.Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+const stockTransferLinesQuery = `
+    select
+      stl.product_id,
+      p.name,
+      p.sku,
+      stl.quantity
+    from stock_transfer_lines stl
+    join products p on p.id = stl.product_id
+    where stl.transfer_id = $1
+    order by p.name, stl.product_id
+  `
+
+func scanStockTransferLines(rows pgx.Rows) ([]model.StockTransferLine, error) {
+	defer rows.Close()
+
+	lines := make([]model.StockTransferLine, 0)
+	for rows.Next() {
+		var line model.StockTransferLine
+		err := rows.Scan(
+			&line.ProductID,
+			&line.ProductName,
+			&line.SKU,
+			&line.Quantity,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
+
+func scanStockTransfer(row pgx.Row) (model.StockTransfer, error) {
+	var (
+		transfer   model.StockTransfer
+		receivedAt *time.Time
+	)
+	err := row.Scan(
+		&transfer.ID,
+		&transfer.FromLocationID,
+		&transfer.FromLocationName,
+		&transfer.ToLocationID,
+		&transfer.ToLocationName,
+		&transfer.Status,
+		&transfer.Notes,
+		&transfer.CreatedAt,
+		&transfer.UpdatedAt,
+		&transfer.CreatedBy,
+		&receivedAt,
+		&transfer.ReceivedBy,
+	)
+	if err != nil {
+		return model.StockTransfer{}, err
+	}
+
+	if receivedAt != nil {
+		transfer.ReceivedAt = *receivedAt
+	}
+
+	return transfer, nil
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type SupplierRepository struct {
+	db *pgx.Conn
+}
+
+func NewSupplierRepository(
+	db *pgx.Conn,
+) *SupplierRepository {
+	return &SupplierRepository{db}
+}
+
+const supplierColumns = `
+      sp.id,
+      sp.name,
+      coalesce(sp.phone_number, ''),
+      coalesce(sp.email, ''),
+      coalesce(sp.address, ''),
+      coalesce(sp.notes, ''),
+      sp.created_at,
+      sp.updated_at,
+      sp.created_by`
+
+func (r *SupplierRepository) Save(
+	ctx context.Context,
+	supplier model.Supplier,
+) (model.Supplier, error) {
+	query := `
+    insert into suppliers (
+      id,
+      name,
+      phone_number,
+      email,
+      address,
+      notes,
+      created_at,
+      updated_at,
+      created_by
+    ) values (
+      $1, $2, nullif($3, ''), nullif($4, ''), nullif($5, ''), nullif($6, ''),
+      $7, $8, $9
+    )`
+
+	_, err := r.db.Exec(
+		ctx,
+		query,
+		supplier.ID,
+		supplier.Name,
+		supplier.PhoneNumber,
+		supplier.Email,
+		supplier.Address,
+		supplier.Notes,
+		supplier.CreatedAt,
+		supplier.UpdatedAt,
+		supplier.CreatedBy,
+	)
+	if err != nil {
+		return model.Supplier{}, err
+	}
+
+	return supplier, nil
+}
+
+func (r *SupplierRepository) FindByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (model.Supplier, error) {
+	query := `
+    select` + supplierColumns + `
+    from suppliers sp
+    where sp.id = $1 and sp.deleted_at is null`
+
+	supplier, err := scanSupplier(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Supplier{}, constant.ErrNotFound
+		}
+		return model.Supplier{}, err
+	}
+
+	return supplier, nil
+}
+
+func (r *SupplierRepository) FindAll(
+	ctx context.Context,
+	searchQuery model.SearchSupplierQuery,
+) ([]model.Supplier, error) {
+	var query bytes.Buffer
+	query.WriteString(`
+    select` + supplierColumns + `
+    from suppliers sp
+    where sp.deleted_at is null`)
+
+	queryString, params := util.BuildQueryStringAndParams(
+		&query,
+		searchQuery.BuildWhereClauseAndParams,
+		searchQuery.BuildPagination,
+		searchQuery.BuildOrderByClause,
+	)
+
+	rows, err := r.db.Query(ctx, queryString, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suppliers := make([]model.Supplier, 0)
+	for rows.Next() {
+		supplier, err := scanSupplier(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		suppliers = append(suppliers, supplier)
+	}
+
+	return suppliers, rows.Err()
+}
+
+func (r *SupplierRepository) Update(
+	ctx context.Context,
+	supplier model.Supplier,
+) error {
+	query := `
+    update suppliers set
+      name = $1,
+      phone_number = nullif($2, ''),
+      email = nullif($3, ''),
+      address = nullif($4, ''),
+      notes = nullif($5, ''),
+      updated_at = $6
+    where id = $7 and deleted_at is null
+  `
+	tag, err := r.db.Exec(
+		ctx,
+		query,
+		supplier.Name,
+		supplier.PhoneNumber,
+		supplier.Email,
+		supplier.Address,
+		supplier.Notes,
+		supplier.UpdatedAt,
+		supplier.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return constant.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete hides the supplier, its purchase orders are kept.
+func (r *SupplierRepository) Delete(
+	ctx context.Context,
+	id uuid.UUID,
+	deletedAt time.Time,
+) error {
+	query := `
+    update suppliers set
+      deleted_at = $1
+    where id = $2 and deleted_at is null
+  `
+	tag, err := r.db.Exec(
+		ctx,
+		query,
+		deletedAt,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return constant.ErrNotFound
+	}
+
+	return nil
+}
+
+func scanSupplier(row pgx.Row) (model.Supplier, error) {
+	var supplier model.Supplier
+	err := row.Scan(
+		&supplier.ID,
+		&supplier.Name,
+		&supplier.PhoneNumber,
+		&supplier.Email,
+		&supplier.Address,
+		&supplier.Notes,
+		&supplier.CreatedAt,
+		&supplier.UpdatedAt,
+		&supplier.CreatedBy,
+	)
+
+	return supplier, err
+}
@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+)
+
+type TaxRateRepository struct {
+	db *pgx.Conn
+}
+
+func NewTaxRateRepository(
+	db *pgx.Conn,
+) *TaxRateRepository {
+	return &TaxRateRepository{db}
+}
+
+const taxRateColumns = `
+      tr.id,
+      tr.name,
+      tr.rate,
+      tr.product_id,
+      tr.category,
+      tr.created_at,
+      tr.updated_at,
+      tr.created_by`
+
+func (r *TaxRateRepository) Save(
+	ctx context.Context,
+	taxRate model.TaxRate,
+) (model.TaxRate, error) {
+	query := `
+    insert into tax_rates (
+      id,
+      name,
+      rate,
+      product_id,
+      category,
+      created_at,
+      updated_at,
+      created_by
+    ) values (
+      $1, $2, $3, $4, nullif($5, '')::category, $6, $6, $7
+    )`
+
+	_, err := r.db.Exec(
+		ctx,
+		query,
+		taxRate.ID,
+		taxRate.Name,
+		taxRate.Rate,
+		taxRate.ProductID,
+		taxRate.Category.ToDBEnumType(),
+		taxRate.CreatedAt,
+		taxRate.CreatedBy,
+	)
+	if err != nil {
+		return model.TaxRate{}, err
+	}
+
+	return taxRate, nil
+}
+
+func (r *TaxRateRepository) Update(
+	ctx context.Context,
+	taxRate model.TaxRate,
+) (model.TaxRate, error) {
+	query := `
+    update tax_rates set
+      name = $1,
+      rate = $2,
+      product_id = $3,
+      category = nullif($4, '')::category,
+      updated_at = $5
+    where id = $6 and deleted_at is null`
+
+	tag, err := r.db.Exec(
+		ctx,
+		query,
+		taxRate.Name,
+		taxRate.Rate,
+		taxRate.ProductID,
+		taxRate.Category.ToDBEnumType(),
+		taxRate.UpdatedAt,
+		taxRate.ID,
+	)
+	if err != nil {
+		return model.TaxRate{}, err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.TaxRate{}, constant.ErrNotFound
+	}
+
+	return taxRate, nil
+}
+
+func (r *TaxRateRepository) Delete(
+	ctx context.Context,
+	id uuid.UUID,
+	deletedAt time.Time,
+) error {
+	tag, err := r.db.Exec(
+		ctx,
+		`
+    update tax_rates set
+      deleted_at = $1,
+      updated_at = $1
+    where id = $2 and deleted_at is null
+  `,
+		deletedAt,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return constant.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *TaxRateRepository) FindByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (model.TaxRate, error) {
+	query := `
+    select` + taxRateColumns + `
+    from tax_rates tr
+    where tr.id = $1 and tr.deleted_at is null`
+
+	taxRate, err := scanTaxRate(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.TaxRate{}, constant.ErrNotFound
+		}
+		return model.TaxRate{}, err
+	}
+
+	return taxRate, nil
+}
+
+// FindByScope finds the rate set for exactly this product or category.
+func (r *TaxRateRepository) FindByScope(
+	ctx context.Context,
+	productID *uuid.UUID,
+	category model.ProductCategory,
+) (model.TaxRate, error) {
+	query := `
+    select` + taxRateColumns + `
+    from tax_rates tr
+    where tr.deleted_at is null
+      and (tr.product_id = $1 or tr.category::text = $2)`
+
+	taxRate, err := scanTaxRate(
+		r.db.QueryRow(
+			ctx,
+			query,
+			productID,
+			category.ToDBEnumType(),
+		),
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.TaxRate{}, constant.ErrNotFound
+		}
+		return model.TaxRate{}, err
+	}
+
+	return taxRate, nil
+}
+
+// FindAll lists every rate, there are at most a few per category.
+func (r *TaxRateRepository) FindAll(
+	ctx context.Context,
+) ([]model.TaxRate, error) {
+	query := `
+    select` + taxRateColumns + `
+    from tax_rates tr
+    where tr.deleted_at is null
+    order by tr.category nulls last, tr.created_at`
+
+	return r.findTaxRates(ctx, query)
+}
+
+// FindForProducts returns the rates set for any of the products or for
+// their categories.
+func (r *TaxRateRepository) FindForProducts(
+	ctx context.Context,
+	products []model.Product,
+) ([]model.TaxRate, error) {
+	productIDs := make([]uuid.UUID, 0, len(products))
+	categories := make([]string, 0, len(products))
+	for _, product := range products {
+		productIDs = append(productIDs, product.ID)
+		categories = append(
+			categories,
+			product.Category.ToDBEnumType(),
+		)
+	}
+
+	query := `
+    select` + taxRateColumns + `
+    from tax_rates tr
+    where tr.deleted_at is null
+      and (
+        tr.product_id = any($1::uuid[])
+        or tr.category::text = any($2::text[])
+      )`
+
+	return r.findTaxRates(ctx, query, productIDs, categories)
+}
+
+func (r *TaxRateRepository) findTaxRates(
+	ctx context.Context,
+	query string,
+	params ...interface{},
+) ([]model.TaxRate, error) {
+	rows, err := r.db.Query(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	taxRates := make([]model.TaxRate, 0)
+	for rows.Next() {
+		taxRate, err := scanTaxRate(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		taxRates = append(taxRates, taxRate)
+	}
+
+	return taxRates, rows.Err()
+}
+
+func scanTaxRate(row pgx.Row) (model.TaxRate, error) {
+	var (
+		taxRate  model.TaxRate
+		category *string
+	)
+	err := row.Scan(
+		&taxRate.ID,
+		&taxRate.Name,
+		&taxRate.Rate,
+		&taxRate.ProductID,
+		&category,
+		&taxRate.CreatedAt,
+		&taxRate.UpdatedAt,
+		&taxRate.CreatedBy,
+	)
+	if err != nil {
+		return model.TaxRate{}, err
+	}
+
+	if category != nil {
+		taxRate.Category = taxRate.Category.FromDBEnumType(*category)
+	}
+
+	return taxRate, nil
+}
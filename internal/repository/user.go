@@ -31,14 +31,18 @@ func (repository *UserRepository) Save(
     (
       id, 
       phone_number,
+      phone_country_code,
+      phone_region,
       password,
       name
     ) values 
     (
       $1,
       $2,
-      $3,
-      $4 
+      nullif($3, ''),
+      $4,
+      $5,
+      $6 
     );
   `
 
@@ -47,6 +51,8 @@ func (repository *UserRepository) Save(
 		query,
 		user.ID,
 		user.PhoneNumber,
+		user.PhoneCountryCode,
+		user.PhoneRegion,
 		user.Password,
 		user.Name,
 	)
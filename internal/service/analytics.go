@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/repository"
+)
+
+type AnalyticsService struct {
+	AnalyticsRepository *repository.AnalyticsRepository
+}
+
+func NewAnalyticsService(
+	analyticsRepository *repository.AnalyticsRepository,
+) *AnalyticsService {
+	return &AnalyticsService{
+		AnalyticsRepository: analyticsRepository,
+	}
+}
+
+// RefreshRollups refreshes the sales rollups right away and then every
+// interval until ctx is done. Failures are logged and retried on the next
+// tick.
+func (service *AnalyticsService) RefreshRollups(
+	ctx context.Context,
+	interval time.Duration,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := service.AnalyticsRepository.RefreshRollups(ctx)
+		if err != nil {
+			log.Printf("unable to refresh sales rollups: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (service *AnalyticsService) FindTopProducts(
+	ctx context.Context,
+	query model.TopProductsQuery,
+) ([]model.ProductSales, error) {
+	return service.AnalyticsRepository.FindTopProducts(ctx, query)
+}
+
+func (service *AnalyticsService) FindSlowMovers(
+	ctx context.Context,
+	query model.SlowMoversQuery,
+) ([]model.SlowMover, error) {
+	return service.AnalyticsRepository.FindSlowMovers(ctx, query)
+}
+
+func (service *AnalyticsService) FindHeatmap(
+	ctx context.Context,
+	query model.DateRangeQuery,
+) ([]model.HeatmapCell, error) {
+	return service.AnalyticsRepository.FindHeatmap(ctx, query)
+}
+
+func (service *AnalyticsService) FindCategoryTrends(
+	ctx context.Context,
+	query model.CategoryTrendQuery,
+) ([]model.CategoryTrend, error) {
+	return service.AnalyticsRepository.FindCategoryTrends(ctx, query)
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/repository"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type CouponService struct {
+	CouponRepository  *repository.CouponRepository
+	ProductRepository *repository.ProductRepository
+}
+
+func NewCouponService(
+	couponRepository *repository.CouponRepository,
+	productRepository *repository.ProductRepository,
+) *CouponService {
+	return &CouponService{
+		CouponRepository:  couponRepository,
+		ProductRepository: productRepository,
+	}
+}
+
+func (service *CouponService) Create(
+	ctx context.Context,
+	coupon model.Coupon,
+) (model.Coupon, error) {
+	err := service.checkCoupon(ctx, coupon)
+	if err != nil {
+		return model.Coupon{}, err
+	}
+
+	coupon.ID, err = uuid.NewV7()
+	if err != nil {
+		return model.Coupon{}, err
+	}
+
+	coupon.CreatedAt = util.Now()
+	coupon.UpdatedAt = coupon.CreatedAt
+	coupon.CreatedBy = uuid.MustParse(ctx.Value("userID").(string))
+	return service.CouponRepository.Save(ctx, coupon)
+}
+
+func (service *CouponService) Update(
+	ctx context.Context,
+	id string,
+	coupon model.Coupon,
+) (model.Coupon, error) {
+	couponID, err := uuid.Parse(id)
+	if err != nil {
+		return model.Coupon{}, constant.ErrNotFound
+	}
+
+	existing, err := service.CouponRepository.FindByID(ctx, couponID)
+	if err != nil {
+		return model.Coupon{}, err
+	}
+
+	coupon.ID = existing.ID
+	err = service.checkCoupon(ctx, coupon)
+	if err != nil {
+		return model.Coupon{}, err
+	}
+
+	// a limit can't be lowered below the uses already made
+	if coupon.MaxUses != nil &&
+		*coupon.MaxUses < existing.UsedCount {
+		return model.Coupon{}, constant.ErrBadInput
+	}
+
+	coupon.UsedCount = existing.UsedCount
+	coupon.CreatedAt = existing.CreatedAt
+	coupon.CreatedBy = existing.CreatedBy
+	coupon.UpdatedAt = util.Now()
+	return service.CouponRepository.Update(ctx, coupon)
+}
+
+func (service *CouponService) Delete(
+	ctx context.Context,
+	id string,
+) error {
+	couponID, err := uuid.Parse(id)
+	if err != nil {
+		return constant.ErrNotFound
+	}
+
+	return service.CouponRepository.Delete(
+		ctx,
+		couponID,
+		util.Now(),
+	)
+}
+
+func (service *CouponService) FindByID(
+	ctx context.Context,
+	id string,
+) (model.Coupon, error) {
+	couponID, err := uuid.Parse(id)
+	if err != nil {
+		return model.Coupon{}, constant.ErrNotFound
+	}
+
+	return service.CouponRepository.FindByID(ctx, couponID)
+}
+
+func (service *CouponService) Search(
+	ctx context.Context,
+	query model.SearchCouponQuery,
+) ([]model.Coupon, error) {
+	return service.CouponRepository.FindAll(ctx, query)
+}
+
+// checkCoupon makes sure the code is not taken by another coupon and that
+// a product scoped coupon points to a product that exists.
+func (service *CouponService) checkCoupon(
+	ctx context.Context,
+	coupon model.Coupon,
+) error {
+	existing, err := service.CouponRepository.FindByCode(
+		ctx,
+		coupon.Code,
+	)
+	if err != nil && !errors.Is(err, constant.ErrNotFound) {
+		return err
+	}
+	if err == nil && existing.ID != coupon.ID {
+		return constant.ErrConflict
+	}
+
+	if coupon.ProductID == nil {
+		return nil
+	}
+
+	products, err := service.ProductRepository.FindByIds(
+		ctx,
+		[]uuid.UUID{*coupon.ProductID},
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := products[*coupon.ProductID]; !ok {
+		return constant.ErrNotFound
+	}
+
+	return nil
+}
@@ -8,17 +8,27 @@ import (
 	"github.com/nozzlium/eniqilo_store/internal/constant"
 	"github.com/nozzlium/eniqilo_store/internal/model"
 	"github.com/nozzlium/eniqilo_store/internal/repository"
+	"github.com/nozzlium/eniqilo_store/internal/util"
 )
 
 type CustomerService struct {
 	CustomerRepository *repository.CustomerRepository
+	OrderRepository    *repository.OrderRepository
+	LoyaltyRepository  *repository.LoyaltyRepository
+	CouponRepository   *repository.CouponRepository
 }
 
 func NewCustomerService(
 	customerRepository *repository.CustomerRepository,
+	orderRepository *repository.OrderRepository,
+	loyaltyRepository *repository.LoyaltyRepository,
+	couponRepository *repository.CouponRepository,
 ) *CustomerService {
 	return &CustomerService{
 		CustomerRepository: customerRepository,
+		OrderRepository:    orderRepository,
+		LoyaltyRepository:  loyaltyRepository,
+		CouponRepository:   couponRepository,
 	}
 }
 
@@ -26,6 +36,16 @@ func (service *CustomerService) Create(
 	ctx context.Context,
 	customer model.Customer,
 ) (model.CustomerData, error) {
+	phoneNumber, err := util.NormalizePhoneNumber(
+		customer.PhoneNumber,
+	)
+	if err != nil {
+		return model.CustomerData{}, err
+	}
+	customer.PhoneNumber = phoneNumber.E164
+	customer.PhoneCountryCode = phoneNumber.CountryCode
+	customer.PhoneRegion = phoneNumber.Region
+
 	savedCustomer, err := service.CustomerRepository.FindByPhoneNumber(
 		ctx,
 		customer.PhoneNumber,
@@ -66,14 +86,14 @@ func (service *CustomerService) Create(
 
 func (service *CustomerService) FindCustomers(
 	ctx context.Context,
-	customer model.Customer,
-) ([]model.CustomerData, error) {
+	query model.SearchCustomerQuery,
+) ([]model.CustomerData, string, error) {
 	customers, err := service.CustomerRepository.FindAllCustomers(
 		ctx,
-		customer,
+		query,
 	)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	res := make(
@@ -91,5 +111,318 @@ func (service *CustomerService) FindCustomers(
 			},
 		)
 	}
-	return res, nil
+	return res, query.NextCursor(customers), nil
+}
+
+func (service *CustomerService) Export(
+	ctx context.Context,
+	query model.SearchCustomerQuery,
+	fn func(customer model.Customer) error,
+) error {
+	return service.CustomerRepository.Export(
+		ctx,
+		query,
+		fn,
+	)
+}
+
+func (service *CustomerService) Count(
+	ctx context.Context,
+	query model.SearchCustomerQuery,
+) (int64, bool, error) {
+	return service.CustomerRepository.Count(
+		ctx,
+		query,
+	)
+}
+
+func (service *CustomerService) Update(
+	ctx context.Context,
+	id string,
+	customer model.Customer,
+) (model.CustomerData, error) {
+	customerID, err := uuid.Parse(id)
+	if err != nil {
+		return model.CustomerData{}, constant.ErrNotFound
+	}
+
+	_, err = service.CustomerRepository.FindByID(
+		ctx,
+		customerID,
+	)
+	if err != nil {
+		return model.CustomerData{}, err
+	}
+
+	phoneNumber, err := util.NormalizePhoneNumber(
+		customer.PhoneNumber,
+	)
+	if err != nil {
+		return model.CustomerData{}, err
+	}
+	customer.PhoneNumber = phoneNumber.E164
+	customer.PhoneCountryCode = phoneNumber.CountryCode
+	customer.PhoneRegion = phoneNumber.Region
+
+	samePhone, err := service.CustomerRepository.FindByPhoneNumber(
+		ctx,
+		customer.PhoneNumber,
+	)
+	if err != nil {
+		if !errors.Is(
+			err,
+			constant.ErrNotFound,
+		) {
+			return model.CustomerData{}, err
+		}
+	}
+
+	if samePhone.ID != uuid.Nil &&
+		samePhone.ID != customerID {
+		return model.CustomerData{}, constant.ErrConflict
+	}
+
+	customer.ID = customerID
+	customer.UpdatedAt = util.Now()
+	err = service.CustomerRepository.Update(
+		ctx,
+		customer,
+	)
+	if err != nil {
+		return model.CustomerData{}, err
+	}
+
+	return model.CustomerData{
+		UserID:      customer.ID.String(),
+		Name:        customer.Name,
+		PhoneNumber: customer.PhoneNumber,
+	}, nil
+}
+
+func (service *CustomerService) Delete(
+	ctx context.Context,
+	id string,
+) error {
+	customerID, err := uuid.Parse(id)
+	if err != nil {
+		return constant.ErrNotFound
+	}
+
+	return service.CustomerRepository.Delete(
+		ctx,
+		customerID,
+		util.Now(),
+	)
+}
+
+// Erase removes the customer's personal data for a privacy request. The
+// customer is deleted as well, their orders are left untouched.
+func (service *CustomerService) Erase(
+	ctx context.Context,
+	id string,
+) error {
+	customerID, err := uuid.Parse(id)
+	if err != nil {
+		return constant.ErrNotFound
+	}
+
+	return service.CustomerRepository.Erase(
+		ctx,
+		customerID,
+		util.Now(),
+	)
+}
+
+func (service *CustomerService) ExportData(
+	ctx context.Context,
+	id string,
+) (model.CustomerDataExport, error) {
+	customerID, err := uuid.Parse(id)
+	if err != nil {
+		return model.CustomerDataExport{}, constant.ErrNotFound
+	}
+
+	customer, err := service.CustomerRepository.FindProfileByID(
+		ctx,
+		customerID,
+	)
+	if err != nil {
+		return model.CustomerDataExport{}, err
+	}
+
+	ids, err := service.OrderRepository.GetOrderIDsByCustomerID(
+		ctx,
+		customerID,
+	)
+	if err != nil {
+		return model.CustomerDataExport{}, err
+	}
+
+	orders := make([]model.OrderResponseBody, 0, len(ids))
+	if len(ids) > 0 {
+		orderMap, uuids, err := service.OrderRepository.Search(
+			ctx,
+			model.SearchOrderDetailQuery{
+				IDs: ids,
+			},
+		)
+		if err != nil {
+			return model.CustomerDataExport{}, err
+		}
+
+		for _, orderID := range uuids {
+			orders = append(
+				orders,
+				orderMap[orderID].ToResponseBody(),
+			)
+		}
+	}
+
+	transactions, err := service.LoyaltyRepository.FindAllTransactions(
+		ctx,
+		customerID,
+	)
+	if err != nil {
+		return model.CustomerDataExport{}, err
+	}
+
+	ledger := make([]model.LoyaltyTransactionBody, 0, len(transactions))
+	for _, transaction := range transactions {
+		ledger = append(ledger, transaction.ToResponseBody())
+	}
+
+	couponRedemptions, err := service.CouponRepository.FindRedemptionsByCustomer(
+		ctx,
+		customerID,
+	)
+	if err != nil {
+		return model.CustomerDataExport{}, err
+	}
+
+	redemptions := make([]model.CouponRedemptionBody, 0, len(couponRedemptions))
+	for _, redemption := range couponRedemptions {
+		redemptions = append(redemptions, redemption.ToResponseBody())
+	}
+
+	customerMerges, err := service.CustomerRepository.FindMerges(
+		ctx,
+		customerID,
+	)
+	if err != nil {
+		return model.CustomerDataExport{}, err
+	}
+
+	merges := make([]model.CustomerMergeResponseBody, 0, len(customerMerges))
+	for _, merge := range customerMerges {
+		merges = append(merges, merge.ToResponseBody())
+	}
+
+	return model.CustomerDataExport{
+		Profile:           customer.ToProfile(),
+		Orders:            orders,
+		LoyaltyLedger:     ledger,
+		CouponRedemptions: redemptions,
+		Merges:            merges,
+	}, nil
+}
+
+const customerTopProductsLimit = 5
+
+func (service *CustomerService) GetDetail(
+	ctx context.Context,
+	id string,
+) (model.CustomerDetail, error) {
+	customerID, err := uuid.Parse(id)
+	if err != nil {
+		return model.CustomerDetail{}, constant.ErrNotFound
+	}
+
+	customer, err := service.CustomerRepository.FindProfileByID(
+		ctx,
+		customerID,
+	)
+	if err != nil {
+		return model.CustomerDetail{}, err
+	}
+
+	if !customer.DeletedAt.IsZero() {
+		return model.CustomerDetail{}, constant.ErrNotFound
+	}
+
+	stats, err := service.OrderRepository.GetCustomerStats(
+		ctx,
+		customerID,
+	)
+	if err != nil {
+		return model.CustomerDetail{}, err
+	}
+
+	topProducts, err := service.OrderRepository.GetCustomerTopProducts(
+		ctx,
+		customerID,
+		customerTopProductsLimit,
+	)
+	if err != nil {
+		return model.CustomerDetail{}, err
+	}
+
+	return model.CustomerDetail{
+		Profile:     customer.ToProfile(),
+		Stats:       stats.ToResponseBody(),
+		TopProducts: topProducts,
+	}, nil
+}
+
+func (service *CustomerService) Merge(
+	ctx context.Context,
+	survivorID string,
+	duplicateID string,
+) (model.CustomerMerge, error) {
+	survivor, err := uuid.Parse(survivorID)
+	if err != nil {
+		return model.CustomerMerge{}, constant.ErrNotFound
+	}
+
+	duplicate, err := uuid.Parse(duplicateID)
+	if err != nil {
+		return model.CustomerMerge{}, constant.ErrNotFound
+	}
+
+	if survivor == duplicate {
+		return model.CustomerMerge{}, constant.ErrBadInput
+	}
+
+	mergeID, err := uuid.NewV7()
+	if err != nil {
+		return model.CustomerMerge{}, err
+	}
+
+	return service.CustomerRepository.Merge(
+		ctx,
+		model.CustomerMerge{
+			ID:          mergeID,
+			SurvivorID:  survivor,
+			DuplicateID: duplicate,
+			MergedBy: uuid.MustParse(
+				ctx.Value("userID").(string),
+			),
+			CreatedAt: util.Now(),
+		},
+	)
+}
+
+const defaultDuplicateCandidatesLimit = 20
+
+func (service *CustomerService) FindDuplicateCandidates(
+	ctx context.Context,
+	limit int,
+) ([]model.DuplicateCustomerCandidate, error) {
+	if limit <= 0 {
+		limit = defaultDuplicateCandidatesLimit
+	}
+
+	return service.CustomerRepository.FindDuplicateCandidates(
+		ctx,
+		limit,
+	)
 }
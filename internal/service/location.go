@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/repository"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type LocationService struct {
+	LocationRepository *repository.LocationRepository
+	ProductRepository  *repository.ProductRepository
+}
+
+func NewLocationService(
+	locationRepository *repository.LocationRepository,
+	productRepository *repository.ProductRepository,
+) *LocationService {
+	return &LocationService{
+		LocationRepository: locationRepository,
+		ProductRepository:  productRepository,
+	}
+}
+
+func (service *LocationService) Create(
+	ctx context.Context,
+	location model.Location,
+) (model.Location, error) {
+	var err error
+	location.ID, err = uuid.NewV7()
+	if err != nil {
+		return model.Location{}, err
+	}
+
+	location.CreatedAt = util.Now()
+	location.UpdatedAt = location.CreatedAt
+	return service.LocationRepository.Save(ctx, location)
+}
+
+func (service *LocationService) Search(
+	ctx context.Context,
+	query model.SearchLocationQuery,
+) ([]model.Location, error) {
+	return service.LocationRepository.FindAll(ctx, query)
+}
+
+func (service *LocationService) FindByID(
+	ctx context.Context,
+	id string,
+) (model.Location, error) {
+	locationID, err := uuid.Parse(id)
+	if err != nil {
+		return model.Location{}, constant.ErrNotFound
+	}
+
+	return service.LocationRepository.FindByID(ctx, locationID)
+}
+
+func (service *LocationService) Update(
+	ctx context.Context,
+	id string,
+	location model.Location,
+) (model.Location, error) {
+	locationID, err := uuid.Parse(id)
+	if err != nil {
+		return model.Location{}, constant.ErrNotFound
+	}
+
+	location.ID = locationID
+	location.UpdatedAt = util.Now()
+	err = service.LocationRepository.Update(ctx, location)
+	if err != nil {
+		return model.Location{}, err
+	}
+
+	return service.LocationRepository.FindByID(ctx, locationID)
+}
+
+func (service *LocationService) Delete(
+	ctx context.Context,
+	id string,
+) error {
+	locationID, err := uuid.Parse(id)
+	if err != nil {
+		return constant.ErrNotFound
+	}
+
+	return service.LocationRepository.Delete(ctx, locationID, util.Now())
+}
+
+// FindStocks lists the stock held at the location in the path.
+func (service *LocationService) FindStocks(
+	ctx context.Context,
+	id string,
+	query model.SearchLocationStockQuery,
+) ([]model.LocationStock, error) {
+	location, err := service.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	query.LocationID = location.ID
+	return service.LocationRepository.FindStocks(ctx, query)
+}
+
+// FindProductStocks is the stock of the product in the path at every
+// location.
+func (service *LocationService) FindProductStocks(
+	ctx context.Context,
+	id string,
+) ([]model.LocationStock, error) {
+	productID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, constant.ErrNotFound
+	}
+
+	_, err = service.ProductRepository.FindByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.LocationRepository.FindProductStocks(ctx, productID)
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/repository"
+)
+
+type LoyaltyService struct {
+	LoyaltyRepository  *repository.LoyaltyRepository
+	CustomerRepository *repository.CustomerRepository
+}
+
+func NewLoyaltyService(
+	loyaltyRepository *repository.LoyaltyRepository,
+	customerRepository *repository.CustomerRepository,
+) *LoyaltyService {
+	return &LoyaltyService{
+		LoyaltyRepository:  loyaltyRepository,
+		CustomerRepository: customerRepository,
+	}
+}
+
+// GetPoints returns the current balance of the customer together with a
+// page of their ledger, newest first.
+func (service *LoyaltyService) GetPoints(
+	ctx context.Context,
+	id string,
+	query model.SearchLoyaltyTransactionQuery,
+) (model.LoyaltyPointsBody, error) {
+	customerID, err := uuid.Parse(id)
+	if err != nil {
+		return model.LoyaltyPointsBody{}, constant.ErrNotFound
+	}
+
+	_, err = service.CustomerRepository.FindByID(
+		ctx,
+		customerID,
+	)
+	if err != nil {
+		return model.LoyaltyPointsBody{}, err
+	}
+
+	balance, err := service.LoyaltyRepository.GetBalance(
+		ctx,
+		customerID,
+	)
+	if err != nil {
+		return model.LoyaltyPointsBody{}, err
+	}
+
+	query.CustomerID = customerID
+	transactions, err := service.LoyaltyRepository.FindTransactions(
+		ctx,
+		query,
+	)
+	if err != nil {
+		return model.LoyaltyPointsBody{}, err
+	}
+
+	body := model.LoyaltyPointsBody{
+		Balance: balance,
+		Transactions: make(
+			[]model.LoyaltyTransactionBody,
+			0,
+			len(transactions),
+		),
+	}
+	for _, transaction := range transactions {
+		body.Transactions = append(
+			body.Transactions,
+			transaction.ToResponseBody(),
+		)
+	}
+
+	return body, nil
+}
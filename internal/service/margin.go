@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/repository"
+)
+
+type MarginService struct {
+	MarginRepository *repository.MarginRepository
+}
+
+func NewMarginService(
+	marginRepository *repository.MarginRepository,
+) *MarginService {
+	return &MarginService{
+		MarginRepository: marginRepository,
+	}
+}
+
+func (service *MarginService) FindByProduct(
+	ctx context.Context,
+	query model.ProductMarginQuery,
+) ([]model.ProductMargin, error) {
+	return service.MarginRepository.FindByProduct(ctx, query)
+}
+
+func (service *MarginService) FindByCategory(
+	ctx context.Context,
+	query model.DateRangeQuery,
+) ([]model.CategoryMargin, error) {
+	return service.MarginRepository.FindByCategory(ctx, query)
+}
+
+func (service *MarginService) FindTrend(
+	ctx context.Context,
+	query model.MarginTrendQuery,
+) ([]model.PeriodMargin, error) {
+	return service.MarginRepository.FindTrend(ctx, query)
+}
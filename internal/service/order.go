@@ -2,29 +2,66 @@ package service
 
 import (
 	"context"
+	"errors"
 	"log"
+	"math"
 
 	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/config"
 	"github.com/nozzlium/eniqilo_store/internal/constant"
 	"github.com/nozzlium/eniqilo_store/internal/model"
 	"github.com/nozzlium/eniqilo_store/internal/repository"
+	"github.com/nozzlium/eniqilo_store/internal/util"
 )
 
 type OrderService struct {
-	orderRepository    *repository.OrderRepository
-	productRepository  *repository.ProductRepository
-	customerRepository *repository.CustomerRepository
+	orderRepository       *repository.OrderRepository
+	productRepository     *repository.ProductRepository
+	customerRepository    *repository.CustomerRepository
+	promotionRepository   *repository.PromotionRepository
+	couponRepository      *repository.CouponRepository
+	taxRateRepository     *repository.TaxRateRepository
+	shiftRepository       *repository.ShiftRepository
+	locationRepository    *repository.LocationRepository
+	stockCountRepository  *repository.StockCountRepository
+	productPackRepository *repository.ProductPackRepository
+	loyalty               config.LoyaltyConfig
+	tax                   config.TaxConfig
+	shift                 config.ShiftConfig
+	count                 config.StockCountConfig
 }
 
 func NewOrderService(
 	orderRepository *repository.OrderRepository,
 	productRepository *repository.ProductRepository,
 	customerRepository *repository.CustomerRepository,
+	promotionRepository *repository.PromotionRepository,
+	couponRepository *repository.CouponRepository,
+	taxRateRepository *repository.TaxRateRepository,
+	shiftRepository *repository.ShiftRepository,
+	locationRepository *repository.LocationRepository,
+	stockCountRepository *repository.StockCountRepository,
+	productPackRepository *repository.ProductPackRepository,
+	loyalty config.LoyaltyConfig,
+	tax config.TaxConfig,
+	shift config.ShiftConfig,
+	count config.StockCountConfig,
 ) *OrderService {
 	return &OrderService{
-		orderRepository:    orderRepository,
-		productRepository:  productRepository,
-		customerRepository: customerRepository,
+		orderRepository:       orderRepository,
+		productRepository:     productRepository,
+		customerRepository:    customerRepository,
+		promotionRepository:   promotionRepository,
+		couponRepository:      couponRepository,
+		taxRateRepository:     taxRateRepository,
+		shiftRepository:       shiftRepository,
+		locationRepository:    locationRepository,
+		stockCountRepository:  stockCountRepository,
+		productPackRepository: productPackRepository,
+		loyalty:               loyalty,
+		tax:                   tax,
+		shift:                 shift,
+		count:                 count,
 	}
 }
 
@@ -40,6 +77,16 @@ func (service *OrderService) Create(
 		return model.Order{}, err
 	}
 
+	err = service.assignShift(ctx, &order)
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	err = service.assignLocation(ctx, &order)
+	if err != nil {
+		return model.Order{}, err
+	}
+
 	stringIds := make(
 		[]uuid.UUID,
 		0,
@@ -59,59 +106,203 @@ func (service *OrderService) Create(
 		return model.Order{}, err
 	}
 
-	var actualTotal float64 = 0
-	updatedProducts := make(
-		[]model.Product,
-		0,
-		len(order.ProductOrders),
+	err = service.convertPacks(ctx, &order, products)
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	// bundles are sold out of the stock of their components
+	bundleIDs := make([]uuid.UUID, 0)
+	for _, product := range products {
+		if product.IsBundle {
+			bundleIDs = append(bundleIDs, product.ID)
+		}
+	}
+	bundles := make(map[uuid.UUID][]model.BundleComponent)
+	if len(bundleIDs) > 0 {
+		bundles, err = service.productRepository.FindBundleComponents(
+			ctx,
+			bundleIDs,
+		)
+		if err != nil {
+			return model.Order{}, err
+		}
+	}
+
+	stockIDs := make([]uuid.UUID, 0, len(stringIds))
+	for _, productID := range stringIds {
+		if !products[productID].IsBundle {
+			stockIDs = append(stockIDs, productID)
+		}
+	}
+	for _, components := range bundles {
+		for _, component := range components {
+			stockIDs = append(stockIDs, component.ProductID)
+		}
+	}
+
+	if service.count.FreezeSales {
+		counting, err := service.stockCountRepository.IsCounting(
+			ctx,
+			order.LocationID,
+			stockIDs,
+		)
+		if err != nil {
+			return model.Order{}, err
+		}
+		if counting {
+			return model.Order{}, constant.ErrStockCountInProgress
+		}
+	}
+
+	// the same product may be on more than one line
+	stockLevels, err := service.locationRepository.FindStockLevels(
+		ctx,
+		order.LocationID,
+		stockIDs,
 	)
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	var actualTotal float64 = 0
 
 	order.ID, err = uuid.NewV7()
 	if err != nil {
 		return model.Order{}, err
 	}
 
+	orderedProducts := make([]model.Product, 0, len(products))
+	for _, product := range products {
+		orderedProducts = append(orderedProducts, product)
+	}
+
+	order.CreatedAt = util.Now()
+	promotions, err := service.promotionRepository.FindRunning(
+		ctx,
+		order.CreatedAt,
+		orderedProducts,
+	)
+	if err != nil {
+		return model.Order{}, err
+	}
+
 	for i, orderProduct := range order.ProductOrders {
 		tempProd, ok := products[orderProduct.ProductID]
 		if !ok {
 			return model.Order{}, constant.ErrNotFound
 		}
 
-		if tempProd.Stock == 0 ||
-			tempProd.Stock < orderProduct.Quantity {
-			return model.Order{}, constant.ErrInsufficientStock
+		cost := tempProd.Cost
+		if tempProd.IsBundle {
+			components := bundles[orderProduct.ProductID]
+			if len(components) == 0 {
+				return model.Order{}, constant.ErrInsufficientStock
+			}
+			for _, component := range components {
+				quantity := util.RoundQuantity(
+					component.Quantity*orderProduct.Quantity,
+					util.QuantityDecimals,
+				)
+				if component.IsDeleted ||
+					stockLevels[component.ProductID] < quantity {
+					return model.Order{}, constant.ErrInsufficientStock
+				}
+				stockLevels[component.ProductID] = util.RoundQuantity(
+					stockLevels[component.ProductID]-quantity,
+					util.QuantityDecimals,
+				)
+			}
+			cost = model.BundleCost(components)
+		} else {
+			if stockLevels[orderProduct.ProductID] < orderProduct.Quantity {
+				return model.Order{}, constant.ErrInsufficientStock
+			}
+			stockLevels[orderProduct.ProductID] = util.RoundQuantity(
+				stockLevels[orderProduct.ProductID]-orderProduct.Quantity,
+				util.QuantityDecimals,
+			)
 		}
 
-		itemTotal := float64(
-			orderProduct.Quantity,
-		) * tempProd.Price
+		// rounded to cents as the line is stored, a fraction of a unit may
+		// come to a fraction of a cent
+		itemTotal := util.RoundMoney(
+			orderProduct.Quantity * tempProd.Price,
+		)
 
-		tempProd.Stock = tempProd.Stock - orderProduct.Quantity
-		updatedProducts = append(
-			updatedProducts,
+		promotion, discount := model.BestPromotion(
+			promotions,
 			tempProd,
+			orderProduct.Quantity,
+			order.CreatedAt,
 		)
+		if promotion != nil {
+			orderProduct.PromotionID = &promotion.ID
+			orderProduct.DiscountAmount = discount
+		}
 
 		orderProduct.OrderID = order.ID
 		orderProduct.Price = tempProd.Price
+		orderProduct.Cost = cost
 		orderProduct.TotalPrice = itemTotal
 		order.ProductOrders[i] = orderProduct
 
-		actualTotal += itemTotal
+		order.GrossPrice += itemTotal
+		order.DiscountAmount += discount
+	}
+
+	order.GrossPrice = util.RoundMoney(order.GrossPrice)
+	order.DiscountAmount = util.RoundMoney(order.DiscountAmount)
+
+	if order.CouponCode != "" {
+		err := service.applyCoupon(ctx, &order, products)
+		if err != nil {
+			return model.Order{}, err
+		}
+	}
+
+	taxRates, err := service.taxRateRepository.FindForProducts(
+		ctx,
+		orderedProducts,
+	)
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	service.applyTax(&order, products, taxRates)
+	actualTotal = order.TotalPrice
+
+	for i, orderProduct := range order.ProductOrders {
+		if components, ok := bundles[orderProduct.ProductID]; ok {
+			order.ProductOrders[i].Components = model.AllocateBundle(
+				orderProduct,
+				components,
+			)
+		}
 	}
 
-	if order.PaymentAmount < actualTotal {
-		return model.Order{}, constant.ErrInsufficientFund
+	err = service.applyPayments(&order)
+	if err != nil {
+		return model.Order{}, err
 	}
+	amountDue := util.RoundMoney(actualTotal - order.PointsAmount)
+
+	// points are only earned on what was not paid with points, the small
+	// epsilon keeps float error from rounding 7.0 down to 6
+	order.PointsEarned = int(math.Floor(
+		amountDue*service.loyalty.EarnRate + 1e-9,
+	))
 
-	if order.PaymentAmount-actualTotal != order.Change {
-		return model.Order{}, constant.ErrInvalidChange
+	order.LoyaltyTransactions, err = service.buildLoyaltyTransactions(
+		order,
+	)
+	if err != nil {
+		return model.Order{}, err
 	}
 
 	result, err := service.orderRepository.Save(
 		ctx,
 		order,
-		updatedProducts,
 	)
 	if err != nil {
 		return model.Order{}, err
@@ -120,16 +311,409 @@ func (service *OrderService) Create(
 	return result, nil
 }
 
+// convertPacks turns the quantities rung up in packs into units of the
+// product. Every quantity has to fit the unit of its product, a fraction of
+// a piece is refused.
+func (service *OrderService) convertPacks(
+	ctx context.Context,
+	order *model.Order,
+	products map[uuid.UUID]model.Product,
+) error {
+	packIDs := make([]uuid.UUID, 0)
+	for _, orderProduct := range order.ProductOrders {
+		if orderProduct.PackID != uuid.Nil {
+			packIDs = append(packIDs, orderProduct.PackID)
+		}
+	}
+
+	packs, err := findPacks(ctx, service.productPackRepository, packIDs)
+	if err != nil {
+		return err
+	}
+
+	for i, orderProduct := range order.ProductOrders {
+		quantity, err := packsToUnits(
+			packs,
+			orderProduct.ProductID,
+			orderProduct.PackID,
+			orderProduct.Quantity,
+		)
+		if err != nil {
+			return err
+		}
+
+		product, ok := products[orderProduct.ProductID]
+		if ok && !product.Unit.IsValidQuantity(quantity) {
+			return constant.ErrBadInput
+		}
+
+		order.ProductOrders[i].Quantity = quantity
+	}
+
+	return nil
+}
+
+// assignShift tags the order with the signed in cashier and their open
+// shift. The shift is checked again when the order is saved, in case it
+// was closed meanwhile.
+func (service *OrderService) assignShift(
+	ctx context.Context,
+	order *model.Order,
+) error {
+	// the order is made by the signed in cashier, a context without one
+	// did not come through the auth middleware
+	userID, ok := ctx.Value("userID").(string)
+	if !ok {
+		return constant.ErrUnauthorized
+	}
+	cashierID, err := uuid.Parse(userID)
+	if err != nil {
+		return constant.ErrUnauthorized
+	}
+	order.CreatedBy = &cashierID
+
+	shift, err := service.shiftRepository.FindOpenByCashier(
+		ctx,
+		cashierID,
+	)
+	if err != nil {
+		if !errors.Is(err, constant.ErrNotFound) {
+			return err
+		}
+		if service.shift.Required {
+			return constant.ErrNoOpenShift
+		}
+		return nil
+	}
+
+	order.ShiftID = &shift.ID
+	return nil
+}
+
+// assignLocation takes the stock of the order from the default location
+// when the terminal did not name one.
+func (service *OrderService) assignLocation(
+	ctx context.Context,
+	order *model.Order,
+) error {
+	if order.LocationID == uuid.Nil {
+		location, err := service.locationRepository.FindDefault(ctx)
+		if err != nil {
+			return err
+		}
+
+		order.LocationID = location.ID
+		return nil
+	}
+
+	_, err := service.locationRepository.FindByID(ctx, order.LocationID)
+	if err != nil {
+		if errors.Is(err, constant.ErrNotFound) {
+			return constant.ErrBadInput
+		}
+		return err
+	}
+
+	return nil
+}
+
+// applyPayments checks the tenders of the order against its total. Non-cash
+// tenders, points included, may not pay more than the total, cash pays the
+// rest and only cash is given change. Points asked for with redeemPoints
+// become a loyalty points tender.
+func (service *OrderService) applyPayments(order *model.Order) error {
+	if order.PointsRedeemed > 0 {
+		order.Payments = append(
+			order.Payments,
+			model.OrderPayment{
+				Method: model.PaymentLoyaltyPoints,
+				TenderedAmount: float64(
+					order.PointsRedeemed,
+				) * service.loyalty.PointValue,
+			},
+		)
+	}
+
+	var (
+		cashTendered, nonCash float64
+		payments              []model.OrderPayment
+	)
+	order.PointsRedeemed = 0
+	for _, payment := range order.Payments {
+		switch payment.Method {
+		case model.PaymentCash:
+			// cash tenders are counted together in a single payment
+			cashTendered += payment.TenderedAmount
+			continue
+		case model.PaymentLoyaltyPoints:
+			if service.loyalty.PointValue <= 0 {
+				return constant.ErrBadInput
+			}
+
+			// the amount has to be worth a whole number of points
+			points := payment.TenderedAmount / service.loyalty.PointValue
+			if math.Abs(points-math.Round(points)) > 1e-6 {
+				return constant.ErrBadInput
+			}
+			order.PointsRedeemed += int(math.Round(points))
+		}
+
+		nonCash += payment.TenderedAmount
+		payment.Amount = payment.TenderedAmount
+		payments = append(payments, payment)
+	}
+
+	nonCash = util.RoundMoney(nonCash)
+	if nonCash > order.TotalPrice {
+		return constant.ErrTenderExceedsDue
+	}
+
+	cashDue := util.RoundMoney(order.TotalPrice - nonCash)
+	if cashTendered < cashDue {
+		return constant.ErrInsufficientFund
+	}
+
+	if util.RoundMoney(cashTendered-cashDue) != order.Change {
+		return constant.ErrInvalidChange
+	}
+
+	if cashTendered > 0 {
+		payments = append(payments, model.OrderPayment{
+			Method:         model.PaymentCash,
+			Amount:         cashDue,
+			TenderedAmount: cashTendered,
+		})
+	}
+
+	for i := range payments {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return err
+		}
+
+		payments[i].ID = id
+		payments[i].OrderID = order.ID
+		payments[i].CreatedAt = order.CreatedAt
+	}
+
+	order.Payments = payments
+	order.PointsAmount = util.RoundMoney(float64(
+		order.PointsRedeemed,
+	) * service.loyalty.PointValue)
+	order.PaymentAmount = util.RoundMoney(
+		cashTendered + nonCash - order.PointsAmount,
+	)
+
+	return nil
+}
+
+// applyTax computes the tax of every line after its discounts and sets the
+// totals of the order. With tax inclusive prices the tax is taken out of
+// the price, otherwise it is added on top. Rounding per line rounds each
+// line tax before summing them, rounding per invoice rounds the sum.
+func (service *OrderService) applyTax(
+	order *model.Order,
+	products map[uuid.UUID]model.Product,
+	taxRates []model.TaxRate,
+) {
+	var amount, tax float64
+	for i, orderProduct := range order.ProductOrders {
+		lineAmount := orderProduct.TotalPrice -
+			orderProduct.DiscountAmount -
+			orderProduct.CouponDiscount
+		rate := model.ResolveTaxRate(
+			taxRates,
+			products[orderProduct.ProductID],
+			service.tax.DefaultRate,
+		)
+
+		var lineTax float64
+		if service.tax.PricesIncludeTax {
+			lineTax = lineAmount * rate / (100 + rate)
+		} else {
+			lineTax = lineAmount * rate / 100
+		}
+
+		orderProduct.TaxRate = rate
+		orderProduct.TaxAmount = util.RoundMoney(lineTax)
+		order.ProductOrders[i] = orderProduct
+
+		if service.tax.Rounding == config.TaxRoundingPerInvoice {
+			tax += lineTax
+		} else {
+			tax += orderProduct.TaxAmount
+		}
+		amount += lineAmount
+	}
+
+	order.PricesIncludeTax = service.tax.PricesIncludeTax
+	order.TaxAmount = util.RoundMoney(tax)
+	order.Subtotal = util.RoundMoney(amount)
+	if order.PricesIncludeTax {
+		order.Subtotal = util.RoundMoney(order.Subtotal - order.TaxAmount)
+	}
+	order.TotalPrice = util.RoundMoney(order.Subtotal + order.TaxAmount)
+}
+
+// applyCoupon takes the coupon off the lines it covers, after their
+// promotions. The usage limits are checked again when the order is saved.
+func (service *OrderService) applyCoupon(
+	ctx context.Context,
+	order *model.Order,
+	products map[uuid.UUID]model.Product,
+) error {
+	coupon, err := service.couponRepository.FindByCode(
+		ctx,
+		order.CouponCode,
+	)
+	if err != nil {
+		if errors.Is(err, constant.ErrNotFound) {
+			return constant.ErrInvalidCoupon
+		}
+		return err
+	}
+
+	if !coupon.IsRunning(order.CreatedAt) {
+		return constant.ErrInvalidCoupon
+	}
+
+	if coupon.MaxUses != nil &&
+		coupon.UsedCount >= *coupon.MaxUses {
+		return constant.ErrCouponLimitReached
+	}
+
+	basketAmount := order.GrossPrice - order.DiscountAmount
+	if basketAmount < coupon.MinBasketAmount {
+		return constant.ErrInvalidCoupon
+	}
+
+	var eligibleAmount float64
+	for _, orderProduct := range order.ProductOrders {
+		if coupon.AppliesTo(products[orderProduct.ProductID]) {
+			eligibleAmount += orderProduct.TotalPrice -
+				orderProduct.DiscountAmount
+		}
+	}
+
+	if eligibleAmount <= 0 {
+		return constant.ErrInvalidCoupon
+	}
+
+	redemptionID, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+
+	order.CouponCode = coupon.Code
+	order.CouponDiscount = coupon.Discount(eligibleAmount)
+
+	// the coupon is spread over its lines by their amount so that their
+	// tax is computed after the coupon, the last line takes the rounding
+	remaining := order.CouponDiscount
+	lastEligible := -1
+	for i, orderProduct := range order.ProductOrders {
+		if !coupon.AppliesTo(products[orderProduct.ProductID]) {
+			continue
+		}
+
+		lineAmount := orderProduct.TotalPrice -
+			orderProduct.DiscountAmount
+		share := util.RoundMoney(
+			order.CouponDiscount * lineAmount / eligibleAmount,
+		)
+		order.ProductOrders[i].CouponDiscount = share
+		remaining -= share
+		lastEligible = i
+	}
+	order.ProductOrders[lastEligible].CouponDiscount = util.RoundMoney(
+		order.ProductOrders[lastEligible].CouponDiscount + remaining,
+	)
+	order.DiscountAmount = util.RoundMoney(
+		order.DiscountAmount + order.CouponDiscount,
+	)
+	order.CouponRedemption = &model.CouponRedemption{
+		ID:             redemptionID,
+		CouponID:       coupon.ID,
+		OrderID:        order.ID,
+		CustomerID:     order.CustomerID,
+		DiscountAmount: order.CouponDiscount,
+		CreatedAt:      order.CreatedAt,
+	}
+
+	return nil
+}
+
+func (service *OrderService) buildLoyaltyTransactions(
+	order model.Order,
+) ([]model.LoyaltyTransaction, error) {
+	var transactions []model.LoyaltyTransaction
+	for _, entry := range []struct {
+		transactionType model.LoyaltyTransactionType
+		points          int
+	}{
+		{model.LoyaltyRedeem, -order.PointsRedeemed},
+		{model.LoyaltyEarn, order.PointsEarned},
+	} {
+		if entry.points == 0 {
+			continue
+		}
+
+		id, err := uuid.NewV7()
+		if err != nil {
+			return nil, err
+		}
+
+		transactions = append(
+			transactions,
+			model.LoyaltyTransaction{
+				ID:         id,
+				CustomerID: order.CustomerID,
+				OrderID:    &order.ID,
+				Type:       entry.transactionType,
+				Points:     entry.points,
+				CreatedAt:  order.CreatedAt,
+			},
+		)
+	}
+
+	return transactions, nil
+}
+
+// Void cancels a completed order, see OrderRepository.Void.
+func (service *OrderService) Void(
+	ctx context.Context,
+	id string,
+) (model.OrderVoid, error) {
+	orderID, err := uuid.Parse(id)
+	if err != nil {
+		return model.OrderVoid{}, constant.ErrNotFound
+	}
+
+	reversalID, err := uuid.NewV7()
+	if err != nil {
+		return model.OrderVoid{}, err
+	}
+
+	return service.orderRepository.Void(
+		ctx,
+		model.OrderVoid{
+			ID:       orderID,
+			VoidedAt: util.Now(),
+		},
+		reversalID,
+	)
+}
+
 func (service *OrderService) Search(
 	ctx context.Context,
 	query model.SearchOrderQuery,
-) ([]model.OrderResponseBody, error) {
+) ([]model.OrderResponseBody, string, error) {
 	ids, err := service.orderRepository.GetOrderIDs(
 		ctx,
 		query,
 	)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	log.Printf("ids: %v", ids)
@@ -141,19 +725,50 @@ func (service *OrderService) Search(
 		},
 	)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
+	orders := make(
+		[]model.Order,
+		0,
+		len(uuids),
+	)
 	resOrders := make(
 		[]model.OrderResponseBody,
 		0,
 		len(uuids),
 	)
 	for _, id := range uuids {
+		orders = append(
+			orders,
+			orderMap[id],
+		)
 		resOrders = append(
 			resOrders,
 			orderMap[id].ToResponseBody(),
 		)
 	}
-	return resOrders, nil
+	return resOrders, query.NextCursor(orders), nil
+}
+
+func (service *OrderService) Export(
+	ctx context.Context,
+	query model.SearchOrderQuery,
+	fn func(row model.OrderExportRow) error,
+) error {
+	return service.orderRepository.Export(
+		ctx,
+		query,
+		fn,
+	)
+}
+
+func (service *OrderService) Count(
+	ctx context.Context,
+	query model.SearchOrderQuery,
+) (int64, bool, error) {
+	return service.orderRepository.Count(
+		ctx,
+		query,
+	)
 }
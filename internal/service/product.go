@@ -63,6 +63,25 @@ func (s ProductService) Save(ctx context.Context, product model.Product) (string
 	// }
 
 	product.ID = id
+	product.IsBundle = len(product.Components) > 0
+	if product.Unit == "" {
+		product.Unit = model.UnitPiece
+	}
+	if product.IsBundle {
+		// bundles are sold whole
+		if product.Unit != model.UnitPiece {
+			return "", "", constant.ErrBadInput
+		}
+		product.Stock, product.ReorderPoint, product.ReorderQuantity = 0, 0, 0
+		err = s.checkComponents(ctx, product)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	if !product.HasValidQuantities() {
+		return "", "", constant.ErrBadInput
+	}
+
 	product.CreatedAt = now
 	product.UpdatedAt = now
 	product.CreatedBy = uuid.MustParse(ctx.Value("userID").(string))
@@ -74,7 +93,7 @@ func (s ProductService) Save(ctx context.Context, product model.Product) (string
 	return id.String(), util.ToISO8601(now), nil
 }
 
-func (s ProductService) Update(ctx context.Context, id string, product model.Product) error {
+func (s ProductService) Update(ctx context.Context, id string, body model.UpdateProductBody) error {
 	now := util.Now()
 
 	uuidID, err := uuid.Parse(id)
@@ -90,6 +109,33 @@ func (s ProductService) Update(ctx context.Context, id string, product model.Pro
 		return fmt.Errorf("failed to find product: %v", err)
 	}
 
+	product := body.ToProduct(existingProduct)
+
+	// a product stays a bundle, or not one, for good
+	product.ID = uuidID
+	product.IsBundle = existingProduct.IsBundle
+	if product.Unit == "" {
+		product.Unit = existingProduct.Unit
+	}
+	if product.IsBundle {
+		if product.Unit != model.UnitPiece {
+			return constant.ErrBadInput
+		}
+		product.Stock, existingProduct.Stock = 0, 0
+		product.ReorderPoint, product.ReorderQuantity = 0, 0
+		if product.Components != nil {
+			err = s.checkComponents(ctx, product)
+			if err != nil {
+				return err
+			}
+		}
+	} else if product.Components != nil {
+		return constant.ErrBadInput
+	}
+	if !product.HasValidQuantities() {
+		return constant.ErrBadInput
+	}
+
 	err = existingProduct.CompareAndUpdate(product)
 	if err != nil {
 		return err
@@ -105,6 +151,43 @@ func (s ProductService) Update(ctx context.Context, id string, product model.Pro
 	return nil
 }
 
+// checkComponents makes sure the components of a bundle are products that
+// are not bundles themselves.
+func (s ProductService) checkComponents(
+	ctx context.Context,
+	bundle model.Product,
+) error {
+	if len(bundle.Components) == 0 {
+		return constant.ErrBadInput
+	}
+
+	componentIDs := make([]uuid.UUID, 0, len(bundle.Components))
+	for _, component := range bundle.Components {
+		if component.ProductID == bundle.ID {
+			return constant.ErrBadInput
+		}
+		componentIDs = append(componentIDs, component.ProductID)
+	}
+
+	components, err := s.repository.FindByIds(ctx, componentIDs)
+	if err != nil {
+		return err
+	}
+	if len(components) != len(componentIDs) {
+		return constant.ErrBadInput
+	}
+
+	for _, component := range bundle.Components {
+		product := components[component.ProductID]
+		if product.IsBundle ||
+			!product.Unit.IsValidQuantity(component.Quantity) {
+			return constant.ErrBadInput
+		}
+	}
+
+	return nil
+}
+
 func (s ProductService) Delete(ctx context.Context, id string) error {
 	now := util.Now()
 
@@ -122,3 +205,18 @@ func (s ProductService) Delete(ctx context.Context, id string) error {
 
 	return nil
 }
+
+func (s ProductService) Export(
+	ctx context.Context,
+	query model.SearchProductQuery,
+	fn func(product model.Product) error,
+) error {
+	return s.repository.Export(ctx, query, fn)
+}
+
+func (s ProductService) Count(
+	ctx context.Context,
+	query model.SearchProductQuery,
+) (int64, bool, error) {
+	return s.repository.Count(ctx, query)
+}
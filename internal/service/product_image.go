@@ -0,0 +1,216 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"log"
+	"mime"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/config"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/repository"
+	"github.com/nozzlium/eniqilo_store/internal/storage"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type ProductImageService struct {
+	ProductImageRepository *repository.ProductImageRepository
+	ProductRepository      *repository.ProductRepository
+	Storage                storage.Storage
+	Image                  config.ImageConfig
+}
+
+func NewProductImageService(
+	productImageRepository *repository.ProductImageRepository,
+	productRepository *repository.ProductRepository,
+	imageStorage storage.Storage,
+	imageConfig config.ImageConfig,
+) *ProductImageService {
+	return &ProductImageService{
+		ProductImageRepository: productImageRepository,
+		ProductRepository:      productRepository,
+		Storage:                imageStorage,
+		Image:                  imageConfig,
+	}
+}
+
+// Upload stores the image and a thumbnail of it and adds it after the other
+// images of the product in the path. The content type given with the upload
+// has to match what the data turns out to be.
+func (service *ProductImageService) Upload(
+	ctx context.Context,
+	id string,
+	contentType string,
+	data []byte,
+) (model.ProductImage, error) {
+	product, err := service.findProduct(ctx, id)
+	if err != nil {
+		return model.ProductImage{}, err
+	}
+
+	if int64(len(data)) > service.Image.MaxSize {
+		return model.ProductImage{}, constant.ErrImageTooLarge
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return model.ProductImage{}, constant.ErrUnsupportedImage
+	}
+	extension, ok := model.ImageFormats[mediaType]
+	if !ok || http.DetectContentType(data) != mediaType {
+		return model.ProductImage{}, constant.ErrUnsupportedImage
+	}
+
+	imageConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil ||
+		imageConfig.Width > util.MaxImageSide ||
+		imageConfig.Height > util.MaxImageSide {
+		return model.ProductImage{}, constant.ErrUnsupportedImage
+	}
+	decoded, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return model.ProductImage{}, constant.ErrUnsupportedImage
+	}
+
+	// thumbnails of PNGs and GIFs are PNGs, to keep their transparency
+	thumbnail, err := util.EncodeImage(
+		util.Thumbnail(decoded, service.Image.ThumbnailSize),
+		format,
+	)
+	if err != nil {
+		return model.ProductImage{}, err
+	}
+	thumbnailType, thumbnailExtension := "image/png", "png"
+	if format == "jpeg" {
+		thumbnailType, thumbnailExtension = "image/jpeg", "jpg"
+	}
+
+	productImage := model.ProductImage{
+		ProductID:   product.ID,
+		ContentType: mediaType,
+		Size:        len(data),
+		Width:       imageConfig.Width,
+		Height:      imageConfig.Height,
+	}
+	productImage.ID, err = uuid.NewV7()
+	if err != nil {
+		return model.ProductImage{}, err
+	}
+
+	prefix := "products/" + product.ID.String() + "/" + productImage.ID.String()
+	productImage.StorageKey = prefix + "." + extension
+	productImage.ThumbnailKey = prefix + "_thumb." + thumbnailExtension
+	productImage.URL = service.Storage.URL(productImage.StorageKey)
+	productImage.ThumbnailURL = service.Storage.URL(productImage.ThumbnailKey)
+
+	err = service.Storage.Put(ctx, productImage.StorageKey, mediaType, data)
+	if err != nil {
+		return model.ProductImage{}, err
+	}
+	err = service.Storage.Put(
+		ctx,
+		productImage.ThumbnailKey,
+		thumbnailType,
+		thumbnail,
+	)
+	if err != nil {
+		service.deleteFiles(ctx, productImage)
+		return model.ProductImage{}, err
+	}
+
+	productImage.CreatedAt = util.Now()
+	productImage.CreatedBy = uuid.MustParse(ctx.Value("userID").(string))
+	saved, err := service.ProductImageRepository.Save(ctx, productImage)
+	if err != nil {
+		service.deleteFiles(ctx, productImage)
+		return model.ProductImage{}, err
+	}
+
+	return saved, nil
+}
+
+func (service *ProductImageService) FindByProduct(
+	ctx context.Context,
+	id string,
+) ([]model.ProductImage, error) {
+	product, err := service.findProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.ProductImageRepository.FindByProduct(ctx, product.ID)
+}
+
+func (service *ProductImageService) Reorder(
+	ctx context.Context,
+	id string,
+	imageIDs []uuid.UUID,
+) ([]model.ProductImage, error) {
+	productID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, constant.ErrNotFound
+	}
+
+	return service.ProductImageRepository.Reorder(ctx, productID, imageIDs)
+}
+
+// Delete removes the image from the product, then its files from the
+// storage. Files that fail to delete are only logged, the image is gone
+// from the product either way.
+func (service *ProductImageService) Delete(
+	ctx context.Context,
+	id string,
+	imageID string,
+) error {
+	productID, err := uuid.Parse(id)
+	if err != nil {
+		return constant.ErrNotFound
+	}
+
+	imageUUID, err := uuid.Parse(imageID)
+	if err != nil {
+		return constant.ErrNotFound
+	}
+
+	productImage, err := service.ProductImageRepository.Delete(
+		ctx,
+		productID,
+		imageUUID,
+	)
+	if err != nil {
+		return err
+	}
+
+	service.deleteFiles(ctx, productImage)
+	return nil
+}
+
+func (service *ProductImageService) deleteFiles(
+	ctx context.Context,
+	productImage model.ProductImage,
+) {
+	for _, key := range []string{
+		productImage.StorageKey,
+		productImage.ThumbnailKey,
+	} {
+		if err := service.Storage.Delete(ctx, key); err != nil {
+			log.Printf("unable to delete image file %s: %v", key, err)
+		}
+	}
+}
+
+func (service *ProductImageService) findProduct(
+	ctx context.Context,
+	id string,
+) (model.Product, error) {
+	productID, err := uuid.Parse(id)
+	if err != nil {
+		return model.Product{}, constant.ErrNotFound
+	}
+
+	return service.ProductRepository.FindByID(ctx, productID)
+}
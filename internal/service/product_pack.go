@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/repository"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type ProductPackService struct {
+	ProductPackRepository *repository.ProductPackRepository
+	ProductRepository     *repository.ProductRepository
+}
+
+func NewProductPackService(
+	productPackRepository *repository.ProductPackRepository,
+	productRepository *repository.ProductRepository,
+) *ProductPackService {
+	return &ProductPackService{
+		ProductPackRepository: productPackRepository,
+		ProductRepository:     productRepository,
+	}
+}
+
+// Create adds a pack to the product in the path. It has to hold a quantity
+// the unit of the product allows, bundles have no packs.
+func (service *ProductPackService) Create(
+	ctx context.Context,
+	id string,
+	pack model.ProductPack,
+) (model.ProductPack, error) {
+	product, err := service.findProduct(ctx, id)
+	if err != nil {
+		return model.ProductPack{}, err
+	}
+
+	if product.IsBundle || !product.Unit.IsValidQuantity(pack.Quantity) {
+		return model.ProductPack{}, constant.ErrBadInput
+	}
+
+	pack.ID, err = uuid.NewV7()
+	if err != nil {
+		return model.ProductPack{}, err
+	}
+
+	pack.ProductID = product.ID
+	pack.CreatedAt = util.Now()
+	return service.ProductPackRepository.Save(ctx, pack)
+}
+
+func (service *ProductPackService) FindByProduct(
+	ctx context.Context,
+	id string,
+) ([]model.ProductPack, error) {
+	product, err := service.findProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.ProductPackRepository.FindByProduct(ctx, product.ID)
+}
+
+func (service *ProductPackService) Delete(
+	ctx context.Context,
+	id string,
+	packID string,
+) error {
+	productID, err := uuid.Parse(id)
+	if err != nil {
+		return constant.ErrNotFound
+	}
+
+	packUUID, err := uuid.Parse(packID)
+	if err != nil {
+		return constant.ErrNotFound
+	}
+
+	return service.ProductPackRepository.Delete(
+		ctx,
+		productID,
+		packUUID,
+		util.Now(),
+	)
+}
+
+func (service *ProductPackService) findProduct(
+	ctx context.Context,
+	id string,
+) (model.Product, error) {
+	productID, err := uuid.Parse(id)
+	if err != nil {
+		return model.Product{}, constant.ErrNotFound
+	}
+
+	return service.ProductRepository.FindByID(ctx, productID)
+}
+
+// findPacks loads the packs the quantities were given in, none when every
+// quantity is in units.
+func findPacks(
+	ctx context.Context,
+	productPackRepository *repository.ProductPackRepository,
+	packIDs []uuid.UUID,
+) (map[uuid.UUID]model.ProductPack, error) {
+	if len(packIDs) == 0 {
+		return map[uuid.UUID]model.ProductPack{}, nil
+	}
+
+	return productPackRepository.FindByIDs(ctx, packIDs)
+}
+
+// packsToUnits turns a quantity given in packs of the product into units,
+// a quantity without a pack is already in units. The pack has to be one of
+// the product and the quantity whole packs.
+func packsToUnits(
+	packs map[uuid.UUID]model.ProductPack,
+	productID uuid.UUID,
+	packID uuid.UUID,
+	quantity float64,
+) (float64, error) {
+	if packID == uuid.Nil {
+		return quantity, nil
+	}
+
+	pack, ok := packs[packID]
+	if !ok || pack.ProductID != productID {
+		return 0, constant.ErrBadInput
+	}
+
+	units, ok := pack.Units(quantity)
+	if !ok {
+		return 0, constant.ErrBadInput
+	}
+
+	return units, nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/repository"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type PromotionService struct {
+	PromotionRepository *repository.PromotionRepository
+	ProductRepository   *repository.ProductRepository
+}
+
+func NewPromotionService(
+	promotionRepository *repository.PromotionRepository,
+	productRepository *repository.ProductRepository,
+) *PromotionService {
+	return &PromotionService{
+		PromotionRepository: promotionRepository,
+		ProductRepository:   productRepository,
+	}
+}
+
+func (service *PromotionService) Create(
+	ctx context.Context,
+	promotion model.Promotion,
+) (model.Promotion, error) {
+	err := service.checkProduct(ctx, promotion)
+	if err != nil {
+		return model.Promotion{}, err
+	}
+
+	promotion.ID, err = uuid.NewV7()
+	if err != nil {
+		return model.Promotion{}, err
+	}
+
+	promotion.CreatedAt = util.Now()
+	promotion.UpdatedAt = promotion.CreatedAt
+	promotion.CreatedBy = uuid.MustParse(ctx.Value("userID").(string))
+	return service.PromotionRepository.Save(ctx, promotion)
+}
+
+func (service *PromotionService) Update(
+	ctx context.Context,
+	id string,
+	promotion model.Promotion,
+) (model.Promotion, error) {
+	promotionID, err := uuid.Parse(id)
+	if err != nil {
+		return model.Promotion{}, constant.ErrNotFound
+	}
+
+	existing, err := service.PromotionRepository.FindByID(
+		ctx,
+		promotionID,
+	)
+	if err != nil {
+		return model.Promotion{}, err
+	}
+
+	err = service.checkProduct(ctx, promotion)
+	if err != nil {
+		return model.Promotion{}, err
+	}
+
+	promotion.ID = existing.ID
+	promotion.CreatedAt = existing.CreatedAt
+	promotion.CreatedBy = existing.CreatedBy
+	promotion.UpdatedAt = util.Now()
+	return service.PromotionRepository.Update(ctx, promotion)
+}
+
+func (service *PromotionService) Delete(
+	ctx context.Context,
+	id string,
+) error {
+	promotionID, err := uuid.Parse(id)
+	if err != nil {
+		return constant.ErrNotFound
+	}
+
+	return service.PromotionRepository.Delete(
+		ctx,
+		promotionID,
+		util.Now(),
+	)
+}
+
+func (service *PromotionService) FindByID(
+	ctx context.Context,
+	id string,
+) (model.Promotion, error) {
+	promotionID, err := uuid.Parse(id)
+	if err != nil {
+		return model.Promotion{}, constant.ErrNotFound
+	}
+
+	return service.PromotionRepository.FindByID(ctx, promotionID)
+}
+
+func (service *PromotionService) Search(
+	ctx context.Context,
+	query model.SearchPromotionQuery,
+) ([]model.Promotion, error) {
+	return service.PromotionRepository.FindAll(ctx, query)
+}
+
+// checkProduct makes sure a product scoped promotion points to a product
+// that exists.
+func (service *PromotionService) checkProduct(
+	ctx context.Context,
+	promotion model.Promotion,
+) error {
+	if promotion.ProductID == nil {
+		return nil
+	}
+
+	products, err := service.ProductRepository.FindByIds(
+		ctx,
+		[]uuid.UUID{*promotion.ProductID},
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := products[*promotion.ProductID]; !ok {
+		return constant.ErrNotFound
+	}
+
+	return nil
+}
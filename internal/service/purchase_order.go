@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/config"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/repository"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type PurchaseOrderService struct {
+	PurchaseOrderRepository *repository.PurchaseOrderRepository
+	SupplierRepository      *repository.SupplierRepository
+	ProductRepository       *repository.ProductRepository
+	LocationRepository      *repository.LocationRepository
+	ProductPackRepository   *repository.ProductPackRepository
+	Cost                    config.CostConfig
+}
+
+func NewPurchaseOrderService(
+	purchaseOrderRepository *repository.PurchaseOrderRepository,
+	supplierRepository *repository.SupplierRepository,
+	productRepository *repository.ProductRepository,
+	locationRepository *repository.LocationRepository,
+	productPackRepository *repository.ProductPackRepository,
+	cost config.CostConfig,
+) *PurchaseOrderService {
+	return &PurchaseOrderService{
+		PurchaseOrderRepository: purchaseOrderRepository,
+		SupplierRepository:      supplierRepository,
+		ProductRepository:       productRepository,
+		LocationRepository:      locationRepository,
+		ProductPackRepository:   productPackRepository,
+		Cost:                    cost,
+	}
+}
+
+// Create orders the lines from the supplier, the supplier, the products and
+// the location have to exist. Without a location the delivery goes to the
+// default one.
+func (service *PurchaseOrderService) Create(
+	ctx context.Context,
+	po model.PurchaseOrder,
+) (model.PurchaseOrder, error) {
+	_, err := service.SupplierRepository.FindByID(ctx, po.SupplierID)
+	if err != nil {
+		if errors.Is(err, constant.ErrNotFound) {
+			return model.PurchaseOrder{}, constant.ErrBadInput
+		}
+		return model.PurchaseOrder{}, err
+	}
+
+	if po.LocationID == uuid.Nil {
+		location, err := service.LocationRepository.FindDefault(ctx)
+		if err != nil {
+			return model.PurchaseOrder{}, err
+		}
+		po.LocationID = location.ID
+	} else {
+		_, err := service.LocationRepository.FindByID(ctx, po.LocationID)
+		if err != nil {
+			if errors.Is(err, constant.ErrNotFound) {
+				return model.PurchaseOrder{}, constant.ErrBadInput
+			}
+			return model.PurchaseOrder{}, err
+		}
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(po.Lines))
+	for _, line := range po.Lines {
+		productIDs = append(productIDs, line.ProductID)
+	}
+	products, err := service.ProductRepository.FindByIds(ctx, productIDs)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+	if len(products) != len(productIDs) {
+		return model.PurchaseOrder{}, constant.ErrBadInput
+	}
+	// bundles are stocked through their components
+	for _, product := range products {
+		if product.IsBundle {
+			return model.PurchaseOrder{}, constant.ErrBadInput
+		}
+	}
+
+	packIDs := make([]uuid.UUID, 0)
+	for _, line := range po.Lines {
+		if line.PackID != uuid.Nil {
+			packIDs = append(packIDs, line.PackID)
+		}
+	}
+	packs, err := findPacks(ctx, service.ProductPackRepository, packIDs)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+	for i, line := range po.Lines {
+		quantity, err := packsToUnits(
+			packs,
+			line.ProductID,
+			line.PackID,
+			line.Quantity,
+		)
+		if err != nil {
+			return model.PurchaseOrder{}, err
+		}
+		if !products[line.ProductID].Unit.IsValidQuantity(quantity) {
+			return model.PurchaseOrder{}, constant.ErrBadInput
+		}
+
+		po.Lines[i].Quantity = quantity
+	}
+	po.TotalCost = po.ComputeTotalCost()
+
+	po.ID, err = uuid.NewV7()
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+
+	po.CreatedAt = util.Now()
+	po.CreatedBy = uuid.MustParse(ctx.Value("userID").(string))
+	po, err = service.PurchaseOrderRepository.Save(ctx, po)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+
+	return service.PurchaseOrderRepository.FindByID(ctx, po.ID)
+}
+
+func (service *PurchaseOrderService) Search(
+	ctx context.Context,
+	query model.SearchPurchaseOrderQuery,
+) ([]model.PurchaseOrder, error) {
+	return service.PurchaseOrderRepository.FindAll(ctx, query)
+}
+
+func (service *PurchaseOrderService) FindByID(
+	ctx context.Context,
+	id string,
+) (model.PurchaseOrder, error) {
+	poID, err := uuid.Parse(id)
+	if err != nil {
+		return model.PurchaseOrder{}, constant.ErrNotFound
+	}
+
+	return service.PurchaseOrderRepository.FindByID(ctx, poID)
+}
+
+// Receive takes in a full or partial delivery of the purchase order in the
+// path, see PurchaseOrderRepository.Receive.
+func (service *PurchaseOrderService) Receive(
+	ctx context.Context,
+	id string,
+	receipt model.PurchaseOrderReceipt,
+) (model.PurchaseOrder, error) {
+	poID, err := uuid.Parse(id)
+	if err != nil {
+		return model.PurchaseOrder{}, constant.ErrNotFound
+	}
+
+	err = service.convertReceiptPacks(ctx, &receipt)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+
+	receipt.PurchaseOrderID = poID
+	receipt.ReceivedAt = util.Now()
+	receipt.ReceivedBy = uuid.MustParse(ctx.Value("userID").(string))
+	receipt.AverageCost = service.Cost.Method == config.CostMethodAverage
+	_, err = service.PurchaseOrderRepository.Receive(ctx, receipt)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+
+	return service.PurchaseOrderRepository.FindByID(ctx, poID)
+}
+
+// convertReceiptPacks turns the quantities received in packs into units of
+// the products, each has to fit the unit of its product.
+func (service *PurchaseOrderService) convertReceiptPacks(
+	ctx context.Context,
+	receipt *model.PurchaseOrderReceipt,
+) error {
+	if len(receipt.Lines) == 0 {
+		return nil
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(receipt.Lines))
+	packIDs := make([]uuid.UUID, 0)
+	for _, line := range receipt.Lines {
+		productIDs = append(productIDs, line.ProductID)
+		if line.PackID != uuid.Nil {
+			packIDs = append(packIDs, line.PackID)
+		}
+	}
+
+	products, err := service.ProductRepository.FindByIds(ctx, productIDs)
+	if err != nil {
+		return err
+	}
+	packs, err := findPacks(ctx, service.ProductPackRepository, packIDs)
+	if err != nil {
+		return err
+	}
+
+	for i, line := range receipt.Lines {
+		quantity, err := packsToUnits(
+			packs,
+			line.ProductID,
+			line.PackID,
+			line.Quantity,
+		)
+		if err != nil {
+			return err
+		}
+		if !products[line.ProductID].Unit.IsValidQuantity(quantity) {
+			return constant.ErrBadInput
+		}
+
+		receipt.Lines[i].Quantity = quantity
+	}
+
+	return nil
+}
+
+func (service *PurchaseOrderService) Cancel(
+	ctx context.Context,
+	id string,
+) (model.PurchaseOrder, error) {
+	poID, err := uuid.Parse(id)
+	if err != nil {
+		return model.PurchaseOrder{}, constant.ErrNotFound
+	}
+
+	err = service.PurchaseOrderRepository.Cancel(ctx, poID, util.Now())
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+
+	return service.PurchaseOrderRepository.FindByID(ctx, poID)
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/repository"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type ReportService struct {
+	ReportRepository *repository.ReportRepository
+}
+
+func NewReportService(
+	reportRepository *repository.ReportRepository,
+) *ReportService {
+	return &ReportService{
+		ReportRepository: reportRepository,
+	}
+}
+
+// GetDaily returns the frozen report of a closed day, else computes it
+// from the orders as they are now.
+func (service *ReportService) GetDaily(
+	ctx context.Context,
+	day time.Time,
+) (model.DailyReport, error) {
+	report, err := service.ReportRepository.FindClosed(ctx, day)
+	if err == nil {
+		return report, nil
+	}
+	if !errors.Is(err, constant.ErrNotFound) {
+		return model.DailyReport{}, err
+	}
+
+	return service.ReportRepository.ComputeDaily(ctx, day)
+}
+
+// CloseDay freezes the report of a day that is over.
+func (service *ReportService) CloseDay(
+	ctx context.Context,
+	date string,
+) (model.DailyReport, error) {
+	day, err := util.ParseDay(date)
+	if err != nil {
+		return model.DailyReport{}, constant.ErrBadInput
+	}
+
+	// orders can still be made or voided on a day that is not over
+	now := util.Now()
+	if day.AddDate(0, 0, 1).After(now) {
+		return model.DailyReport{}, constant.ErrBadInput
+	}
+
+	return service.ReportRepository.Close(
+		ctx,
+		day,
+		now,
+		uuid.MustParse(ctx.Value("userID").(string)),
+	)
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/repository"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type ShiftService struct {
+	ShiftRepository *repository.ShiftRepository
+}
+
+func NewShiftService(
+	shiftRepository *repository.ShiftRepository,
+) *ShiftService {
+	return &ShiftService{
+		ShiftRepository: shiftRepository,
+	}
+}
+
+// Open starts a shift for the signed in cashier.
+func (service *ShiftService) Open(
+	ctx context.Context,
+	shift model.Shift,
+) (model.Shift, error) {
+	shift.CashierID = uuid.MustParse(ctx.Value("userID").(string))
+	_, err := service.ShiftRepository.FindOpenByCashier(
+		ctx,
+		shift.CashierID,
+	)
+	if err == nil {
+		return model.Shift{}, constant.ErrShiftAlreadyOpen
+	}
+	if !errors.Is(err, constant.ErrNotFound) {
+		return model.Shift{}, err
+	}
+
+	shift.ID, err = uuid.NewV7()
+	if err != nil {
+		return model.Shift{}, err
+	}
+
+	shift.OpenedAt = util.Now()
+	return service.ShiftRepository.Save(ctx, shift)
+}
+
+// FindCurrent is the open shift of the signed in cashier.
+func (service *ShiftService) FindCurrent(
+	ctx context.Context,
+) (model.Shift, error) {
+	shift, err := service.ShiftRepository.FindOpenByCashier(
+		ctx,
+		uuid.MustParse(ctx.Value("userID").(string)),
+	)
+	if err != nil {
+		return model.Shift{}, err
+	}
+
+	return service.ShiftRepository.FindByID(ctx, shift.ID)
+}
+
+func (service *ShiftService) FindByID(
+	ctx context.Context,
+	id string,
+) (model.Shift, error) {
+	shiftID, err := uuid.Parse(id)
+	if err != nil {
+		return model.Shift{}, constant.ErrNotFound
+	}
+
+	return service.ShiftRepository.FindByID(ctx, shiftID)
+}
+
+func (service *ShiftService) Search(
+	ctx context.Context,
+	query model.SearchShiftQuery,
+) ([]model.Shift, error) {
+	return service.ShiftRepository.FindAll(ctx, query)
+}
+
+func (service *ShiftService) AddCashMovement(
+	ctx context.Context,
+	id string,
+	movement model.CashMovement,
+) (model.CashMovement, error) {
+	shiftID, err := uuid.Parse(id)
+	if err != nil {
+		return model.CashMovement{}, constant.ErrNotFound
+	}
+
+	movement.ID, err = uuid.NewV7()
+	if err != nil {
+		return model.CashMovement{}, err
+	}
+
+	movement.ShiftID = shiftID
+	movement.CreatedAt = util.Now()
+	movement.CreatedBy = uuid.MustParse(ctx.Value("userID").(string))
+	return service.ShiftRepository.SaveCashMovement(ctx, movement)
+}
+
+// Close records the counted cash of the shift in the path against what
+// the drawer should hold, see ShiftRepository.Close.
+func (service *ShiftService) Close(
+	ctx context.Context,
+	id string,
+	shiftClose model.ShiftClose,
+) (model.Shift, error) {
+	shiftID, err := uuid.Parse(id)
+	if err != nil {
+		return model.Shift{}, constant.ErrNotFound
+	}
+
+	shiftClose.ID = shiftID
+	shiftClose.ClosedAt = util.Now()
+	shiftClose.ClosedBy = uuid.MustParse(ctx.Value("userID").(string))
+	shift, err := service.ShiftRepository.Close(ctx, shiftClose)
+	if err != nil {
+		return model.Shift{}, err
+	}
+
+	return service.ShiftRepository.FindByID(ctx, shift.ID)
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/notifier"
+	"github.com/nozzlium/eniqilo_store/internal/repository"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+// stockAlertBatchSize is how many alerts are sent per poll at most.
+const stockAlertBatchSize = 50
+
+type StockAlertService struct {
+	StockAlertRepository *repository.StockAlertRepository
+	Notifier             notifier.Notifier
+}
+
+func NewStockAlertService(
+	stockAlertRepository *repository.StockAlertRepository,
+	notifier notifier.Notifier,
+) *StockAlertService {
+	return &StockAlertService{
+		StockAlertRepository: stockAlertRepository,
+		Notifier:             notifier,
+	}
+}
+
+// DispatchAlerts sends the pending stock alerts every interval until ctx
+// is done. A failed alert is tried again on the next poll.
+func (service *StockAlertService) DispatchAlerts(
+	ctx context.Context,
+	interval time.Duration,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := service.dispatchPending(ctx)
+		if err != nil {
+			log.Printf("unable to dispatch stock alerts: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (service *StockAlertService) dispatchPending(
+	ctx context.Context,
+) error {
+	alerts, err := service.StockAlertRepository.FindPending(
+		ctx,
+		stockAlertBatchSize,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, alert := range alerts {
+		err := service.Notifier.NotifyLowStock(ctx, alert)
+		if err != nil {
+			log.Printf("unable to send stock alert %s: %v", alert.ID, err)
+			err = service.StockAlertRepository.MarkFailed(
+				ctx,
+				alert.ID,
+				err,
+			)
+		} else {
+			err = service.StockAlertRepository.MarkNotified(
+				ctx,
+				alert.ID,
+				util.Now(),
+			)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (service *StockAlertService) FindLowStock(
+	ctx context.Context,
+	query model.SearchLowStockQuery,
+) ([]model.LowStockProduct, error) {
+	return service.StockAlertRepository.FindLowStock(ctx, query)
+}
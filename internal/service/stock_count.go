@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/repository"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type StockCountService struct {
+	StockCountRepository *repository.StockCountRepository
+	LocationRepository   *repository.LocationRepository
+}
+
+func NewStockCountService(
+	stockCountRepository *repository.StockCountRepository,
+	locationRepository *repository.LocationRepository,
+) *StockCountService {
+	return &StockCountService{
+		StockCountRepository: stockCountRepository,
+		LocationRepository:   locationRepository,
+	}
+}
+
+// Open starts a count of the location, the default one when none is given.
+// A location has one open count at a time.
+func (service *StockCountService) Open(
+	ctx context.Context,
+	count model.StockCount,
+) (model.StockCount, error) {
+	if count.LocationID == uuid.Nil {
+		location, err := service.LocationRepository.FindDefault(ctx)
+		if err != nil {
+			return model.StockCount{}, err
+		}
+		count.LocationID = location.ID
+	} else {
+		_, err := service.LocationRepository.FindByID(ctx, count.LocationID)
+		if err != nil {
+			if errors.Is(err, constant.ErrNotFound) {
+				return model.StockCount{}, constant.ErrBadInput
+			}
+			return model.StockCount{}, err
+		}
+	}
+
+	var err error
+	count.ID, err = uuid.NewV7()
+	if err != nil {
+		return model.StockCount{}, err
+	}
+
+	count.CreatedAt = util.Now()
+	count.CreatedBy = uuid.MustParse(ctx.Value("userID").(string))
+	count, err = service.StockCountRepository.Save(ctx, count)
+	if err != nil {
+		return model.StockCount{}, err
+	}
+
+	return service.StockCountRepository.FindByID(ctx, count.ID)
+}
+
+func (service *StockCountService) Search(
+	ctx context.Context,
+	query model.SearchStockCountQuery,
+) ([]model.StockCount, error) {
+	return service.StockCountRepository.FindAll(ctx, query)
+}
+
+func (service *StockCountService) FindByID(
+	ctx context.Context,
+	id string,
+) (model.StockCount, error) {
+	countID, err := uuid.Parse(id)
+	if err != nil {
+		return model.StockCount{}, constant.ErrNotFound
+	}
+
+	return service.StockCountRepository.FindByID(ctx, countID)
+}
+
+// Record sets the counted quantities of the entries, or adds them to what
+// was counted when add is set.
+func (service *StockCountService) Record(
+	ctx context.Context,
+	id string,
+	entries []model.StockCountEntry,
+	add bool,
+) (model.StockCount, error) {
+	countID, err := uuid.Parse(id)
+	if err != nil {
+		return model.StockCount{}, constant.ErrNotFound
+	}
+
+	err = service.StockCountRepository.Record(
+		ctx,
+		model.StockCountRecord{
+			CountID:    countID,
+			Entries:    entries,
+			Add:        add,
+			RecordedAt: util.Now(),
+			RecordedBy: uuid.MustParse(ctx.Value("userID").(string)),
+		},
+	)
+	if err != nil {
+		return model.StockCount{}, err
+	}
+
+	return service.StockCountRepository.FindByID(ctx, countID)
+}
+
+// Post adjusts the stock to the count, see StockCountRepository.Post.
+func (service *StockCountService) Post(
+	ctx context.Context,
+	id string,
+	uncountedAsZero bool,
+) (model.StockCount, error) {
+	countID, err := uuid.Parse(id)
+	if err != nil {
+		return model.StockCount{}, constant.ErrNotFound
+	}
+
+	err = service.StockCountRepository.Post(
+		ctx,
+		countID,
+		uncountedAsZero,
+		util.Now(),
+		uuid.MustParse(ctx.Value("userID").(string)),
+	)
+	if err != nil {
+		return model.StockCount{}, err
+	}
+
+	return service.StockCountRepository.FindByID(ctx, countID)
+}
+
+func (service *StockCountService) Cancel(
+	ctx context.Context,
+	id string,
+) (model.StockCount, error) {
+	countID, err := uuid.Parse(id)
+	if err != nil {
+		return model.StockCount{}, constant.ErrNotFound
+	}
+
+	err = service.StockCountRepository.Cancel(
+		ctx,
+		countID,
+		util.Now(),
+		uuid.MustParse(ctx.Value("userID").(string)),
+	)
+	if err != nil {
+		return model.StockCount{}, err
+	}
+
+	return service.StockCountRepository.FindByID(ctx, countID)
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/repository"
+)
+
+type StockMovementService struct {
+	StockMovementRepository *repository.StockMovementRepository
+	ProductRepository       *repository.ProductRepository
+}
+
+func NewStockMovementService(
+	stockMovementRepository *repository.StockMovementRepository,
+	productRepository *repository.ProductRepository,
+) *StockMovementService {
+	return &StockMovementService{
+		StockMovementRepository: stockMovementRepository,
+		ProductRepository:       productRepository,
+	}
+}
+
+// FindByProduct is the stock history of the product in the path.
+func (service *StockMovementService) FindByProduct(
+	ctx context.Context,
+	id string,
+	query model.SearchStockMovementQuery,
+) ([]model.StockMovement, error) {
+	productID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, constant.ErrNotFound
+	}
+
+	_, err = service.ProductRepository.FindByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	query.ProductID = productID
+	return service.StockMovementRepository.FindAll(ctx, query)
+}
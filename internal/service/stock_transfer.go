@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/repository"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type StockTransferService struct {
+	StockTransferRepository *repository.StockTransferRepository
+	LocationRepository      *repository.LocationRepository
+	ProductRepository       *repository.ProductRepository
+}
+
+func NewStockTransferService(
+	stockTransferRepository *repository.StockTransferRepository,
+	locationRepository *repository.LocationRepository,
+	productRepository *repository.ProductRepository,
+) *StockTransferService {
+	return &StockTransferService{
+		StockTransferRepository: stockTransferRepository,
+		LocationRepository:      locationRepository,
+		ProductRepository:       productRepository,
+	}
+}
+
+// Create sends the stock of the lines from one location to the other, both
+// locations and the products have to exist.
+func (service *StockTransferService) Create(
+	ctx context.Context,
+	transfer model.StockTransfer,
+) (model.StockTransfer, error) {
+	for _, locationID := range []uuid.UUID{
+		transfer.FromLocationID,
+		transfer.ToLocationID,
+	} {
+		_, err := service.LocationRepository.FindByID(ctx, locationID)
+		if err != nil {
+			if errors.Is(err, constant.ErrNotFound) {
+				return model.StockTransfer{}, constant.ErrBadInput
+			}
+			return model.StockTransfer{}, err
+		}
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(transfer.Lines))
+	for _, line := range transfer.Lines {
+		productIDs = append(productIDs, line.ProductID)
+	}
+	products, err := service.ProductRepository.FindByIds(ctx, productIDs)
+	if err != nil {
+		return model.StockTransfer{}, err
+	}
+	if len(products) != len(productIDs) {
+		return model.StockTransfer{}, constant.ErrBadInput
+	}
+	// bundles are stocked through their components
+	for _, line := range transfer.Lines {
+		product := products[line.ProductID]
+		if product.IsBundle ||
+			!product.Unit.IsValidQuantity(line.Quantity) {
+			return model.StockTransfer{}, constant.ErrBadInput
+		}
+	}
+
+	transfer.ID, err = uuid.NewV7()
+	if err != nil {
+		return model.StockTransfer{}, err
+	}
+
+	transfer.CreatedAt = util.Now()
+	transfer.CreatedBy = uuid.MustParse(ctx.Value("userID").(string))
+	transfer, err = service.StockTransferRepository.Save(ctx, transfer)
+	if err != nil {
+		return model.StockTransfer{}, err
+	}
+
+	return service.StockTransferRepository.FindByID(ctx, transfer.ID)
+}
+
+func (service *StockTransferService) Search(
+	ctx context.Context,
+	query model.SearchStockTransferQuery,
+) ([]model.StockTransfer, error) {
+	return service.StockTransferRepository.FindAll(ctx, query)
+}
+
+func (service *StockTransferService) FindByID(
+	ctx context.Context,
+	id string,
+) (model.StockTransfer, error) {
+	transferID, err := uuid.Parse(id)
+	if err != nil {
+		return model.StockTransfer{}, constant.ErrNotFound
+	}
+
+	return service.StockTransferRepository.FindByID(ctx, transferID)
+}
+
+func (service *StockTransferService) Receive(
+	ctx context.Context,
+	id string,
+) (model.StockTransfer, error) {
+	transferID, err := uuid.Parse(id)
+	if err != nil {
+		return model.StockTransfer{}, constant.ErrNotFound
+	}
+
+	err = service.StockTransferRepository.Receive(
+		ctx,
+		transferID,
+		util.Now(),
+		uuid.MustParse(ctx.Value("userID").(string)),
+	)
+	if err != nil {
+		return model.StockTransfer{}, err
+	}
+
+	return service.StockTransferRepository.FindByID(ctx, transferID)
+}
+
+func (service *StockTransferService) Cancel(
+	ctx context.Context,
+	id string,
+) (model.StockTransfer, error) {
+	transferID, err := uuid.Parse(id)
+	if err != nil {
+		return model.StockTransfer{}, constant.ErrNotFound
+	}
+
+	err = service.StockTransferRepository.Cancel(
+		ctx,
+		transferID,
+		util.Now(),
+		uuid.MustParse(ctx.Value("userID").(string)),
+	)
+	if err != nil {
+		return model.StockTransfer{}, err
+	}
+
+	return service.StockTransferRepository.FindByID(ctx, transferID)
+}
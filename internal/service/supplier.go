@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/repository"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type SupplierService struct {
+	SupplierRepository *repository.SupplierRepository
+}
+
+func NewSupplierService(
+	supplierRepository *repository.SupplierRepository,
+) *SupplierService {
+	return &SupplierService{
+		SupplierRepository: supplierRepository,
+	}
+}
+
+func (service *SupplierService) Create(
+	ctx context.Context,
+	supplier model.Supplier,
+) (model.Supplier, error) {
+	var err error
+	supplier.ID, err = uuid.NewV7()
+	if err != nil {
+		return model.Supplier{}, err
+	}
+
+	supplier.CreatedAt = util.Now()
+	supplier.UpdatedAt = supplier.CreatedAt
+	supplier.CreatedBy = uuid.MustParse(ctx.Value("userID").(string))
+	return service.SupplierRepository.Save(ctx, supplier)
+}
+
+func (service *SupplierService) Search(
+	ctx context.Context,
+	query model.SearchSupplierQuery,
+) ([]model.Supplier, error) {
+	return service.SupplierRepository.FindAll(ctx, query)
+}
+
+func (service *SupplierService) FindByID(
+	ctx context.Context,
+	id string,
+) (model.Supplier, error) {
+	supplierID, err := uuid.Parse(id)
+	if err != nil {
+		return model.Supplier{}, constant.ErrNotFound
+	}
+
+	return service.SupplierRepository.FindByID(ctx, supplierID)
+}
+
+func (service *SupplierService) Update(
+	ctx context.Context,
+	id string,
+	supplier model.Supplier,
+) (model.Supplier, error) {
+	supplierID, err := uuid.Parse(id)
+	if err != nil {
+		return model.Supplier{}, constant.ErrNotFound
+	}
+
+	supplier.ID = supplierID
+	supplier.UpdatedAt = util.Now()
+	err = service.SupplierRepository.Update(ctx, supplier)
+	if err != nil {
+		return model.Supplier{}, err
+	}
+
+	return service.SupplierRepository.FindByID(ctx, supplierID)
+}
+
+func (service *SupplierService) Delete(
+	ctx context.Context,
+	id string,
+) error {
+	supplierID, err := uuid.Parse(id)
+	if err != nil {
+		return constant.ErrNotFound
+	}
+
+	return service.SupplierRepository.Delete(ctx, supplierID, util.Now())
+}
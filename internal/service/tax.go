@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+	"github.com/nozzlium/eniqilo_store/internal/model"
+	"github.com/nozzlium/eniqilo_store/internal/repository"
+	"github.com/nozzlium/eniqilo_store/internal/util"
+)
+
+type TaxRateService struct {
+	TaxRateRepository *repository.TaxRateRepository
+	ProductRepository *repository.ProductRepository
+}
+
+func NewTaxRateService(
+	taxRateRepository *repository.TaxRateRepository,
+	productRepository *repository.ProductRepository,
+) *TaxRateService {
+	return &TaxRateService{
+		TaxRateRepository: taxRateRepository,
+		ProductRepository: productRepository,
+	}
+}
+
+func (service *TaxRateService) Create(
+	ctx context.Context,
+	taxRate model.TaxRate,
+) (model.TaxRate, error) {
+	err := service.checkScope(ctx, taxRate)
+	if err != nil {
+		return model.TaxRate{}, err
+	}
+
+	taxRate.ID, err = uuid.NewV7()
+	if err != nil {
+		return model.TaxRate{}, err
+	}
+
+	taxRate.CreatedAt = util.Now()
+	taxRate.UpdatedAt = taxRate.CreatedAt
+	taxRate.CreatedBy = uuid.MustParse(ctx.Value("userID").(string))
+	return service.TaxRateRepository.Save(ctx, taxRate)
+}
+
+func (service *TaxRateService) Update(
+	ctx context.Context,
+	id string,
+	taxRate model.TaxRate,
+) (model.TaxRate, error) {
+	taxRateID, err := uuid.Parse(id)
+	if err != nil {
+		return model.TaxRate{}, constant.ErrNotFound
+	}
+
+	existing, err := service.TaxRateRepository.FindByID(ctx, taxRateID)
+	if err != nil {
+		return model.TaxRate{}, err
+	}
+
+	taxRate.ID = existing.ID
+	err = service.checkScope(ctx, taxRate)
+	if err != nil {
+		return model.TaxRate{}, err
+	}
+
+	taxRate.CreatedAt = existing.CreatedAt
+	taxRate.CreatedBy = existing.CreatedBy
+	taxRate.UpdatedAt = util.Now()
+	return service.TaxRateRepository.Update(ctx, taxRate)
+}
+
+func (service *TaxRateService) Delete(
+	ctx context.Context,
+	id string,
+) error {
+	taxRateID, err := uuid.Parse(id)
+	if err != nil {
+		return constant.ErrNotFound
+	}
+
+	return service.TaxRateRepository.Delete(
+		ctx,
+		taxRateID,
+		util.Now(),
+	)
+}
+
+func (service *TaxRateService) FindAll(
+	ctx context.Context,
+) ([]model.TaxRate, error) {
+	return service.TaxRateRepository.FindAll(ctx)
+}
+
+// checkScope allows a single rate per product or category, and only for a
+// product that exists.
+func (service *TaxRateService) checkScope(
+	ctx context.Context,
+	taxRate model.TaxRate,
+) error {
+	existing, err := service.TaxRateRepository.FindByScope(
+		ctx,
+		taxRate.ProductID,
+		taxRate.Category,
+	)
+	if err != nil && !errors.Is(err, constant.ErrNotFound) {
+		return err
+	}
+	if err == nil && existing.ID != taxRate.ID {
+		return constant.ErrConflict
+	}
+
+	if taxRate.ProductID == nil {
+		return nil
+	}
+
+	products, err := service.ProductRepository.FindByIds(
+		ctx,
+		[]uuid.UUID{*taxRate.ProductID},
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := products[*taxRate.ProductID]; !ok {
+		return constant.ErrNotFound
+	}
+
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/nozzlium/eniqilo_store/internal/constant"
 	"github.com/nozzlium/eniqilo_store/internal/model"
 	"github.com/nozzlium/eniqilo_store/internal/repository"
+	"github.com/nozzlium/eniqilo_store/internal/util"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -37,6 +38,16 @@ func (service *UserService) Register(
 	ctx context.Context,
 	user model.User,
 ) (model.RegisterResponse, error) {
+	phoneNumber, err := util.NormalizePhoneNumber(
+		user.PhoneNumber,
+	)
+	if err != nil {
+		return model.RegisterResponse{}, err
+	}
+	user.PhoneNumber = phoneNumber.E164
+	user.PhoneCountryCode = phoneNumber.CountryCode
+	user.PhoneRegion = phoneNumber.Region
+
 	generatedUUID, err := uuid.NewV7()
 	if err != nil {
 		return model.RegisterResponse{}, err
@@ -95,9 +106,16 @@ func (service *UserService) Login(
 	ctx context.Context,
 	user model.User,
 ) (model.LoginResponse, error) {
+	phoneNumber, err := util.NormalizePhoneNumber(
+		user.PhoneNumber,
+	)
+	if err != nil {
+		return model.LoginResponse{}, err
+	}
+
 	userResult, err := service.repo.FindByPhoneNumber(
 		ctx,
-		user.PhoneNumber,
+		phoneNumber.E164,
 	)
 	if err != nil {
 		return model.LoginResponse{}, err
@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nozzlium/eniqilo_store/internal/config"
+)
+
+// Storage keeps the uploaded product images. Keys are slash separated
+// paths, the same key always maps to the same URL.
+type Storage interface {
+	Put(
+		ctx context.Context,
+		key string,
+		contentType string,
+		data []byte,
+	) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// New returns the storage picked by IMAGE_STORAGE.
+func New(cfg config.ImageConfig) (Storage, error) {
+	switch cfg.Storage {
+	case config.ImageStorageLocal:
+		if cfg.Dir == "" {
+			return nil, fmt.Errorf("IMAGE_DIR is required by the local storage")
+		}
+		return NewLocalStorage(cfg.Dir, cfg.BaseURL), nil
+	case config.ImageStorageS3:
+		if cfg.S3Endpoint == "" || cfg.S3Bucket == "" {
+			return nil, fmt.Errorf("IMAGE_S3_ENDPOINT and IMAGE_S3_BUCKET are required by the s3 storage")
+		}
+		return NewS3Storage(cfg.S3Endpoint, cfg.S3Bucket, cfg.BaseURL), nil
+	default:
+		return nil, fmt.Errorf("unknown image storage %q", cfg.Storage)
+	}
+}
+
+// LocalStorage writes the images under a directory the app serves at
+// baseURL.
+type LocalStorage struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocalStorage(dir string, baseURL string) *LocalStorage {
+	return &LocalStorage{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+func (s *LocalStorage) Put(
+	ctx context.Context,
+	key string,
+	contentType string,
+	data []byte,
+) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// written aside and renamed, so the image is never served half written
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	err := os.Remove(s.path(key))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+func (s *LocalStorage) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
+
+// S3Storage stands in for an S3 compatible store. It puts and deletes the
+// objects with plain HTTP requests to endpoint/bucket/key, which takes a
+// bucket that allows anonymous writes, a MinIO bucket with a public policy
+// or a local fake for instance. Requests are not signed.
+type S3Storage struct {
+	client   *http.Client
+	endpoint string
+	bucket   string
+	baseURL  string
+}
+
+// NewS3Storage serves the images from baseURL, the public URL of the
+// bucket, or straight from the endpoint when it is left out.
+func NewS3Storage(
+	endpoint string,
+	bucket string,
+	baseURL string,
+) *S3Storage {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	if baseURL == "" || strings.HasPrefix(baseURL, "/") {
+		baseURL = endpoint + "/" + bucket
+	}
+
+	return &S3Storage{
+		client:   &http.Client{Timeout: 30 * time.Second},
+		endpoint: endpoint,
+		bucket:   bucket,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+func (s *S3Storage) Put(
+	ctx context.Context,
+	key string,
+	contentType string,
+	data []byte,
+) error {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPut,
+		s.objectURL(key),
+		bytes.NewReader(data),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	return s.do(req)
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodDelete,
+		s.objectURL(key),
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.do(req)
+}
+
+func (s *S3Storage) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+func (s *S3Storage) objectURL(key string) string {
+	return s.endpoint + "/" + s.bucket + "/" + key
+}
+
+// do sends the request, any status other than 2xx is a failure.
+func (s *S3Storage) do(req *http.Request) error {
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf(
+			"%s %s responded with %s",
+			req.Method,
+			req.URL.Path,
+			res.Status,
+		)
+	}
+
+	return nil
+}
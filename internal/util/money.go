@@ -0,0 +1,9 @@
+package util
+
+import "math"
+
+// RoundMoney rounds an amount to whole cents, so that sums of discounted
+// prices compare equal to what the cashier typed in.
+func RoundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,148 @@
+package util
+
+import (
+	"strings"
+
+	"github.com/nozzlium/eniqilo_store/internal/constant"
+)
+
+// PhoneNumber is a phone number in canonical E.164 form together with the
+// country it belongs to.
+type PhoneNumber struct {
+	// E164 is the canonical form, e.g. +6281234567890
+	E164 string
+	// CountryCode is the calling code without the plus sign, e.g. 62
+	CountryCode string
+	// Region is the ISO 3166-1 alpha-2 code, ZZ when the calling code is
+	// not in callingCodes
+	Region string
+}
+
+const unknownRegion = "ZZ"
+
+type callingCode struct {
+	region string
+	// length range of the national significant number
+	minLength int
+	maxLength int
+	// keepTrunkZero is set where a leading zero is part of the number
+	keepTrunkZero bool
+}
+
+// callingCodes covers the countries we expect customers and staff from.
+// Calling codes are prefix free, so at most one of them matches a number.
+var callingCodes = map[string]callingCode{
+	"1":   {region: "US", minLength: 10, maxLength: 10},
+	"7":   {region: "RU", minLength: 10, maxLength: 10},
+	"20":  {region: "EG", minLength: 8, maxLength: 10},
+	"27":  {region: "ZA", minLength: 9, maxLength: 9},
+	"31":  {region: "NL", minLength: 9, maxLength: 9},
+	"33":  {region: "FR", minLength: 9, maxLength: 9},
+	"34":  {region: "ES", minLength: 9, maxLength: 9},
+	"39":  {region: "IT", minLength: 6, maxLength: 11, keepTrunkZero: true},
+	"44":  {region: "GB", minLength: 9, maxLength: 10},
+	"49":  {region: "DE", minLength: 6, maxLength: 13},
+	"60":  {region: "MY", minLength: 8, maxLength: 10},
+	"61":  {region: "AU", minLength: 9, maxLength: 9},
+	"62":  {region: "ID", minLength: 8, maxLength: 12},
+	"63":  {region: "PH", minLength: 8, maxLength: 10},
+	"64":  {region: "NZ", minLength: 8, maxLength: 10},
+	"65":  {region: "SG", minLength: 8, maxLength: 8},
+	"66":  {region: "TH", minLength: 8, maxLength: 9},
+	"81":  {region: "JP", minLength: 9, maxLength: 10},
+	"82":  {region: "KR", minLength: 8, maxLength: 10},
+	"84":  {region: "VN", minLength: 9, maxLength: 10},
+	"86":  {region: "CN", minLength: 9, maxLength: 11},
+	"90":  {region: "TR", minLength: 10, maxLength: 10},
+	"91":  {region: "IN", minLength: 10, maxLength: 10},
+	"92":  {region: "PK", minLength: 9, maxLength: 10},
+	"670": {region: "TL", minLength: 7, maxLength: 8},
+	"673": {region: "BN", minLength: 7, maxLength: 7},
+	"852": {region: "HK", minLength: 8, maxLength: 8},
+	"853": {region: "MO", minLength: 8, maxLength: 8},
+	"855": {region: "KH", minLength: 8, maxLength: 9},
+	"856": {region: "LA", minLength: 8, maxLength: 10},
+	"886": {region: "TW", minLength: 8, maxLength: 9},
+	"966": {region: "SA", minLength: 9, maxLength: 9},
+	"971": {region: "AE", minLength: 8, maxLength: 9},
+}
+
+// NormalizePhoneNumber parses an international number written with an
+// optional mix of spaces, dashes, dots and parentheses and returns it in
+// E.164 form. The national number length is checked against the country of
+// the calling code when the country is known.
+func NormalizePhoneNumber(raw string) (PhoneNumber, error) {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case strings.HasPrefix(raw, "+"):
+		raw = raw[1:]
+	case strings.HasPrefix(raw, "00"):
+		raw = raw[2:]
+	default:
+		return PhoneNumber{}, constant.ErrInvalidPhoneNumber
+	}
+
+	var digits strings.Builder
+	for _, r := range raw {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case strings.ContainsRune(" -.()", r):
+		default:
+			return PhoneNumber{}, constant.ErrInvalidPhoneNumber
+		}
+	}
+
+	number := digits.String()
+	if number == "" || number[0] == '0' {
+		return PhoneNumber{}, constant.ErrInvalidPhoneNumber
+	}
+
+	for codeLength := 1; codeLength <= 3 && codeLength < len(number); codeLength++ {
+		code := number[:codeLength]
+		country, ok := callingCodes[code]
+		if !ok {
+			continue
+		}
+
+		national := number[codeLength:]
+		// +62 0812... is a common way of writing +62 812...
+		if !country.keepTrunkZero {
+			national = strings.TrimPrefix(national, "0")
+		}
+
+		if len(national) < country.minLength ||
+			len(national) > country.maxLength {
+			return PhoneNumber{}, constant.ErrInvalidPhoneNumber
+		}
+
+		return PhoneNumber{
+			E164:        "+" + code + national,
+			CountryCode: code,
+			Region:      country.region,
+		}, nil
+	}
+
+	// E.164 numbers are at most 15 digits long
+	if len(number) < 7 || len(number) > 15 {
+		return PhoneNumber{}, constant.ErrInvalidPhoneNumber
+	}
+
+	return PhoneNumber{
+		E164:   "+" + number,
+		Region: unknownRegion,
+	}, nil
+}
+
+// PhoneNumberDigits strips everything but digits, for prefix searches on
+// stored E.164 numbers.
+func PhoneNumberDigits(raw string) string {
+	var digits strings.Builder
+	for _, r := range raw {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+
+	return digits.String()
+}
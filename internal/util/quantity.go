@@ -0,0 +1,13 @@
+package util
+
+import "math"
+
+// QuantityDecimals is the precision quantities and stock are kept at.
+const QuantityDecimals = 3
+
+// RoundQuantity rounds a quantity to decimals places, so that sums and
+// differences of decimal quantities compare equal to what the database holds.
+func RoundQuantity(quantity float64, decimals int) float64 {
+	scale := math.Pow10(decimals)
+	return math.Round(quantity*scale) / scale
+}
@@ -59,16 +59,22 @@ func BuildQueryStringAndParamsWithoutLimit(
 	return baseQuery.String(), params
 }
 
-func DefaultPaginationBuilder(limit, offset int) (string, []interface{}) {
+const DefaultPageLimit = 5
 
-	defaultLimit := 5
-	defaultOffset := 0
+func PageLimit(limit int) int {
 	if limit > 0 {
-		defaultLimit = limit
+		return limit
 	}
+
+	return DefaultPageLimit
+}
+
+func DefaultPaginationBuilder(limit, offset int) (string, []interface{}) {
+
+	defaultOffset := 0
 	if offset > 0 {
 		defaultOffset = offset
 	}
 
-	return " limit $%d offset $%d ", []interface{}{defaultLimit, defaultOffset}
+	return " limit $%d offset $%d ", []interface{}{PageLimit(limit), defaultOffset}
 }
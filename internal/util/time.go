@@ -2,11 +2,92 @@ package util
 
 import "time"
 
+// location is the time zone of the store, business days start at its
+// midnight
+var location, _ = time.LoadLocation("Asia/Jakarta")
+
+// SetTimeZone changes the time zone of the store, name is an IANA zone
+// such as Asia/Jakarta.
+func SetTimeZone(name string) error {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return err
+	}
+
+	location = loc
+	return nil
+}
+
+func Location() *time.Location {
+	return location
+}
+
 func Now() time.Time {
-	loc, _ := time.LoadLocation("Asia/Jakarta")
-	return time.Now().In(loc)
+	return time.Now().In(location)
 }
 
 func ToISO8601(t time.Time) string {
 	return t.Format("2006-01-02T15:04:05.999Z")
 }
+
+const dateLayout = "2006-01-02"
+
+// ParseDate reads a date filter given either as a plain date or as a full
+// ISO 8601 timestamp. isDateOnly lets callers treat an upper bound as the
+// whole day.
+func ParseDate(value string) (t time.Time, isDateOnly bool, err error) {
+	t, err = time.Parse(dateLayout, value)
+	if err == nil {
+		return t, true, nil
+	}
+
+	t, err = time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+// ParseStoreTime reads a date or an ISO 8601 timestamp as a time in the
+// store time zone, a plain date as the midnight it starts at. Its wall clock
+// is what a timestamp column stores, the same as for util.Now.
+func ParseStoreTime(value string) (time.Time, error) {
+	t, err := time.ParseInLocation(dateLayout, value, location)
+	if err == nil {
+		return t, nil
+	}
+
+	t, err = time.Parse(time.RFC3339, value)
+	return t.In(location), err
+}
+
+// WallClock is the date and time of day of t read as UTC, which is how
+// timestamp columns give them back. Times saved from the store time zone
+// compare with what the database holds once both are taken through it.
+func WallClock(t time.Time) time.Time {
+	return time.Date(
+		t.Year(),
+		t.Month(),
+		t.Day(),
+		t.Hour(),
+		t.Minute(),
+		t.Second(),
+		t.Nanosecond(),
+		time.UTC,
+	)
+}
+
+// ParseDay reads a plain date as the midnight it starts at in the store
+// time zone.
+func ParseDay(value string) (time.Time, error) {
+	return time.ParseInLocation(dateLayout, value, location)
+}
+
+// StartOfDay is the midnight in the store time zone that t falls after.
+func StartOfDay(t time.Time) time.Time {
+	t = t.In(location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+}
+
+// FormatDay formats the date of t as is, times read back from the database
+// already are store wall clock times.
+func FormatDay(t time.Time) string {
+	return t.Format(dateLayout)
+}
@@ -1,17 +1,24 @@
 package main
 
 import (
+	"context"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/bytedance/sonic"
 	"github.com/caarlos0/env/v11"
 	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
 	"github.com/nozzlium/eniqilo_store/internal/client"
 	"github.com/nozzlium/eniqilo_store/internal/config"
 	"github.com/nozzlium/eniqilo_store/internal/handler"
 	"github.com/nozzlium/eniqilo_store/internal/middleware"
+	"github.com/nozzlium/eniqilo_store/internal/notifier"
 	"github.com/nozzlium/eniqilo_store/internal/repository"
 	"github.com/nozzlium/eniqilo_store/internal/service"
+	"github.com/nozzlium/eniqilo_store/internal/storage"
+	"github.com/nozzlium/eniqilo_store/internal/util"
 )
 
 func main() {
@@ -42,6 +49,11 @@ func setupApp(app *fiber.App) error {
 		return err
 	}
 
+	if err := util.SetTimeZone(cfg.TimeZone); err != nil {
+		log.Fatalf("invalid STORE_TIME_ZONE: %+v\n", err)
+		return err
+	}
+
 	db, err := client.InitDB(cfg.DB)
 	if err != nil {
 		log.Fatal(err)
@@ -61,6 +73,69 @@ func setupApp(app *fiber.App) error {
 	orderRepository := repository.NewOrderRepository(
 		db,
 	)
+	loyaltyRepository := repository.NewLoyaltyRepository(
+		db,
+	)
+	promotionRepository := repository.NewPromotionRepository(
+		db,
+	)
+	couponRepository := repository.NewCouponRepository(
+		db,
+	)
+	taxRateRepository := repository.NewTaxRateRepository(
+		db,
+	)
+	shiftRepository := repository.NewShiftRepository(
+		db,
+	)
+	reportRepository := repository.NewReportRepository(
+		db,
+	)
+	analyticsRepository := repository.NewAnalyticsRepository(
+		db,
+	)
+	stockAlertRepository := repository.NewStockAlertRepository(
+		db,
+	)
+	supplierRepository := repository.NewSupplierRepository(
+		db,
+	)
+	purchaseOrderRepository := repository.NewPurchaseOrderRepository(
+		db,
+	)
+	stockMovementRepository := repository.NewStockMovementRepository(
+		db,
+	)
+	marginRepository := repository.NewMarginRepository(
+		db,
+	)
+	locationRepository := repository.NewLocationRepository(
+		db,
+	)
+	stockTransferRepository := repository.NewStockTransferRepository(
+		db,
+	)
+	stockCountRepository := repository.NewStockCountRepository(
+		db,
+	)
+	productPackRepository := repository.NewProductPackRepository(
+		db,
+	)
+	productImageRepository := repository.NewProductImageRepository(
+		db,
+	)
+
+	stockAlertNotifier, err := notifier.New(cfg.Alert)
+	if err != nil {
+		log.Fatal(err)
+		return err
+	}
+
+	imageStorage, err := storage.New(cfg.Image)
+	if err != nil {
+		log.Fatal(err)
+		return err
+	}
 
 	// initiate services
 	userService := service.NewUserService(
@@ -74,13 +149,136 @@ func setupApp(app *fiber.App) error {
 	)
 	customerService := service.NewCustomerService(
 		customerRepository,
+		orderRepository,
+		loyaltyRepository,
+		couponRepository,
 	)
 	orderService := service.NewOrderService(
 		orderRepository,
 		productRepository,
 		customerRepository,
+		promotionRepository,
+		couponRepository,
+		taxRateRepository,
+		shiftRepository,
+		locationRepository,
+		stockCountRepository,
+		productPackRepository,
+		cfg.Loyalty,
+		cfg.Tax,
+		cfg.Shift,
+		cfg.StockCount,
+	)
+	loyaltyService := service.NewLoyaltyService(
+		loyaltyRepository,
+		customerRepository,
+	)
+	promotionService := service.NewPromotionService(
+		promotionRepository,
+		productRepository,
+	)
+	couponService := service.NewCouponService(
+		couponRepository,
+		productRepository,
+	)
+	taxRateService := service.NewTaxRateService(
+		taxRateRepository,
+		productRepository,
+	)
+	shiftService := service.NewShiftService(
+		shiftRepository,
+	)
+	reportService := service.NewReportService(
+		reportRepository,
+	)
+	analyticsService := service.NewAnalyticsService(
+		analyticsRepository,
+	)
+	supplierService := service.NewSupplierService(
+		supplierRepository,
+	)
+	purchaseOrderService := service.NewPurchaseOrderService(
+		purchaseOrderRepository,
+		supplierRepository,
+		productRepository,
+		locationRepository,
+		productPackRepository,
+		cfg.Cost,
+	)
+	stockMovementService := service.NewStockMovementService(
+		stockMovementRepository,
+		productRepository,
+	)
+	marginService := service.NewMarginService(
+		marginRepository,
+	)
+	locationService := service.NewLocationService(
+		locationRepository,
+		productRepository,
+	)
+	stockCountService := service.NewStockCountService(
+		stockCountRepository,
+		locationRepository,
+	)
+	stockTransferService := service.NewStockTransferService(
+		stockTransferRepository,
+		locationRepository,
+		productRepository,
+	)
+	productPackService := service.NewProductPackService(
+		productPackRepository,
+		productRepository,
+	)
+	productImageService := service.NewProductImageService(
+		productImageRepository,
+		productRepository,
+		imageStorage,
+		cfg.Image,
 	)
 
+	stockAlertService := service.NewStockAlertService(
+		stockAlertRepository,
+		stockAlertNotifier,
+	)
+
+	// with prefork only the parent process runs the background jobs. A
+	// pgx.Conn can't be shared between goroutines, so every job gets a
+	// connection of its own, and the alerts start half a poll late so the
+	// two don't hit the database at the same moment on startup.
+	if !fiber.IsChild() {
+		if cfg.Analytics.RefreshInterval > 0 {
+			err := startBackgroundJob(
+				db,
+				0,
+				func(ctx context.Context, conn *pgx.Conn) {
+					service.NewAnalyticsService(
+						repository.NewAnalyticsRepository(conn),
+					).RefreshRollups(ctx, cfg.Analytics.RefreshInterval)
+				},
+			)
+			if err != nil {
+				log.Fatal(err)
+				return err
+			}
+		}
+		if cfg.Alert.PollInterval > 0 {
+			err := startBackgroundJob(
+				db,
+				cfg.Alert.PollInterval/2,
+				func(ctx context.Context, conn *pgx.Conn) {
+					service.NewStockAlertService(
+						repository.NewStockAlertRepository(conn),
+						stockAlertNotifier,
+					).DispatchAlerts(ctx, cfg.Alert.PollInterval)
+				},
+			)
+			if err != nil {
+				log.Fatal(err)
+				return err
+			}
+		}
+	}
+
 	// initiate handlers
 	authHandler := handler.NewAuthHandler(
 		userService,
@@ -94,6 +292,64 @@ func setupApp(app *fiber.App) error {
 	orderHandler := handler.NewOrderHandler(
 		orderService,
 	)
+	loyaltyHandler := handler.NewLoyaltyHandler(
+		loyaltyService,
+	)
+	promotionHandler := handler.NewPromotionHandler(
+		promotionService,
+	)
+	couponHandler := handler.NewCouponHandler(
+		couponService,
+	)
+	taxRateHandler := handler.NewTaxRateHandler(
+		taxRateService,
+	)
+	shiftHandler := handler.NewShiftHandler(
+		shiftService,
+	)
+	reportHandler := handler.NewReportHandler(
+		reportService,
+	)
+	analyticsHandler := handler.NewAnalyticsHandler(
+		analyticsService,
+	)
+	stockAlertHandler := handler.NewStockAlertHandler(
+		stockAlertService,
+	)
+	supplierHandler := handler.NewSupplierHandler(
+		supplierService,
+	)
+	purchaseOrderHandler := handler.NewPurchaseOrderHandler(
+		purchaseOrderService,
+	)
+	stockMovementHandler := handler.NewStockMovementHandler(
+		stockMovementService,
+	)
+	marginHandler := handler.NewMarginHandler(
+		marginService,
+	)
+	locationHandler := handler.NewLocationHandler(
+		locationService,
+	)
+	stockTransferHandler := handler.NewStockTransferHandler(
+		stockTransferService,
+	)
+	stockCountHandler := handler.NewStockCountHandler(
+		stockCountService,
+	)
+	productPackHandler := handler.NewProductPackHandler(
+		productPackService,
+	)
+	productImageHandler := handler.NewProductImageHandler(
+		productImageService,
+	)
+
+	// the uploaded images are served by the app when they are kept on its
+	// disk, a base URL elsewhere serves them itself
+	if cfg.Image.Storage == config.ImageStorageLocal &&
+		strings.HasPrefix(cfg.Image.BaseURL, "/") {
+		app.Static(cfg.Image.BaseURL, cfg.Image.Dir)
+	}
 
 	v1 := app.Group("/v1")
 	auth := v1.Group("/staff")
@@ -120,6 +376,14 @@ func setupApp(app *fiber.App) error {
 		"",
 		productHandler.Search,
 	)
+	protectedProduct.Get(
+		"/export",
+		productHandler.Export,
+	)
+	protectedProduct.Get(
+		"/low-stock",
+		stockAlertHandler.GetLowStock,
+	)
 	protectedProduct.Post(
 		"",
 		productHandler.Create,
@@ -132,14 +396,58 @@ func setupApp(app *fiber.App) error {
 		"/:id",
 		productHandler.Delete,
 	)
+	protectedProduct.Get(
+		"/:id/stock-movements",
+		stockMovementHandler.GetByProduct,
+	)
+	protectedProduct.Get(
+		"/:id/stocks",
+		locationHandler.GetProductStocks,
+	)
+	protectedProduct.Get(
+		"/:id/packs",
+		productPackHandler.GetByProduct,
+	)
+	protectedProduct.Post(
+		"/:id/packs",
+		productPackHandler.Create,
+	)
+	protectedProduct.Delete(
+		"/:id/packs/:packId",
+		productPackHandler.Delete,
+	)
+	protectedProduct.Get(
+		"/:id/images",
+		productImageHandler.GetByProduct,
+	)
+	protectedProduct.Post(
+		"/:id/images",
+		productImageHandler.Upload,
+	)
+	protectedProduct.Put(
+		"/:id/images/order",
+		productImageHandler.Reorder,
+	)
+	protectedProduct.Delete(
+		"/:id/images/:imageId",
+		productImageHandler.Delete,
+	)
 	protectedProduct.Post(
 		"/checkout",
 		orderHandler.Create,
 	)
+	protectedProduct.Post(
+		"/checkout/:id/void",
+		orderHandler.Void,
+	)
 	protectedProduct.Get(
 		"/checkout/history",
 		orderHandler.Search,
 	)
+	protectedProduct.Get(
+		"/checkout/history/export",
+		orderHandler.Export,
+	)
 
 	customer := v1.Group(
 		"/customer",
@@ -155,6 +463,367 @@ func setupApp(app *fiber.App) error {
 		"",
 		customerHandler.GetCustomers,
 	)
+	customer.Get(
+		"/export",
+		customerHandler.Export,
+	)
+	customer.Get(
+		"/duplicates",
+		customerHandler.GetDuplicateCandidates,
+	)
+	customer.Get(
+		"/:id",
+		customerHandler.GetDetail,
+	)
+	customer.Get(
+		"/:id/orders",
+		orderHandler.SearchByCustomer,
+	)
+	customer.Get(
+		"/:id/points",
+		loyaltyHandler.GetPoints,
+	)
+	customer.Put(
+		"/:id",
+		customerHandler.Update,
+	)
+	customer.Delete(
+		"/:id",
+		customerHandler.Delete,
+	)
+	customer.Post(
+		"/:id/merge",
+		customerHandler.Merge,
+	)
+	customer.Post(
+		"/:id/erase",
+		customerHandler.Erase,
+	)
+	customer.Get(
+		"/:id/data",
+		customerHandler.ExportData,
+	)
+
+	promotion := v1.Group(
+		"/promotion",
+	).
+		Use(middleware.Protected()).
+		Use(middleware.SetEmailAndUserID())
+
+	promotion.Post(
+		"",
+		promotionHandler.Create,
+	)
+	promotion.Get(
+		"",
+		promotionHandler.Search,
+	)
+	promotion.Get(
+		"/:id",
+		promotionHandler.GetByID,
+	)
+	promotion.Put(
+		"/:id",
+		promotionHandler.Update,
+	)
+	promotion.Delete(
+		"/:id",
+		promotionHandler.Delete,
+	)
+
+	coupon := v1.Group(
+		"/coupon",
+	).
+		Use(middleware.Protected()).
+		Use(middleware.SetEmailAndUserID())
+
+	coupon.Post(
+		"",
+		couponHandler.Create,
+	)
+	coupon.Get(
+		"",
+		couponHandler.Search,
+	)
+	coupon.Get(
+		"/:id",
+		couponHandler.GetByID,
+	)
+	coupon.Put(
+		"/:id",
+		couponHandler.Update,
+	)
+	coupon.Delete(
+		"/:id",
+		couponHandler.Delete,
+	)
+
+	taxRate := v1.Group(
+		"/tax-rate",
+	).
+		Use(middleware.Protected()).
+		Use(middleware.SetEmailAndUserID())
+
+	taxRate.Post(
+		"",
+		taxRateHandler.Create,
+	)
+	taxRate.Get(
+		"",
+		taxRateHandler.GetAll,
+	)
+	taxRate.Put(
+		"/:id",
+		taxRateHandler.Update,
+	)
+	taxRate.Delete(
+		"/:id",
+		taxRateHandler.Delete,
+	)
+
+	shift := v1.Group(
+		"/shift",
+	).
+		Use(middleware.Protected()).
+		Use(middleware.SetEmailAndUserID())
+
+	shift.Post(
+		"",
+		shiftHandler.Open,
+	)
+	shift.Get(
+		"",
+		shiftHandler.Search,
+	)
+	shift.Get(
+		"/current",
+		shiftHandler.GetCurrent,
+	)
+	shift.Get(
+		"/:id",
+		shiftHandler.GetByID,
+	)
+	shift.Post(
+		"/:id/cash-movement",
+		shiftHandler.AddCashMovement,
+	)
+	shift.Post(
+		"/:id/close",
+		shiftHandler.Close,
+	)
+
+	supplier := v1.Group(
+		"/supplier",
+	).
+		Use(middleware.Protected()).
+		Use(middleware.SetEmailAndUserID())
+
+	supplier.Post(
+		"",
+		supplierHandler.Create,
+	)
+	supplier.Get(
+		"",
+		supplierHandler.Search,
+	)
+	supplier.Get(
+		"/:id",
+		supplierHandler.GetByID,
+	)
+	supplier.Put(
+		"/:id",
+		supplierHandler.Update,
+	)
+	supplier.Delete(
+		"/:id",
+		supplierHandler.Delete,
+	)
+
+	purchaseOrder := v1.Group(
+		"/purchase-order",
+	).
+		Use(middleware.Protected()).
+		Use(middleware.SetEmailAndUserID())
+
+	purchaseOrder.Post(
+		"",
+		purchaseOrderHandler.Create,
+	)
+	purchaseOrder.Get(
+		"",
+		purchaseOrderHandler.Search,
+	)
+	purchaseOrder.Get(
+		"/:id",
+		purchaseOrderHandler.GetByID,
+	)
+	purchaseOrder.Post(
+		"/:id/receive",
+		purchaseOrderHandler.Receive,
+	)
+	purchaseOrder.Post(
+		"/:id/cancel",
+		purchaseOrderHandler.Cancel,
+	)
+
+	location := v1.Group(
+		"/location",
+	).
+		Use(middleware.Protected()).
+		Use(middleware.SetEmailAndUserID())
+
+	location.Post(
+		"",
+		locationHandler.Create,
+	)
+	location.Get(
+		"",
+		locationHandler.Search,
+	)
+	location.Get(
+		"/:id",
+		locationHandler.GetByID,
+	)
+	location.Put(
+		"/:id",
+		locationHandler.Update,
+	)
+	location.Delete(
+		"/:id",
+		locationHandler.Delete,
+	)
+	location.Get(
+		"/:id/stock",
+		locationHandler.GetStocks,
+	)
+
+	stockTransfer := v1.Group(
+		"/stock-transfer",
+	).
+		Use(middleware.Protected()).
+		Use(middleware.SetEmailAndUserID())
+
+	stockTransfer.Post(
+		"",
+		stockTransferHandler.Create,
+	)
+	stockTransfer.Get(
+		"",
+		stockTransferHandler.Search,
+	)
+	stockTransfer.Get(
+		"/:id",
+		stockTransferHandler.GetByID,
+	)
+	stockTransfer.Post(
+		"/:id/receive",
+		stockTransferHandler.Receive,
+	)
+	stockTransfer.Post(
+		"/:id/cancel",
+		stockTransferHandler.Cancel,
+	)
+
+	stockCount := v1.Group(
+		"/stock-count",
+	).
+		Use(middleware.Protected()).
+		Use(middleware.SetEmailAndUserID())
+
+	stockCount.Post(
+		"",
+		stockCountHandler.Open,
+	)
+	stockCount.Get(
+		"",
+		stockCountHandler.Search,
+	)
+	stockCount.Get(
+		"/:id",
+		stockCountHandler.GetByID,
+	)
+	stockCount.Put(
+		"/:id/lines",
+		stockCountHandler.Record,
+	)
+	stockCount.Post(
+		"/:id/scan",
+		stockCountHandler.Scan,
+	)
+	stockCount.Post(
+		"/:id/post",
+		stockCountHandler.Post,
+	)
+	stockCount.Post(
+		"/:id/cancel",
+		stockCountHandler.Cancel,
+	)
+
+	report := v1.Group(
+		"/report",
+	).
+		Use(middleware.Protected()).
+		Use(middleware.SetEmailAndUserID())
+
+	report.Get(
+		"/daily",
+		reportHandler.GetDaily,
+	)
+	report.Post(
+		"/daily/:date/close",
+		reportHandler.CloseDay,
+	)
+	report.Get(
+		"/products/top",
+		analyticsHandler.GetTopProducts,
+	)
+	report.Get(
+		"/products/slow",
+		analyticsHandler.GetSlowMovers,
+	)
+	report.Get(
+		"/sales/heatmap",
+		analyticsHandler.GetHeatmap,
+	)
+	report.Get(
+		"/categories/trend",
+		analyticsHandler.GetCategoryTrends,
+	)
+	report.Get(
+		"/margin/products",
+		marginHandler.GetByProduct,
+	)
+	report.Get(
+		"/margin/categories",
+		marginHandler.GetByCategory,
+	)
+	report.Get(
+		"/margin/trend",
+		marginHandler.GetTrend,
+	)
+
+	return nil
+}
+
+// startBackgroundJob opens a connection for the job alone and runs it in its
+// own goroutine after delay. The connection is closed when the job returns.
+func startBackgroundJob(
+	db *pgx.Conn,
+	delay time.Duration,
+	run func(ctx context.Context, conn *pgx.Conn),
+) error {
+	ctx := context.Background()
+	conn, err := pgx.ConnectConfig(ctx, db.Config().Copy())
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer conn.Close(context.Background())
+
+		time.Sleep(delay)
+		run(ctx, conn)
+	}()
 
 	return nil
 }